@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"ads_backend/internal/client"
+	"ads_backend/internal/domain"
+)
+
+func runCreate(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet(a, "create", "")
+	title := fs.String("title", "", "title of the ad")
+	imageURL := fs.String("image-url", "", "URL of the ad's image")
+	placement := fs.String("placement", "", "home_screen, ride_summary or map_view")
+	ttl := fs.Int("ttl", 0, "minutes until the ad deactivates; the server default when not set")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usageErrorf("unexpected arguments %q", fs.Args())
+	}
+
+	input := client.AdInput{Title: *title, ImageURL: *imageURL, Placement: domain.Placement(*placement)}
+	if isSet(fs, "ttl") {
+		input.TTLMinutes = ttl
+	}
+	ad, err := a.client.CreateAd(ctx, input)
+	if err != nil {
+		return err
+	}
+	return a.printAds(ad)
+}
+
+func runGet(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet(a, "get", "<id>")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usageErrorf("expected one ad ID")
+	}
+
+	ad, err := a.client.GetAd(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	return a.printAds(ad)
+}
+
+func runList(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet(a, "list", "")
+	filters := addFilterFlags(fs)
+	limit := fs.Int("limit", 0, "ads per page; the server default when not set")
+	cursor := fs.String("cursor", "", "next_cursor of the previous page")
+	all := fs.Bool("all", false, "follow the cursors and list every page")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usageErrorf("unexpected arguments %q", fs.Args())
+	}
+
+	query := filters.query()
+	if *limit > 0 {
+		query.Set("limit", fmt.Sprint(*limit))
+	}
+	if *cursor != "" {
+		query.Set("cursor", *cursor)
+	}
+
+	if *all {
+		ads, err := a.client.ListAllAds(ctx, query)
+		if err != nil {
+			return err
+		}
+		return a.printPage(domain.AdPage{Ads: ads})
+	}
+	page, err := a.client.ListAds(ctx, query)
+	if err != nil {
+		return err
+	}
+	return a.printPage(page)
+}
+
+// runDeactivate deactivates every ad named, going on past failures. The
+// exit code is that of the last failure.
+func runDeactivate(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet(a, "deactivate", "<id>...")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return usageErrorf("expected at least one ad ID")
+	}
+
+	var deactivated []domain.Ad
+	var lastErr error
+	for _, id := range fs.Args() {
+		ad, err := a.client.DeactivateAd(ctx, id)
+		if err != nil {
+			fmt.Fprintf(a.stderr, "adsctl deactivate: %s: %v\n", id, err)
+			lastErr = err
+			continue
+		}
+		deactivated = append(deactivated, ad)
+	}
+	if len(deactivated) > 0 {
+		if err := a.printAds(deactivated...); err != nil {
+			return err
+		}
+	}
+	if lastErr != nil {
+		return reportedError{err: lastErr}
+	}
+	return nil
+}
+
+func runImport(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet(a, "import", "<file|->")
+	format := fs.String("format", "", "csv or ndjson; taken from the file extension when not set")
+	dryRun := fs.Bool("dry-run", false, "validate the file without creating any ad")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usageErrorf("expected one file, or - for stdin")
+	}
+	path := fs.Arg(0)
+	if *format == "" {
+		*format = formatFromPath(path)
+	}
+	if *format != client.FormatCSV && *format != client.FormatNDJSON {
+		return usageErrorf("-format must be csv or ndjson")
+	}
+
+	body := a.stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		body = file
+	}
+
+	result, err := a.client.ImportAds(ctx, body, *format, *dryRun)
+	var importErr *client.ImportError
+	if err != nil && !errors.As(err, &importErr) {
+		return err
+	}
+	if err := a.printImport(result); err != nil {
+		return err
+	}
+	if importErr != nil {
+		return reportedError{err: importErr}
+	}
+	return nil
+}
+
+func runExport(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet(a, "export", "")
+	filters := addFilterFlags(fs)
+	format := fs.String("format", client.FormatCSV, "csv or ndjson")
+	file := fs.String("file", "", "file to write to instead of stdout")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usageErrorf("unexpected arguments %q", fs.Args())
+	}
+	if *format != client.FormatCSV && *format != client.FormatNDJSON {
+		return usageErrorf("-format must be csv or ndjson")
+	}
+
+	w := a.stdout
+	if *file != "" {
+		f, err := os.Create(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	return a.client.ExportAds(ctx, w, *format, filters.query())
+}
+
+// filterFlags are the filters list and export share.
+type filterFlags struct {
+	status, placement, title, sort, order, createdAfter, createdBefore *string
+}
+
+func addFilterFlags(fs *flag.FlagSet) filterFlags {
+	return filterFlags{
+		status:        fs.String("status", "", "active or inactive"),
+		placement:     fs.String("placement", "", "home_screen, ride_summary or map_view"),
+		title:         fs.String("title", "", "only ads whose title contains this"),
+		sort:          fs.String("sort", "", "created_at, title or deactivate_at"),
+		order:         fs.String("order", "", "asc or desc"),
+		createdAfter:  fs.String("created-after", "", "RFC3339 timestamp"),
+		createdBefore: fs.String("created-before", "", "RFC3339 timestamp"),
+	}
+}
+
+func (f filterFlags) query() url.Values {
+	query := url.Values{}
+	for key, value := range map[string]string{
+		"status":         *f.status,
+		"placement":      *f.placement,
+		"title":          *f.title,
+		"sort":           *f.sort,
+		"order":          *f.order,
+		"created_after":  *f.createdAfter,
+		"created_before": *f.createdBefore,
+	} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	return query
+}
+
+func formatFromPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return client.FormatCSV
+	case ".ndjson", ".jsonl":
+		return client.FormatNDJSON
+	}
+	return ""
+}
+
+func isSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
@@ -0,0 +1,206 @@
+// Command adsctl manages ads through the HTTP API, for scripts and
+// operators.
+//
+//	adsctl [global flags] <command> [flags] [args]
+//
+// Run adsctl -h for the commands and their flags.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"ads_backend/internal/client"
+)
+
+// Exit codes, so scripts can tell failures apart without parsing output.
+const (
+	exitOK           = 0
+	exitError        = 1
+	exitUsage        = 2
+	exitNotFound     = 3
+	exitUnauthorized = 4
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+const usage = `Usage: adsctl [global flags] <command> [flags] [args]
+
+Commands:
+  create      create an ad
+  get         show an ad
+  list        list ads
+  deactivate  deactivate one or more ads
+  import      create ads from a CSV or NDJSON file
+  export      write ads as CSV or NDJSON
+
+Run adsctl <command> -h for a command's flags.
+
+Exit codes: 0 ok, 1 failed, 2 bad usage, 3 not found, 4 unauthorized or forbidden.
+
+Global flags:
+`
+
+// usageError is a mistake in the command line rather than a failed call.
+type usageError struct {
+	err error
+}
+
+func (e usageError) Error() string {
+	return e.err.Error()
+}
+
+// reportedError is a failure the command has printed already, so run only
+// needs its exit code.
+type reportedError struct {
+	err error
+}
+
+func (e reportedError) Error() string {
+	return e.err.Error()
+}
+
+func (e reportedError) Unwrap() error {
+	return e.err
+}
+
+func usageErrorf(format string, args ...any) error {
+	return usageError{err: fmt.Errorf(format, args...)}
+}
+
+// app is what every command runs with.
+type app struct {
+	client *client.Client
+	output string
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+type command func(ctx context.Context, a *app, args []string) error
+
+var commands = map[string]command{
+	"create":     runCreate,
+	"get":        runGet,
+	"list":       runList,
+	"deactivate": runDeactivate,
+	"import":     runImport,
+	"export":     runExport,
+}
+
+func main() {
+	os.Exit(run(context.Background(), os.Args[1:], os.Getenv, os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes the command line args and returns the exit code. Flags not
+// given fall back to the ADS_API_URL, ADS_API_KEY and ADS_TENANT
+// environment variables.
+func run(ctx context.Context, args []string, getenv func(string) string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("adsctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+	baseURL := fs.String("url", envOr(getenv, "ADS_API_URL", "http://localhost:8080"), "base URL of the API (ADS_API_URL)")
+	apiKey := fs.String("api-key", "", "API key or JWT to authenticate with (ADS_API_KEY)")
+	tenant := fs.String("tenant", getenv("ADS_TENANT"), "tenant to act on, sent as X-Tenant-ID (ADS_TENANT)")
+	output := fs.String("output", outputTable, "output format, table or json")
+	timeout := fs.Duration("timeout", 30*time.Second, "timeout for each request")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	// Read after parsing so -h does not print the key as a default.
+	if *apiKey == "" {
+		*apiKey = getenv("ADS_API_KEY")
+	}
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitUsage
+	}
+	name := fs.Arg(0)
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(stderr, "adsctl: unknown command %q\n", name)
+		fs.Usage()
+		return exitUsage
+	}
+	if *output != outputTable && *output != outputJSON {
+		fmt.Fprintln(stderr, "adsctl: -output must be table or json")
+		return exitUsage
+	}
+
+	a := &app{
+		client: client.New(*baseURL, *apiKey, *tenant, &http.Client{Timeout: *timeout}),
+		output: *output,
+		stdin:  stdin,
+		stdout: stdout,
+		stderr: stderr,
+	}
+	err := cmd(ctx, a, fs.Args()[1:])
+	var reported reportedError
+	if err != nil && !errors.Is(err, flag.ErrHelp) && !errors.As(err, &reported) {
+		fmt.Fprintf(stderr, "adsctl %s: %v\n", name, err)
+	}
+	return exitCode(err)
+}
+
+func exitCode(err error) int {
+	var usageErr usageError
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.As(err, &usageErr):
+		return exitUsage
+	}
+	switch client.StatusCode(err) {
+	case http.StatusNotFound:
+		return exitNotFound
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return exitUnauthorized
+	}
+	return exitError
+}
+
+func envOr(getenv func(string) string, key, fallback string) string {
+	if value := getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// newFlagSet builds a command's flag set. Its parse errors are reported
+// once, by the flag package, and come back as usage errors.
+func newFlagSet(a *app, name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(a.stderr, "Usage: adsctl %s [flags] %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return reportedError{err: usageError{err: err}}
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ads_backend/internal/ads_service"
+	"ads_backend/internal/auth"
+	"ads_backend/internal/domain"
+	"ads_backend/internal/featureflag"
+	http_server "ads_backend/internal/http"
+	"ads_backend/internal/idempotency"
+	"ads_backend/internal/metrics"
+	"ads_backend/internal/persistence"
+	"ads_backend/internal/search"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const adminAPIKey = "adsctl-admin-key"
+
+// startServer serves the ads API in process, wired as in cmd/main.go.
+func startServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	log := zap.NewNop()
+	keys := auth.NewKeyServiceFromConfig(auth.NewKeyStore(), auth.Config{AdminAPIKey: adminAPIKey})
+	authenticator := http_server.NewAuthenticator(log, keys, auth.NewTokenVerifierFromConfig(auth.JWTConfig{}))
+	flags := featureflag.NewStatic()
+	service := ads_service.NewService(log, persistence.NewAdRepository(), search.NewIndex(), persistence.NewAuditStore(), persistence.NewVersionStore(), metrics.New(), flags)
+
+	routes := []http_server.Route{
+		http_server.NewAdsHandler(log, service, flags),
+		http_server.NewExportHandler(log, service),
+		http_server.NewImportHandler(log, service),
+	}
+	mux := http_server.NewServeMux(routes, authenticator)
+	handler := http_server.NewAPIHandler(mux, authenticator, http_server.NewRateLimiter(1000, 1000), http_server.NewIdempotency(log, idempotency.NewMemoryStore(time.Hour)))
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return server
+}
+
+type result struct {
+	code   int
+	stdout string
+	stderr string
+}
+
+func runCLI(t *testing.T, server *httptest.Server, env map[string]string, args ...string) result {
+	t.Helper()
+	if env == nil {
+		env = map[string]string{"ADS_API_KEY": adminAPIKey}
+	}
+	env["ADS_API_URL"] = server.URL
+
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), args, func(key string) string { return env[key] }, strings.NewReader(""), &stdout, &stderr)
+	return result{code: code, stdout: stdout.String(), stderr: stderr.String()}
+}
+
+func createAd(t *testing.T, server *httptest.Server, title string) domain.Ad {
+	t.Helper()
+	res := runCLI(t, server, nil, "-output", "json", "create",
+		"-title", title, "-image-url", "https://example.com/a.png", "-placement", "home_screen", "-ttl", "60")
+	require.Equal(t, exitOK, res.code, res.stderr)
+
+	var ad domain.Ad
+	require.NoError(t, json.Unmarshal([]byte(res.stdout), &ad))
+	return ad
+}
+
+func TestAdLifecycle(t *testing.T) {
+	server := startServer(t)
+
+	ad := createAd(t, server, "Promo 2x1")
+	assert.NotEmpty(t, ad.ID)
+	assert.Equal(t, 60, ad.TTLMinutes)
+	assert.Equal(t, domain.StatusActive, ad.Status)
+
+	res := runCLI(t, server, nil, "get", ad.ID)
+	require.Equal(t, exitOK, res.code, res.stderr)
+	lines := strings.Split(strings.TrimSpace(res.stdout), "\n")
+	require.Len(t, lines, 2)
+	assert.Regexp(t, `^ID\s+TITLE\s+PLACEMENT\s+STATUS\s+CREATED\s+DEACTIVATES$`, lines[0])
+	assert.Contains(t, lines[1], ad.ID)
+	assert.Contains(t, lines[1], "Promo 2x1")
+
+	res = runCLI(t, server, nil, "deactivate", ad.ID)
+	require.Equal(t, exitOK, res.code, res.stderr)
+	assert.Contains(t, res.stdout, "inactive")
+
+	res = runCLI(t, server, nil, "-output", "json", "list", "-status", "inactive")
+	require.Equal(t, exitOK, res.code, res.stderr)
+	var page domain.AdPage
+	require.NoError(t, json.Unmarshal([]byte(res.stdout), &page))
+	require.Len(t, page.Ads, 1)
+	assert.Equal(t, ad.ID, page.Ads[0].ID)
+}
+
+func TestList_FollowsCursors(t *testing.T) {
+	server := startServer(t)
+	for _, title := range []string{"Uno", "Dos", "Tres"} {
+		createAd(t, server, title)
+	}
+
+	res := runCLI(t, server, nil, "list", "-limit", "2")
+	require.Equal(t, exitOK, res.code, res.stderr)
+	assert.Len(t, strings.Split(strings.TrimSpace(res.stdout), "\n"), 3)
+	assert.Contains(t, res.stderr, "next cursor: ")
+
+	res = runCLI(t, server, nil, "-output", "json", "list", "-limit", "2", "-all")
+	require.Equal(t, exitOK, res.code, res.stderr)
+	var page domain.AdPage
+	require.NoError(t, json.Unmarshal([]byte(res.stdout), &page))
+	assert.Len(t, page.Ads, 3)
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestImportAndExport(t *testing.T) {
+	server := startServer(t)
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "ads.csv")
+	require.NoError(t, os.WriteFile(valid, []byte("title,image_url,placement,ttl_minutes\nUno,https://example.com/1.png,home_screen,30\nDos,https://example.com/2.png,map_view,\n"), 0o600))
+
+	res := runCLI(t, server, nil, "import", "-dry-run", valid)
+	require.Equal(t, exitOK, res.code, res.stderr)
+	assert.Contains(t, res.stderr, "2 rows are valid (dry run)")
+
+	res = runCLI(t, server, nil, "-output", "json", "import", valid)
+	require.Equal(t, exitOK, res.code, res.stderr)
+	var imported struct {
+		Created int      `json:"created"`
+		IDs     []string `json:"ids"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(res.stdout), &imported))
+	assert.Equal(t, 2, imported.Created)
+	assert.Len(t, imported.IDs, 2)
+
+	invalid := filepath.Join(dir, "ads.ndjson")
+	require.NoError(t, os.WriteFile(invalid, []byte(`{"title":"Tres","imageUrl":"https://example.com/3.png","placement":"home_screen"}`+"\n"+`{"title":"","placement":"nowhere"}`+"\n"), 0o600))
+	res = runCLI(t, server, nil, "import", invalid)
+	assert.Equal(t, exitError, res.code)
+	assert.Regexp(t, `(?m)^2\s+title is required$`, res.stdout)
+	assert.Contains(t, res.stderr, "1 of 2 rows are invalid")
+
+	exported := filepath.Join(dir, "export.ndjson")
+	res = runCLI(t, server, nil, "export", "-format", "ndjson", "-placement", "map_view", "-file", exported)
+	require.Equal(t, exitOK, res.code, res.stderr)
+	raw, err := os.ReadFile(exported)
+	require.NoError(t, err)
+	var ad domain.Ad
+	require.NoError(t, json.Unmarshal(raw, &ad))
+	assert.Equal(t, "Dos", ad.Title)
+	assert.Equal(t, 1, strings.Count(string(raw), "\n"))
+}
+
+func TestExitCodes(t *testing.T) {
+	server := startServer(t)
+	ad := createAd(t, server, "Promo")
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		args []string
+		code int
+	}{
+		{name: "no command", args: nil, code: exitUsage},
+		{name: "unknown command", args: []string{"delete"}, code: exitUsage},
+		{name: "unknown flag", args: []string{"list", "-colour"}, code: exitUsage},
+		{name: "missing ID", args: []string{"get"}, code: exitUsage},
+		{name: "bad output", args: []string{"-output", "yaml", "get", ad.ID}, code: exitUsage},
+		{name: "not found", args: []string{"get", "missing"}, code: exitNotFound},
+		{name: "partly not found", args: []string{"deactivate", ad.ID, "missing"}, code: exitNotFound},
+		{name: "no key", env: map[string]string{}, args: []string{"deactivate", ad.ID}, code: exitUnauthorized},
+		{name: "invalid ad", args: []string{"create", "-title", "Promo"}, code: exitError},
+		{name: "help", args: []string{"list", "-h"}, code: exitOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := runCLI(t, server, tt.env, tt.args...)
+			assert.Equal(t, tt.code, res.code, res.stderr)
+		})
+	}
+}
+
+func TestErrorsAreReportedOnce(t *testing.T) {
+	server := startServer(t)
+
+	res := runCLI(t, server, nil, "get", "missing")
+	assert.Equal(t, "adsctl get: 404 Not Found: ad not found\n", res.stderr)
+	assert.Empty(t, res.stdout)
+
+	res = runCLI(t, server, nil, "list", "-colour")
+	assert.Equal(t, 1, strings.Count(res.stderr, "flag provided but not defined"))
+}
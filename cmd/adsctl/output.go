@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"ads_backend/internal/client"
+	"ads_backend/internal/domain"
+)
+
+// printAds writes a single ad as a JSON object and several as an array, or
+// any number as table rows.
+func (a *app) printAds(ads ...domain.Ad) error {
+	if a.output == outputJSON {
+		if len(ads) == 1 {
+			return a.printJSON(ads[0])
+		}
+		return a.printJSON(ads)
+	}
+	return a.printAdTable(ads)
+}
+
+// printPage writes a page of ads. In a table the cursor to the next page
+// goes to stderr, so stdout holds only rows.
+func (a *app) printPage(page domain.AdPage) error {
+	if a.output == outputJSON {
+		if page.Ads == nil {
+			page.Ads = []domain.Ad{}
+		}
+		return a.printJSON(page)
+	}
+	if err := a.printAdTable(page.Ads); err != nil {
+		return err
+	}
+	if page.NextCursor != "" {
+		fmt.Fprintf(a.stderr, "next cursor: %s\n", page.NextCursor)
+	}
+	return nil
+}
+
+func (a *app) printImport(result client.ImportResult) error {
+	if a.output == outputJSON {
+		return a.printJSON(result)
+	}
+
+	tw := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+	if len(result.Errors) > 0 {
+		fmt.Fprintln(tw, "ROW\tERROR")
+		for _, rowErr := range result.Errors {
+			fmt.Fprintf(tw, "%d\t%s\n", rowErr.Row, rowErr.Error)
+		}
+	} else if len(result.IDs) > 0 {
+		fmt.Fprintln(tw, "ID")
+		for _, id := range result.IDs {
+			fmt.Fprintln(tw, id)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	switch {
+	case len(result.Errors) > 0:
+		_, err := fmt.Fprintf(a.stderr, "%d of %d rows are invalid, nothing was imported\n", len(result.Errors), result.Rows)
+		return err
+	case result.DryRun:
+		_, err := fmt.Fprintf(a.stderr, "%d rows are valid (dry run)\n", result.Rows)
+		return err
+	default:
+		_, err := fmt.Fprintf(a.stderr, "imported %d ads\n", result.Created)
+		return err
+	}
+}
+
+func (a *app) printAdTable(ads []domain.Ad) error {
+	tw := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tTITLE\tPLACEMENT\tSTATUS\tCREATED\tDEACTIVATES")
+	for _, ad := range ads {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			ad.ID, ad.Title, ad.Placement, ad.Status, formatTime(ad.CreatedAt), formatTime(ad.DeactivateAt))
+	}
+	return tw.Flush()
+}
+
+func (a *app) printJSON(v any) error {
+	enc := json.NewEncoder(a.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
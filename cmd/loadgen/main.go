@@ -0,0 +1,206 @@
+// Command loadgen sends ad traffic to the API at a target rate and reports
+// latency percentiles, error rates and 429s for each kind of request. The
+// traffic is either generated from a mix of ad decisions (GET /adspots),
+// listings (GET /adposts) and creates (POST /adposts), or replayed from a
+// recording, which -record writes as NDJSON.
+//
+//	loadgen -rps 200 -duration 1m -mix decision=90,list=8,create=2
+//	loadgen -rps 50 -requests 1000 -record traffic.ndjson
+//	loadgen -replay traffic.ndjson -speed 2
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"ads_backend/internal/client"
+	"ads_backend/internal/domain"
+)
+
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	os.Exit(run(ctx, os.Args[1:], os.Getenv, os.Stdout, os.Stderr))
+}
+
+// run executes the command line args and returns the exit code. Interrupting
+// a run stops it and reports what was measured so far.
+func run(ctx context.Context, args []string, getenv func(string) string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("loadgen", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	baseURL := fs.String("url", envOr(getenv, "ADS_API_URL", "http://localhost:8080"), "base URL of the API (ADS_API_URL)")
+	apiKey := fs.String("api-key", "", "API key or JWT to send (ADS_API_KEY); list and create need one")
+	tenant := fs.String("tenant", getenv("ADS_TENANT"), "tenant to send as X-Tenant-ID (ADS_TENANT)")
+	rps := fs.Float64("rps", 50, "requests per second to send")
+	duration := fs.Duration("duration", 10*time.Second, "how long to send for; 0 for no limit")
+	requests := fs.Int("requests", 0, "stop after this many requests; 0 for no limit")
+	mixFlag := fs.String("mix", "decision=80,list=15,create=5", "weights of the request kinds")
+	placementsFlag := fs.String("placements", "home_screen,ride_summary,map_view", "placements to spread requests over")
+	users := fs.Int("users", 1000, "distinct X-User-ID values for decisions; 0 sends none")
+	seedAds := fs.Int("seed-ads", 0, "ads to create in each placement before the run")
+	concurrency := fs.Int("concurrency", 256, "most requests in flight; requests due beyond it are dropped")
+	timeout := fs.Duration("timeout", 10*time.Second, "timeout for each request")
+	recordPath := fs.String("record", "", "write the requests sent to this NDJSON file")
+	replayPath := fs.String("replay", "", "send the requests recorded in this NDJSON file instead of generating them")
+	speed := fs.Float64("speed", 1, "replay speed; 2 sends a recording in half its time")
+	output := fs.String("output", "table", "report format, table or json")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if *apiKey == "" {
+		*apiKey = getenv("ADS_API_KEY")
+	}
+
+	usageErr := func(format string, args ...any) int {
+		fmt.Fprintf(stderr, "loadgen: "+format+"\n", args...)
+		return exitUsage
+	}
+	if fs.NArg() > 0 {
+		return usageErr("unexpected arguments %q", fs.Args())
+	}
+	if *output != "table" && *output != "json" {
+		return usageErr("-output must be table or json")
+	}
+	if *concurrency <= 0 {
+		return usageErr("-concurrency must be positive")
+	}
+	if *replayPath == "" && *rps <= 0 {
+		return usageErr("-rps must be positive")
+	}
+	if *replayPath == "" && *duration == 0 && *requests == 0 {
+		return usageErr("set -duration or -requests, or the run never ends")
+	}
+	if *speed <= 0 {
+		return usageErr("-speed must be positive")
+	}
+	mix, err := parseMix(*mixFlag)
+	if err != nil {
+		return usageErr("-mix: %v", err)
+	}
+	placements, err := parsePlacements(*placementsFlag)
+	if err != nil {
+		return usageErr("-placements: %v", err)
+	}
+
+	httpClient := &http.Client{
+		Timeout:   *timeout,
+		Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, MaxIdleConnsPerHost: *concurrency},
+	}
+	if *seedAds > 0 {
+		if err := seed(ctx, client.New(*baseURL, *apiKey, *tenant, httpClient), placements, *seedAds); err != nil {
+			fmt.Fprintf(stderr, "loadgen: seeding ads: %v\n", err)
+			return exitError
+		}
+	}
+
+	var src source = &generator{
+		mix:        mix,
+		placements: placements,
+		users:      *users,
+		interval:   time.Duration(float64(time.Second) / *rps),
+		duration:   *duration,
+		limit:      *requests,
+		rand:       rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())),
+	}
+	if *replayPath != "" {
+		file, err := os.Open(*replayPath)
+		if err != nil {
+			fmt.Fprintf(stderr, "loadgen: %v\n", err)
+			return exitError
+		}
+		defer file.Close()
+		src = newReplay(file, *speed)
+	}
+
+	r := &runner{
+		client:      httpClient,
+		baseURL:     *baseURL,
+		apiKey:      *apiKey,
+		tenant:      *tenant,
+		concurrency: *concurrency,
+		stats:       newStats(),
+	}
+	if *recordPath != "" {
+		file, err := os.Create(*recordPath)
+		if err != nil {
+			fmt.Fprintf(stderr, "loadgen: %v\n", err)
+			return exitError
+		}
+		defer file.Close()
+		r.record = file
+	}
+
+	elapsed, runErr := r.run(ctx, src)
+	report := r.stats.report(elapsed)
+	if *output == "json" {
+		err = report.writeJSON(stdout)
+	} else {
+		err = report.writeTable(stdout)
+	}
+	if runErr == nil {
+		runErr = err
+	}
+	if runErr != nil {
+		fmt.Fprintf(stderr, "loadgen: %v\n", runErr)
+		return exitError
+	}
+	return exitOK
+}
+
+// seed creates n ads in each placement, so decisions have ads to choose
+// from.
+func seed(ctx context.Context, c *client.Client, placements []domain.Placement, n int) error {
+	for _, placement := range placements {
+		for i := 0; i < n; i++ {
+			_, err := c.CreateAd(ctx, client.AdInput{
+				Title:     fmt.Sprintf("Load test seed %s %d", placement, i),
+				ImageURL:  fmt.Sprintf("https://example.com/loadgen/seed-%s-%d.png", placement, i),
+				Placement: placement,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func parsePlacements(value string) ([]domain.Placement, error) {
+	var placements []domain.Placement
+	for _, name := range strings.Split(value, ",") {
+		placement := domain.Placement(strings.TrimSpace(name))
+		switch placement {
+		case domain.HomeScreen, domain.RideSummary, domain.MapView:
+			placements = append(placements, placement)
+		default:
+			return nil, fmt.Errorf("unknown placement %q", name)
+		}
+	}
+	return placements, nil
+}
+
+func envOr(getenv func(string) string, key, fallback string) string {
+	if value := getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"ads_backend/internal/ads_service"
+	"ads_backend/internal/auth"
+	"ads_backend/internal/featureflag"
+	http_server "ads_backend/internal/http"
+	"ads_backend/internal/metrics"
+	"ads_backend/internal/persistence"
+	"ads_backend/internal/search"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const adminAPIKey = "loadgen-admin-key"
+
+// startServer serves the ads API in process behind a limiter of rps
+// requests per second with the given burst.
+func startServer(t *testing.T, rps, burst int) *httptest.Server {
+	t.Helper()
+
+	log := zap.NewNop()
+	keys := auth.NewKeyServiceFromConfig(auth.NewKeyStore(), auth.Config{AdminAPIKey: adminAPIKey})
+	authenticator := http_server.NewAuthenticator(log, keys, auth.NewTokenVerifierFromConfig(auth.JWTConfig{}))
+	flags := featureflag.NewStatic()
+	service := ads_service.NewService(log, persistence.NewAdRepository(), search.NewIndex(), persistence.NewAuditStore(), persistence.NewVersionStore(), metrics.New(), flags)
+
+	routes := []http_server.Route{http_server.NewAdsHandler(log, service, flags)}
+	mux := http_server.NewServeMux(routes, authenticator)
+
+	server := httptest.NewServer(http_server.NewAPIHandler(mux, authenticator, http_server.NewRateLimiter(rps, burst), nil))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func runLoadgen(t *testing.T, args ...string) (int, string, string) {
+	t.Helper()
+	env := map[string]string{"ADS_API_KEY": adminAPIKey}
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), args, func(key string) string { return env[key] }, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func decodeReport(t *testing.T, raw string) runReport {
+	t.Helper()
+	var report runReport
+	require.NoError(t, json.Unmarshal([]byte(raw), &report))
+	return report
+}
+
+func kindOf(t *testing.T, report runReport, name string) kindReport {
+	t.Helper()
+	for _, k := range report.Kinds {
+		if k.Kind == name {
+			return k
+		}
+	}
+	t.Fatalf("no %s requests in the report", name)
+	return kindReport{}
+}
+
+func TestRun_SendsTheMix(t *testing.T) {
+	server := startServer(t, 1000, 1000)
+
+	code, stdout, stderr := runLoadgen(t, "-url", server.URL, "-rps", "400", "-requests", "60",
+		"-mix", "decision=2,list=1,create=1", "-seed-ads", "2", "-output", "json")
+	require.Equal(t, exitOK, code, stderr)
+
+	report := decodeReport(t, stdout)
+	assert.Equal(t, 60, report.Total.Requests)
+	assert.Equal(t, 60, report.Total.OK)
+	assert.Zero(t, report.Total.ErrorRate)
+	assert.Positive(t, report.Total.P50)
+	assert.LessOrEqual(t, report.Total.P50, report.Total.P99)
+	assert.LessOrEqual(t, report.Total.P99, report.Total.Max)
+	for _, name := range []string{"decision", "list", "create"} {
+		assert.Positive(t, kindOf(t, report, name).Requests, name)
+	}
+	assert.Equal(t, report.Total.OK, report.Statuses[http.StatusOK]+report.Statuses[http.StatusCreated])
+}
+
+func TestRun_Counts429sApartFromErrors(t *testing.T) {
+	server := startServer(t, 1, 5)
+
+	code, stdout, stderr := runLoadgen(t, "-url", server.URL, "-rps", "500", "-requests", "20",
+		"-mix", "decision=1", "-output", "json")
+	require.Equal(t, exitOK, code, stderr)
+
+	decisions := kindOf(t, decodeReport(t, stdout), "decision")
+	assert.Equal(t, 20, decisions.Requests)
+	assert.GreaterOrEqual(t, decisions.RateLimited, 14)
+	assert.Equal(t, 20, decisions.OK+decisions.RateLimited)
+	assert.Zero(t, decisions.ErrorRate)
+}
+
+func TestRun_ReportsErrors(t *testing.T) {
+	server := startServer(t, 1000, 1000)
+
+	// Creating without a key is rejected.
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), []string{"-url", server.URL, "-rps", "200", "-requests", "10", "-mix", "create=1"},
+		func(string) string { return "" }, &stdout, &stderr)
+	require.Equal(t, exitOK, code, stderr.String())
+	assert.Regexp(t, `(?m)^\s+create\s+10\s+\S+\s+0\s+0\s+10\s+0\s+0\s+0\s+100\.00%`, stdout.String())
+	assert.Contains(t, stdout.String(), "statuses: 401=10")
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	var mu sync.Mutex
+	var seen []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		seen = append(seen, r.Method+" "+r.URL.RequestURI()+" "+r.Header.Get("X-User-ID")+" "+string(body))
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	recording := filepath.Join(t.TempDir(), "traffic.ndjson")
+	code, _, stderr := runLoadgen(t, "-url", server.URL, "-rps", "100", "-requests", "15",
+		"-record", recording, "-output", "json")
+	require.Equal(t, exitOK, code, stderr)
+	recorded := seen
+	seen = nil
+
+	raw, err := os.ReadFile(recording)
+	require.NoError(t, err)
+	assert.Equal(t, 15, bytes.Count(raw, []byte("\n")))
+
+	started := time.Now()
+	code, stdout, stderr := runLoadgen(t, "-url", server.URL, "-replay", recording, "-speed", "10", "-output", "json")
+	require.Equal(t, exitOK, code, stderr)
+	assert.Less(t, time.Since(started), 100*time.Millisecond)
+	assert.ElementsMatch(t, recorded, seen)
+	assert.Equal(t, 15, decodeReport(t, stdout).Total.Requests)
+}
+
+func TestRun_RejectsBadFlags(t *testing.T) {
+	for _, args := range [][]string{
+		{"-mix", "decision=1,delete=1"},
+		{"-mix", "decision=0"},
+		{"-placements", "lobby"},
+		{"-rps", "0"},
+		{"-duration", "0"},
+		{"-output", "yaml"},
+		{"-speed", "0"},
+	} {
+		code, _, _ := runLoadgen(t, args...)
+		assert.Equal(t, exitUsage, code, args)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	var latencies []time.Duration
+	for i := 1; i <= 100; i++ {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+
+	assert.Equal(t, 50*time.Millisecond, percentile(latencies, 50))
+	assert.Equal(t, 99*time.Millisecond, percentile(latencies, 99))
+	assert.Equal(t, 100*time.Millisecond, percentile(latencies, 100))
+	assert.Equal(t, time.Millisecond, percentile(latencies, 0))
+	assert.Zero(t, percentile(nil, 50))
+}
+
+func TestParseMix(t *testing.T) {
+	m, err := parseMix("decision=80, list=15,create=5")
+	require.NoError(t, err)
+	assert.Equal(t, mix{kindDecision: 80, kindList: 15, kindCreate: 5}, m)
+
+	for _, value := range []string{"decision", "decision=-1", "decision=x", "serve=1", "list=0"} {
+		_, err := parseMix(value)
+		assert.Error(t, err, value)
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// stats collects the outcome of every request, by kind.
+type stats struct {
+	mu    sync.Mutex
+	kinds map[kind]*samples
+}
+
+type samples struct {
+	latencies []time.Duration
+	statuses  map[int]int
+	dropped   int
+}
+
+func newStats() *stats {
+	return &stats{kinds: map[kind]*samples{}}
+}
+
+func (s *stats) samples(k kind) *samples {
+	if s.kinds[k] == nil {
+		s.kinds[k] = &samples{statuses: map[int]int{}}
+	}
+	return s.kinds[k]
+}
+
+// add records an answered request. Status 0 is a request that got no
+// answer, such as a refused connection or a timeout.
+func (s *stats) add(k kind, status int, latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	samples := s.samples(k)
+	samples.latencies = append(samples.latencies, latency)
+	samples.statuses[status]++
+}
+
+func (s *stats) drop(k kind) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.samples(k).dropped++
+}
+
+// runReport is the outcome of a run. Latencies are in milliseconds. Errors are
+// answers other than 2xx and 429, and requests that got no answer; 429s are
+// counted on their own, as they are the rate limiter working.
+type runReport struct {
+	DurationSeconds float64      `json:"duration_seconds"`
+	Total           kindReport   `json:"total"`
+	Kinds           []kindReport `json:"kinds"`
+	Statuses        map[int]int  `json:"statuses"`
+}
+
+type kindReport struct {
+	Kind         string  `json:"kind"`
+	Requests     int     `json:"requests"`
+	Dropped      int     `json:"dropped"`
+	RPS          float64 `json:"rps"`
+	OK           int     `json:"ok"`
+	RateLimited  int     `json:"rate_limited"`
+	ClientErrors int     `json:"client_errors"`
+	ServerErrors int     `json:"server_errors"`
+	Failed       int     `json:"failed"`
+	ErrorRate    float64 `json:"error_rate"`
+	P50          float64 `json:"p50_ms"`
+	P90          float64 `json:"p90_ms"`
+	P95          float64 `json:"p95_ms"`
+	P99          float64 `json:"p99_ms"`
+	Max          float64 `json:"max_ms"`
+}
+
+func (s *stats) report(elapsed time.Duration) runReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	report := runReport{DurationSeconds: elapsed.Seconds(), Kinds: []kindReport{}, Statuses: map[int]int{}}
+	total := &samples{statuses: map[int]int{}}
+	for _, k := range kinds {
+		samples, ok := s.kinds[k]
+		if !ok {
+			continue
+		}
+		report.Kinds = append(report.Kinds, samples.report(string(k), elapsed))
+		total.latencies = append(total.latencies, samples.latencies...)
+		total.dropped += samples.dropped
+		for status, n := range samples.statuses {
+			total.statuses[status] += n
+			report.Statuses[status] += n
+		}
+	}
+	report.Total = total.report("total", elapsed)
+	return report
+}
+
+func (s *samples) report(name string, elapsed time.Duration) kindReport {
+	r := kindReport{Kind: name, Requests: len(s.latencies), Dropped: s.dropped}
+	if elapsed > 0 {
+		r.RPS = float64(r.Requests) / elapsed.Seconds()
+	}
+	for status, n := range s.statuses {
+		switch {
+		case status == 0:
+			r.Failed += n
+		case status == http.StatusTooManyRequests:
+			r.RateLimited += n
+		case status >= 500:
+			r.ServerErrors += n
+		case status >= 400:
+			r.ClientErrors += n
+		default:
+			r.OK += n
+		}
+	}
+	if r.Requests > 0 {
+		r.ErrorRate = float64(r.ClientErrors+r.ServerErrors+r.Failed) / float64(r.Requests)
+	}
+
+	latencies := slices.Clone(s.latencies)
+	slices.Sort(latencies)
+	r.P50 = milliseconds(percentile(latencies, 50))
+	r.P90 = milliseconds(percentile(latencies, 90))
+	r.P95 = milliseconds(percentile(latencies, 95))
+	r.P99 = milliseconds(percentile(latencies, 99))
+	if len(latencies) > 0 {
+		r.Max = milliseconds(latencies[len(latencies)-1])
+	}
+	return r
+}
+
+// percentile is the nearest-rank percentile of sorted latencies.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
+
+func milliseconds(d time.Duration) float64 {
+	return math.Round(float64(d)/float64(time.Microsecond)) / 1000
+}
+
+func (r runReport) writeJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+func (r runReport) writeTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "KIND\tREQUESTS\tRPS\tOK\t429\t4XX\t5XX\tFAILED\tDROPPED\tERRORS\tP50\tP90\tP95\tP99\tMAX\t")
+	for _, k := range append(slices.Clone(r.Kinds), r.Total) {
+		fmt.Fprintf(tw, "%s\t%d\t%.1f\t%d\t%d\t%d\t%d\t%d\t%d\t%.2f%%\t%.1fms\t%.1fms\t%.1fms\t%.1fms\t%.1fms\t\n",
+			k.Kind, k.Requests, k.RPS, k.OK, k.RateLimited, k.ClientErrors, k.ServerErrors, k.Failed, k.Dropped,
+			k.ErrorRate*100, k.P50, k.P90, k.P95, k.P99, k.Max)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	statuses := make([]int, 0, len(r.Statuses))
+	for status := range r.Statuses {
+		statuses = append(statuses, status)
+	}
+	slices.Sort(statuses)
+	fmt.Fprintf(w, "\n%.1fs, statuses:", r.DurationSeconds)
+	for _, status := range statuses {
+		label := fmt.Sprint(status)
+		if status == 0 {
+			label = "failed"
+		}
+		fmt.Fprintf(w, " %s=%d", label, r.Statuses[status])
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// runner sends requests open loop: each goes out when it is due, whether or
+// not earlier ones have been answered, so a slow server shows up as latency
+// rather than as a lower rate. A request due while concurrency requests are
+// in flight is dropped and counted.
+type runner struct {
+	client      *http.Client
+	baseURL     string
+	apiKey      string
+	tenant      string
+	concurrency int
+	record      io.Writer
+	stats       *stats
+}
+
+// run sends every request from src and waits for the answers. It stops
+// early, keeping what was measured, when ctx is done.
+func (r *runner) run(ctx context.Context, src source) (time.Duration, error) {
+	var recorder *json.Encoder
+	if r.record != nil {
+		recorder = json.NewEncoder(r.record)
+	}
+	slots := make(chan struct{}, r.concurrency)
+	var wg sync.WaitGroup
+	start := time.Now()
+
+	var err error
+	for {
+		req, ok, nextErr := src.next()
+		if nextErr != nil {
+			err = nextErr
+			break
+		}
+		if !ok {
+			break
+		}
+		if wait := time.Until(start.Add(req.offset())); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+			case <-timer.C:
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		select {
+		case slots <- struct{}{}:
+		default:
+			r.stats.drop(req.Kind)
+			continue
+		}
+		if recorder != nil {
+			if err = recorder.Encode(req); err != nil {
+				<-slots
+				break
+			}
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			r.send(ctx, req)
+		}()
+	}
+
+	wg.Wait()
+	return time.Since(start), err
+}
+
+func (r *runner) send(ctx context.Context, req request) {
+	var body io.Reader
+	if len(req.Body) > 0 {
+		body = bytes.NewReader(req.Body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.Method, strings.TrimSuffix(r.baseURL, "/")+req.Path, body)
+	if err != nil {
+		r.stats.add(req.Kind, 0, 0)
+		return
+	}
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if r.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+r.apiKey)
+	}
+	if r.tenant != "" {
+		httpReq.Header.Set("X-Tenant-ID", r.tenant)
+	}
+	if req.UserID != "" {
+		httpReq.Header.Set("X-User-ID", req.UserID)
+	}
+
+	sent := time.Now()
+	resp, err := r.client.Do(httpReq)
+	if err != nil {
+		if ctx.Err() == nil {
+			r.stats.add(req.Kind, 0, time.Since(sent))
+		}
+		return
+	}
+	// Read the whole body so the connection is reused and the latency
+	// covers the full response.
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	r.stats.add(req.Kind, resp.StatusCode, time.Since(sent))
+}
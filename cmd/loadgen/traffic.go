@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"ads_backend/internal/domain"
+)
+
+// kind is a class of request, reported on its own.
+type kind string
+
+const (
+	kindDecision kind = "decision"
+	kindList     kind = "list"
+	kindCreate   kind = "create"
+)
+
+var kinds = []kind{kindDecision, kindList, kindCreate}
+
+// request is one request to send, at Offset from the start of the run. It is
+// also the line format of recordings.
+type request struct {
+	OffsetMS float64         `json:"offset_ms"`
+	Kind     kind            `json:"kind"`
+	Method   string          `json:"method"`
+	Path     string          `json:"path"`
+	UserID   string          `json:"user_id,omitempty"`
+	Body     json.RawMessage `json:"body,omitempty"`
+}
+
+func (r request) offset() time.Duration {
+	return time.Duration(r.OffsetMS * float64(time.Millisecond))
+}
+
+// source yields the requests of a run in the order they are due.
+type source interface {
+	next() (request, bool, error)
+}
+
+// mix is how often each kind of request is picked, by weight.
+type mix map[kind]int
+
+// parseMix reads weights such as "decision=80,list=15,create=5".
+func parseMix(value string) (mix, error) {
+	m := mix{}
+	total := 0
+	for _, part := range strings.Split(value, ",") {
+		name, raw, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("mix entry %q must be kind=weight", part)
+		}
+		k := kind(name)
+		if k != kindDecision && k != kindList && k != kindCreate {
+			return nil, fmt.Errorf("unknown request kind %q, want decision, list or create", name)
+		}
+		weight, err := strconv.Atoi(raw)
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("weight of %s must be a non-negative integer", name)
+		}
+		m[k] += weight
+		total += weight
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("mix must give some kind a positive weight")
+	}
+	return m, nil
+}
+
+func (m mix) pick(rnd *rand.Rand) kind {
+	total := 0
+	for _, weight := range m {
+		total += weight
+	}
+	n := rnd.IntN(total)
+	for _, k := range kinds {
+		if n < m[k] {
+			return k
+		}
+		n -= m[k]
+	}
+	return kindDecision
+}
+
+// generator makes requests from a mix at a fixed rate until the run has
+// lasted duration or sent limit requests; a zero bound is no bound.
+type generator struct {
+	mix        mix
+	placements []domain.Placement
+	users      int
+	interval   time.Duration
+	duration   time.Duration
+	limit      int
+	rand       *rand.Rand
+	n          int
+}
+
+func (g *generator) next() (request, bool, error) {
+	offset := time.Duration(g.n) * g.interval
+	if g.limit > 0 && g.n >= g.limit || g.duration > 0 && offset >= g.duration {
+		return request{}, false, nil
+	}
+	g.n++
+
+	placement := g.placements[g.rand.IntN(len(g.placements))]
+	req := request{OffsetMS: float64(offset) / float64(time.Millisecond), Kind: g.mix.pick(g.rand)}
+	switch req.Kind {
+	case kindDecision:
+		req.Method = "GET"
+		req.Path = "/adspots?" + url.Values{"placement": {string(placement)}}.Encode()
+		if g.users > 0 {
+			req.UserID = "rider-" + strconv.Itoa(g.rand.IntN(g.users))
+		}
+	case kindList:
+		req.Method = "GET"
+		req.Path = "/adposts?" + url.Values{"placement": {string(placement)}, "limit": {"20"}}.Encode()
+	case kindCreate:
+		req.Method = "POST"
+		req.Path = "/adposts"
+		req.Body, _ = json.Marshal(map[string]any{
+			"title":      fmt.Sprintf("Load test ad %d", g.n),
+			"imageUrl":   fmt.Sprintf("https://example.com/loadgen/%d.png", g.n),
+			"placement":  placement,
+			"ttlMinutes": 60,
+		})
+	}
+	return req, true, nil
+}
+
+// replay reads a recording, sending each request at its recorded offset
+// divided by speed.
+type replay struct {
+	scanner *bufio.Scanner
+	speed   float64
+	line    int
+}
+
+func newReplay(r io.Reader, speed float64) *replay {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	return &replay{scanner: scanner, speed: speed}
+}
+
+func (r *replay) next() (request, bool, error) {
+	for r.scanner.Scan() {
+		r.line++
+		line := strings.TrimSpace(r.scanner.Text())
+		if line == "" {
+			continue
+		}
+		var req request
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			return request{}, false, fmt.Errorf("line %d: %w", r.line, err)
+		}
+		if req.Method == "" || !strings.HasPrefix(req.Path, "/") {
+			return request{}, false, fmt.Errorf("line %d: method and path are required", r.line)
+		}
+		req.OffsetMS /= r.speed
+		return req, true, nil
+	}
+	return request{}, false, r.scanner.Err()
+}
@@ -2,28 +2,107 @@ package main
 
 import (
 	"ads_backend/internal/ads_service"
+	"ads_backend/internal/auth"
+	"ads_backend/internal/clientip"
+	"ads_backend/internal/config"
+	"ads_backend/internal/featureflag"
+	"ads_backend/internal/health"
 	http_server "ads_backend/internal/http"
+	"ads_backend/internal/idempotency"
+	"ads_backend/internal/logging"
+	"ads_backend/internal/metrics"
+	"ads_backend/internal/persistence"
+	"ads_backend/internal/ratelimit"
+	"ads_backend/internal/search"
+	"ads_backend/internal/tracing"
+	"errors"
+	"flag"
+	"fmt"
 	"net/http"
+	"os"
 
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/fx"
 	"go.uber.org/fx/fxevent"
 	"go.uber.org/zap"
 )
 
 func main() {
+	loader, err := config.NewLoader(os.Args[0], os.Args[1:], os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		os.Exit(2)
+	}
+	cfg, err := loader.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if loader.PrintConfig {
+		if err := config.Write(os.Stdout, cfg); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	fx.New(
 		fx.WithLogger(func(log *zap.Logger) fxevent.Logger {
-			return &fxevent.ZapLogger{Logger: log}
+			// fx events carry their own "caller" field.
+			return &fxevent.ZapLogger{Logger: log.WithOptions(zap.WithCaller(false))}
 		}),
 		fx.Provide(
 			fx.Annotate(http_server.NewServeMux, fx.ParamTags(`group:"routes"`)),
 			http_server.AsRoute(http_server.NewAdsHandler),
+			http_server.AsRoute(http_server.NewSearchHandler),
+			http_server.AsRoute(http_server.NewBatchHandler),
+			http_server.AsRoute(http_server.NewExportHandler),
+			http_server.AsRoute(http_server.NewImportHandler),
+			http_server.AsRoute(http_server.NewHistoryHandler),
+			http_server.AsRoutes(http_server.NewVersionRoutes),
+			http_server.AsRoutes(http_server.NewAPIKeyRoutes),
+			http_server.AsRoutes(http_server.NewLogLevelRoutes),
+			http_server.AsRoutes(http_server.NewFeatureFlagRoutes),
+			http_server.AsRoute(http_server.NewMetricsRoute),
 			http_server.NewRateLimiterMiddleware,
 			http_server.NewRequestLogger,
+			http_server.NewRequestMetrics,
+			http_server.NewRequestTracer,
+			http_server.NewIdempotency,
+			http_server.NewAuthenticatorFromConfig,
+			http_server.NewHealthHandler,
 			http_server.NewHTTPServer,
+			fx.Annotate(health.NewHealth, fx.ParamTags(`group:"health_checkers"`)),
+			health.AsChecker(persistence.NewHealthChecker),
+			health.AsChecker(ratelimit.NewHealthChecker),
+			health.AsChecker(http_server.NewRateLimitConfigChecker),
 			ads_service.NewService,
-			zap.NewExample,
+			persistence.NewAdRepository,
+			persistence.NewAuditStore,
+			persistence.NewVersionStore,
+			metrics.New,
+			tracing.NewTracerProvider,
+			tracing.NewPropagator,
+			search.NewIndex,
+			idempotency.NewStore,
+			ratelimit.NewStore,
+			auth.NewKeyStore,
+			auth.NewKeyServiceFromConfig,
+			auth.NewTokenVerifier,
+			clientip.NewResolverFromConfig,
+			featureflag.NewFromConfig,
+		),
+		config.Module(loader, cfg),
+		logging.Module,
+		fx.Decorate(
+			func(repo persistence.AdRepository, m *metrics.Metrics, tp trace.TracerProvider) persistence.AdRepository {
+				return tracing.InstrumentAdRepository(metrics.InstrumentAdRepository(repo, m), tp)
+			},
+			tracing.InstrumentService,
 		),
+		fx.Invoke(config.ReloadRateLimits, config.ReloadLogLevel, config.ReloadFeatureFlags),
 		fx.Invoke(func(*http.Server) {}),
 	).Run()
 }
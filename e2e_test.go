@@ -9,15 +9,25 @@ import (
 	"time"
 
 	"ads_backend/internal/ads_service"
+	"ads_backend/internal/auth"
+	"ads_backend/internal/clientip"
 	"ads_backend/internal/domain"
+	"ads_backend/internal/featureflag"
 	http_server "ads_backend/internal/http"
+	"ads_backend/internal/idempotency"
+	"ads_backend/internal/metrics"
+	"ads_backend/internal/persistence"
+	"ads_backend/internal/search"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 )
 
-const baseURL = "http://localhost:8081"
+const (
+	baseURL     = "http://localhost:8081"
+	adminAPIKey = "e2e-admin-key"
+)
 
 func TestE2E_AdLifecycle(t *testing.T) {
 	server := startTestServer(t)
@@ -25,6 +35,8 @@ func TestE2E_AdLifecycle(t *testing.T) {
 
 	waitForServer(t, baseURL)
 
+	editorKey := issueAPIKey(t, "e2e editor", "editor", "")
+
 	reqBody := map[string]interface{}{
 		"title":      "E2E Test Ad",
 		"imageUrl":   "http://example.com/e2e-ad.jpg",
@@ -37,6 +49,11 @@ func TestE2E_AdLifecycle(t *testing.T) {
 	require.NoError(t, err)
 	defer resp.Body.Close()
 
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp = doRequest(t, http.MethodPost, baseURL+"/adposts", editorKey, body)
+	defer resp.Body.Close()
+
 	assert.Equal(t, http.StatusCreated, resp.StatusCode)
 	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
 	assert.NotEmpty(t, resp.Header.Get("X-Correlation-ID"))
@@ -86,9 +103,7 @@ func TestE2E_AdLifecycle(t *testing.T) {
 	}
 	assert.True(t, found, "Created ad should be in the list")
 
-	req, _ := http.NewRequest(http.MethodPost, baseURL+"/adposts/"+createdAd.ID+"/deactivate", nil)
-	resp, err = http.DefaultClient.Do(req)
-	require.NoError(t, err)
+	resp = doRequest(t, http.MethodPost, baseURL+"/adposts/"+createdAd.ID+"/deactivate", editorKey, nil)
 	defer resp.Body.Close()
 
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
@@ -112,6 +127,91 @@ func TestE2E_AdLifecycle(t *testing.T) {
 	for _, ad := range ads {
 		assert.NotEqual(t, createdAd.ID, ad.ID, "Deactivated ad should not be in active list")
 	}
+
+	resp = doRequest(t, http.MethodPost, baseURL+"/adposts/"+createdAd.ID+"/versions/1/rollback", editorKey, nil)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var restoredAd domain.Ad
+	err = json.NewDecoder(resp.Body).Decode(&restoredAd)
+	require.NoError(t, err)
+	assert.Equal(t, domain.StatusActive, restoredAd.Status)
+
+	resp = doRequest(t, http.MethodGet, baseURL+"/adposts/"+createdAd.ID+"/versions", editorKey, nil)
+	defer resp.Body.Close()
+
+	var versions []domain.AdVersion
+	err = json.NewDecoder(resp.Body).Decode(&versions)
+	require.NoError(t, err)
+	require.Len(t, versions, 3)
+	assert.Equal(t, domain.AuditActionRolledBack, versions[2].Action)
+}
+
+func TestE2E_TenantIsolation(t *testing.T) {
+	server := startTestServer(t)
+	defer server.stop()
+
+	waitForServer(t, baseURL)
+
+	bogotaKey := issueAPIKey(t, "bogota editor", "editor", "bogota")
+	limaKey := issueAPIKey(t, "lima editor", "editor", "lima")
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"title":     "Bogota Ad",
+		"imageUrl":  "http://example.com/bogota.jpg",
+		"placement": "home_screen",
+	})
+	resp := doRequest(t, http.MethodPost, baseURL+"/adposts", bogotaKey, body)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var createdAd domain.Ad
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&createdAd))
+
+	resp = doRequest(t, http.MethodGet, baseURL+"/adposts/"+createdAd.ID, bogotaKey, nil)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = doRequest(t, http.MethodGet, baseURL+"/adposts/"+createdAd.ID, limaKey, nil)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp = doRequest(t, http.MethodPost, baseURL+"/adposts/"+createdAd.ID+"/deactivate", limaKey, nil)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp = doRequest(t, http.MethodGet, baseURL+"/adposts", limaKey, nil)
+	defer resp.Body.Close()
+	var page domain.AdPage
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&page))
+	assert.Empty(t, page.Ads)
+
+	resp, err := http.Get(baseURL + "/adspots?placement=home_screen")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var ads []domain.Ad
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&ads))
+	assert.Empty(t, ads, "default tenant must not serve bogota ads")
+
+	req, err := http.NewRequest(http.MethodGet, baseURL+"/adspots?placement=home_screen", nil)
+	require.NoError(t, err)
+	req.Header.Set("X-Tenant-ID", "bogota")
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&ads))
+	require.Len(t, ads, 1)
+	assert.Equal(t, createdAd.ID, ads[0].ID)
+
+	req, err = http.NewRequest(http.MethodGet, baseURL+"/adposts/"+createdAd.ID, nil)
+	require.NoError(t, err)
+	req.Header.Set("X-API-Key", limaKey)
+	req.Header.Set("X-Tenant-ID", "bogota")
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
 }
 
 type testServer struct {
@@ -122,21 +222,31 @@ type testServer struct {
 func startTestServer(t *testing.T) *testServer {
 	t.Helper()
 
-	os.Setenv("HTTP_PORT", "8081")
-	defer os.Unsetenv("HTTP_PORT")
-
 	log := zap.NewNop()
-	service := ads_service.NewService()
-	handler := http_server.NewAdsHandler(log, service)
+	keys := auth.NewKeyServiceFromConfig(auth.NewKeyStore(), auth.Config{AdminAPIKey: adminAPIKey})
+	authenticator := http_server.NewAuthenticator(log, keys, auth.NewTokenVerifierFromConfig(auth.JWTConfig{}))
+	flags := featureflag.NewStatic()
+	service := ads_service.NewService(log, persistence.NewAdRepository(), search.NewIndex(), persistence.NewAuditStore(), persistence.NewVersionStore(), metrics.New(), flags)
+	handler := http_server.NewAdsHandler(log, service, flags)
+	searchHandler := http_server.NewSearchHandler(log, service)
+	batchHandler := http_server.NewBatchHandler(log, service)
+	exportHandler := http_server.NewExportHandler(log, service)
+	importHandler := http_server.NewImportHandler(log, service)
+	historyHandler := http_server.NewHistoryHandler(log, service)
 	rateLimiter := http_server.NewRateLimiter(100, 200)
 	requestLogger := http_server.NewRequestLogger(log)
+	idempotencyMiddleware := http_server.NewIdempotency(log, idempotency.NewMemoryStore(time.Hour))
 
-	routes := []http_server.Route{handler}
-	mux := http_server.NewServeMux(routes)
+	routes := []http_server.Route{handler, searchHandler, batchHandler, exportHandler, importHandler, historyHandler}
+	routes = append(routes, http_server.NewVersionRoutes(log, service)...)
+	routes = append(routes, http_server.NewAPIKeyRoutes(log, keys)...)
+	mux := http_server.NewServeMux(routes, authenticator)
 
-	var finalHandler http.Handler = mux
-	finalHandler = rateLimiter.Middleware(finalHandler)
+	finalHandler := http_server.NewAPIHandler(mux, authenticator, rateLimiter, idempotencyMiddleware)
 	finalHandler = requestLogger.Middleware(finalHandler)
+	clientIPs, err := clientip.NewResolver(nil)
+	require.NoError(t, err)
+	finalHandler = clientIPs.Middleware(finalHandler)
 
 	server := &http.Server{
 		Addr:         ":8081",
@@ -165,6 +275,34 @@ func (ts *testServer) stop() {
 	}
 }
 
+func doRequest(t *testing.T, method, url, apiKey string, body []byte) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", apiKey)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	return resp
+}
+
+func issueAPIKey(t *testing.T, name, role, tenant string) string {
+	t.Helper()
+
+	body, _ := json.Marshal(map[string]string{"name": name, "role": role, "tenant": tenant})
+	resp := doRequest(t, http.MethodPost, baseURL+"/admin/apikeys", adminAPIKey, body)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var issued struct {
+		Key string `json:"key"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&issued))
+	return issued.Key
+}
+
 func waitForServer(t *testing.T, url string) {
 	t.Helper()
 
@@ -1,64 +1,386 @@
 package ads_service
 
 import (
+	"ads_backend/internal/auth"
+	"ads_backend/internal/correlation"
 	"ads_backend/internal/domain"
+	"ads_backend/internal/featureflag"
+	"ads_backend/internal/logging"
+	"ads_backend/internal/metrics"
 	"ads_backend/internal/persistence"
+	"ads_backend/internal/search"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
+	"go.uber.org/zap"
 )
 
 type Service interface {
-	CreateAd(ad domain.Ad) (domain.Ad, error)
-	GetAd(id string) (domain.Ad, error)
-	DeactivateAd(id string) (domain.Ad, error)
-	ListEligibleActiveAdsByPlacement(placement domain.Placement) ([]domain.Ad, error)
+	CreateAd(ctx context.Context, ad domain.Ad) (domain.Ad, error)
+	GetAd(ctx context.Context, id string) (domain.Ad, error)
+	DeactivateAd(ctx context.Context, id string) (domain.Ad, error)
+	ListEligibleActiveAdsByPlacement(ctx context.Context, placement domain.Placement) ([]domain.Ad, error)
+	ListAds(ctx context.Context, query domain.AdQuery) (domain.AdPage, error)
+	ExportAds(ctx context.Context, query domain.AdQuery, fn func(domain.Ad) error) error
+	SearchAds(ctx context.Context, query string, status domain.Status, limit int) ([]domain.AdSearchResult, error)
+	CreateAds(ctx context.Context, ads []domain.Ad, atomic bool) ([]domain.BatchResult, error)
+	DeactivateAds(ctx context.Context, ids []string, atomic bool) ([]domain.BatchResult, error)
+	GetAdHistory(ctx context.Context, id string) ([]domain.AuditRecord, error)
+	UpdateAd(ctx context.Context, id string, update domain.AdUpdate) (domain.Ad, error)
+	ListAdVersions(ctx context.Context, id string) ([]domain.AdVersion, error)
+	GetAdVersion(ctx context.Context, id string, version int) (domain.AdVersion, error)
+	DiffAdVersions(ctx context.Context, id string, from, to int) ([]domain.FieldChange, error)
+	RollbackAd(ctx context.Context, id string, version int) (domain.Ad, error)
 }
 
+// FlagNewestFirst serves a placement's eligible ads newest first instead of
+// in no particular order.
+const FlagNewestFirst = "serving.newest_first"
+
 type service struct {
 	adRepository persistence.AdRepository
+	searchIndex  search.Index
+	auditStore   persistence.AuditStore
+	versionStore persistence.VersionStore
+	metrics      *metrics.Metrics
+	flags        *featureflag.Flags
+	log          *zap.Logger
 }
 
-func NewService() Service {
+func NewService(log *zap.Logger, adRepository persistence.AdRepository, searchIndex search.Index, auditStore persistence.AuditStore, versionStore persistence.VersionStore, metrics *metrics.Metrics, flags *featureflag.Flags) Service {
 	return &service{
-		adRepository: persistence.NewAdRepository(),
+		adRepository: adRepository,
+		searchIndex:  searchIndex,
+		auditStore:   auditStore,
+		versionStore: versionStore,
+		metrics:      metrics,
+		flags:        flags,
+		log:          log,
+	}
+}
+
+// ErrNotRecorded is returned when an ad was written but its audit record or
+// version snapshot was not. The write itself stands, so retrying it would
+// apply the change a second time.
+var ErrNotRecorded = errors.New("ad saved but its change was not recorded")
+
+// change is a write to one ad. Once the write is committed, commit applies it
+// to the search index, the audit log, the ad's version history, the metrics
+// and the log.
+type change struct {
+	action domain.AuditAction
+	before *domain.Ad
+	after  domain.Ad
+}
+
+func (s *service) commit(ctx context.Context, c change) error {
+	s.searchIndex.Upsert(c.after)
+	log := logging.For(ctx, s.log)
+	log.Info("Ad changed",
+		zap.String("ad_id", c.after.ID),
+		zap.String("action", string(c.action)),
+		zap.String("actor", actor(ctx)),
+	)
+	switch c.action {
+	case domain.AuditActionCreated:
+		s.metrics.AdCreated(c.after.Placement)
+	case domain.AuditActionDeactivated:
+		s.metrics.AdDeactivated(c.after.Placement)
+	}
+
+	after := c.after
+	now := time.Now()
+	err := s.auditStore.Append(domain.AuditRecord{
+		ID:            uuid.New().String(),
+		AdID:          after.ID,
+		Action:        c.action,
+		Actor:         actor(ctx),
+		CorrelationID: correlation.ID(ctx),
+		Before:        c.before,
+		After:         &after,
+		Timestamp:     now,
+	})
+	if err != nil {
+		log.Error("Ad changed but its audit record was not written", zap.String("ad_id", after.ID), zap.Error(err))
+		return fmt.Errorf("%w: ad %s: %v", ErrNotRecorded, after.ID, err)
+	}
+
+	_, err = s.versionStore.Append(domain.AdVersion{
+		AdID:      after.ID,
+		Action:    c.action,
+		Actor:     actor(ctx),
+		Ad:        after,
+		CreatedAt: now,
+	})
+	if err != nil {
+		log.Error("Ad changed but its version was not written", zap.String("ad_id", after.ID), zap.Error(err))
+		return fmt.Errorf("%w: ad %s: %v", ErrNotRecorded, after.ID, err)
 	}
+	return nil
 }
 
-func (s *service) CreateAd(ad domain.Ad) (domain.Ad, error) {
+// actor identifies the caller in audit records as "<auth method>:<subject>".
+func actor(ctx context.Context) string {
+	if principal, ok := auth.PrincipalFromContext(ctx); ok {
+		return principal.Method + ":" + principal.Subject
+	}
+	return "anonymous"
+}
+
+func create(ctx context.Context, tx persistence.AdWriter, ad domain.Ad) (change, error) {
+	created, err := tx.CreateAd(ctx, newAd(ad))
+	return change{action: domain.AuditActionCreated, after: created}, err
+}
+
+func deactivate(ctx context.Context, tx persistence.AdWriter, id string) (change, error) {
+	before, err := tx.GetAd(ctx, id)
+	if err != nil {
+		return change{}, err
+	}
+	after, err := tx.DeactivateAd(ctx, id)
+	return change{action: domain.AuditActionDeactivated, before: &before, after: after}, err
+}
+
+func update(ctx context.Context, tx persistence.AdWriter, id string, action domain.AuditAction, apply func(domain.Ad) domain.Ad) (change, error) {
+	before, err := tx.GetAd(ctx, id)
+	if err != nil {
+		return change{}, err
+	}
+	after, err := tx.UpdateAd(ctx, apply(before))
+	return change{action: action, before: &before, after: after}, err
+}
+
+func newAd(ad domain.Ad) domain.Ad {
 	ad.ID = uuid.New().String()
 	ad.Status = domain.StatusActive
 	ad.CreatedAt = time.Now()
 	if ad.TTLMinutes > 0 {
 		ad.DeactivateAt = time.Now().Add(time.Duration(ad.TTLMinutes) * time.Minute)
 	}
-	ad, err := s.adRepository.CreateAd(ad)
+	return ad
+}
+
+func (s *service) CreateAd(ctx context.Context, ad domain.Ad) (domain.Ad, error) {
+	c, err := create(ctx, s.adRepository, ad)
 	if err != nil {
 		return domain.Ad{}, err
 	}
+	if err := s.commit(ctx, c); err != nil {
+		return domain.Ad{}, err
+	}
 
-	return ad, nil
+	return c.after, nil
 }
-func (s *service) GetAd(id string) (domain.Ad, error) {
-	ad, err := s.adRepository.GetAd(id)
+func (s *service) GetAd(ctx context.Context, id string) (domain.Ad, error) {
+	ad, err := s.adRepository.GetAd(ctx, id)
 	if err != nil {
 		return domain.Ad{}, err
 	}
 
 	return ad, nil
 }
-func (s *service) DeactivateAd(id string) (domain.Ad, error) {
-	ad, err := s.adRepository.DeactivateAd(id)
+func (s *service) DeactivateAd(ctx context.Context, id string) (domain.Ad, error) {
+	c, err := deactivate(ctx, s.adRepository, id)
 	if err != nil {
 		return domain.Ad{}, err
 	}
+	if err := s.commit(ctx, c); err != nil {
+		return domain.Ad{}, err
+	}
 
-	return ad, nil
+	return c.after, nil
 }
-func (s *service) ListEligibleActiveAdsByPlacement(placement domain.Placement) ([]domain.Ad, error) {
-	ads, err := s.adRepository.ListEligibleActiveAdsByPlacement(placement)
+func (s *service) ListEligibleActiveAdsByPlacement(ctx context.Context, placement domain.Placement) ([]domain.Ad, error) {
+	ads, err := s.adRepository.ListEligibleActiveAdsByPlacement(ctx, placement)
 	if err != nil {
 		return []domain.Ad{}, err
 	}
+	s.metrics.SetEligibleAds(placement, len(ads))
+	if s.flags.Enabled(ctx, FlagNewestFirst) {
+		sort.SliceStable(ads, func(i, j int) bool { return ads[i].CreatedAt.After(ads[j].CreatedAt) })
+	}
 	return ads, nil
 }
+func (s *service) ListAds(ctx context.Context, query domain.AdQuery) (domain.AdPage, error) {
+	page, err := s.adRepository.ListAds(ctx, query.WithDefaults())
+	if err != nil {
+		return domain.AdPage{}, err
+	}
+	return page, nil
+}
+
+// ExportAds calls fn with every ad matching query in one pass over the
+// repository, however many there are; Limit and After are ignored.
+func (s *service) ExportAds(ctx context.Context, query domain.AdQuery, fn func(domain.Ad) error) error {
+	return s.adRepository.EachAd(ctx, query.WithDefaults(), fn)
+}
+
+// SearchAds ranks across the shared index, then drops hits outside the
+// caller's tenant when loading them from the repository.
+func (s *service) SearchAds(ctx context.Context, query string, status domain.Status, limit int) ([]domain.AdSearchResult, error) {
+	results := make([]domain.AdSearchResult, 0)
+	for _, hit := range s.searchIndex.Search(query) {
+		if len(results) == limit {
+			break
+		}
+		ad, err := s.adRepository.GetAd(ctx, hit.ID)
+		if err != nil {
+			continue
+		}
+		if status != "" && ad.Status != status {
+			continue
+		}
+		results = append(results, domain.AdSearchResult{Ad: ad, Score: hit.Score})
+	}
+	return results, nil
+}
+
+// CreateAds creates every ad it can and reports each outcome. In atomic mode
+// the ads are written in one transaction: the first failure rolls back the
+// whole batch and every item is reported as failed.
+func (s *service) CreateAds(ctx context.Context, ads []domain.Ad, atomic bool) ([]domain.BatchResult, error) {
+	return s.runBatch(ctx, len(ads), atomic, func(tx persistence.AdWriter, i int) (change, error) {
+		return create(ctx, tx, ads[i])
+	})
+}
+
+// DeactivateAds deactivates the given ads with the same per-item reporting
+// and atomic semantics as CreateAds.
+func (s *service) DeactivateAds(ctx context.Context, ids []string, atomic bool) ([]domain.BatchResult, error) {
+	return s.runBatch(ctx, len(ids), atomic, func(tx persistence.AdWriter, i int) (change, error) {
+		return deactivate(ctx, tx, ids[i])
+	})
+}
+
+func (s *service) runBatch(ctx context.Context, n int, atomic bool, apply func(tx persistence.AdWriter, i int) (change, error)) ([]domain.BatchResult, error) {
+	results := make([]domain.BatchResult, n)
+	changes := make(map[int]change, n)
+
+	if !atomic {
+		for i := range results {
+			c, err := apply(s.adRepository, i)
+			results[i] = batchResult(i, c.after, err)
+			if err == nil {
+				changes[i] = c
+			}
+		}
+		s.commitAll(ctx, results, changes)
+		return results, nil
+	}
+
+	err := s.adRepository.WithTransaction(ctx, func(tx persistence.AdWriter) error {
+		for i := range results {
+			c, err := apply(tx, i)
+			results[i] = batchResult(i, c.after, err)
+			if err != nil {
+				return err
+			}
+			changes[i] = c
+		}
+		return nil
+	})
+	if err != nil {
+		for i := range results {
+			if results[i].Error == "" {
+				results[i] = domain.BatchResult{Index: i, Error: "rolled back: " + err.Error()}
+			}
+		}
+		return results, nil
+	}
+
+	s.commitAll(ctx, results, changes)
+	return results, nil
+}
+
+// commitAll commits the change written for each result index. A change that
+// is not recorded keeps its result, ad included, and is marked with
+// CodeNotRecorded; the rest of the changes are still committed.
+func (s *service) commitAll(ctx context.Context, results []domain.BatchResult, changes map[int]change) {
+	for i := range results {
+		c, ok := changes[i]
+		if !ok {
+			continue
+		}
+		if err := s.commit(ctx, c); err != nil {
+			results[i].Error = err.Error()
+			results[i].Code = domain.CodeNotRecorded
+		}
+	}
+}
+
+func batchResult(i int, ad domain.Ad, err error) domain.BatchResult {
+	if err != nil {
+		return domain.BatchResult{Index: i, Error: err.Error()}
+	}
+	return domain.BatchResult{Index: i, ID: ad.ID, Ad: &ad}
+}
+
+func (s *service) GetAdHistory(ctx context.Context, id string) ([]domain.AuditRecord, error) {
+	if _, err := s.adRepository.GetAd(ctx, id); err != nil {
+		return []domain.AuditRecord{}, err
+	}
+	return s.auditStore.ListByAd(id)
+}
+
+func (s *service) UpdateAd(ctx context.Context, id string, u domain.AdUpdate) (domain.Ad, error) {
+	c, err := update(ctx, s.adRepository, id, domain.AuditActionUpdated, u.Apply)
+	if err != nil {
+		return domain.Ad{}, err
+	}
+	if err := s.commit(ctx, c); err != nil {
+		return domain.Ad{}, err
+	}
+
+	return c.after, nil
+}
+
+func (s *service) ListAdVersions(ctx context.Context, id string) ([]domain.AdVersion, error) {
+	if _, err := s.adRepository.GetAd(ctx, id); err != nil {
+		return []domain.AdVersion{}, err
+	}
+	return s.versionStore.List(id)
+}
+
+func (s *service) GetAdVersion(ctx context.Context, id string, version int) (domain.AdVersion, error) {
+	if _, err := s.adRepository.GetAd(ctx, id); err != nil {
+		return domain.AdVersion{}, err
+	}
+	return s.versionStore.Get(id, version)
+}
+
+func (s *service) DiffAdVersions(ctx context.Context, id string, from, to int) ([]domain.FieldChange, error) {
+	fromVersion, err := s.GetAdVersion(ctx, id, from)
+	if err != nil {
+		return []domain.FieldChange{}, err
+	}
+	toVersion, err := s.GetAdVersion(ctx, id, to)
+	if err != nil {
+		return []domain.FieldChange{}, err
+	}
+	return domain.DiffAds(fromVersion.Ad, toVersion.Ad), nil
+}
+
+// RollbackAd restores the ad to the given version, status included. The
+// restore is itself recorded as a new version; history is never rewritten.
+func (s *service) RollbackAd(ctx context.Context, id string, version int) (domain.Ad, error) {
+	target, err := s.GetAdVersion(ctx, id, version)
+	if err != nil {
+		return domain.Ad{}, err
+	}
+
+	c, err := update(ctx, s.adRepository, id, domain.AuditActionRolledBack, func(domain.Ad) domain.Ad {
+		return target.Ad
+	})
+	if err != nil {
+		return domain.Ad{}, err
+	}
+	if err := s.commit(ctx, c); err != nil {
+		return domain.Ad{}, err
+	}
+
+	return c.after, nil
+}
@@ -0,0 +1,299 @@
+package ads_service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ads_backend/internal/auth"
+	"ads_backend/internal/correlation"
+	"ads_backend/internal/domain"
+	"ads_backend/internal/featureflag"
+	"ads_backend/internal/metrics"
+	"ads_backend/internal/persistence"
+	"ads_backend/internal/search"
+	"ads_backend/internal/tenant"
+	"ads_backend/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newTestService() (Service, persistence.AdRepository) {
+	repo := persistence.NewAdRepository()
+	return NewService(zap.NewNop(), repo, search.NewIndex(), persistence.NewAuditStore(), persistence.NewVersionStore(), metrics.New(), featureflag.NewStatic()), repo
+}
+
+func TestCreateAds_NonAtomicIndexesEveryAd(t *testing.T) {
+	svc, _ := newTestService()
+
+	results, err := svc.CreateAds(context.Background(), []domain.Ad{
+		{Title: "Promo uno", Placement: domain.HomeScreen},
+		{Title: "Promo dos", Placement: domain.MapView},
+	}, false)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	for i, result := range results {
+		assert.Equal(t, i, result.Index)
+		assert.False(t, result.Failed())
+		assert.Equal(t, domain.StatusActive, result.Ad.Status)
+	}
+
+	found, err := svc.SearchAds(context.Background(), "promo", "", 10)
+	require.NoError(t, err)
+	assert.Len(t, found, 2)
+}
+
+func TestDeactivateAds_NonAtomicReportsPerItem(t *testing.T) {
+	svc, _ := newTestService()
+	ad, err := svc.CreateAd(context.Background(), domain.Ad{Title: "Promo", Placement: domain.HomeScreen})
+	require.NoError(t, err)
+
+	results, err := svc.DeactivateAds(context.Background(), []string{ad.ID, "missing"}, false)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.False(t, results[0].Failed())
+	assert.Equal(t, domain.StatusInactive, results[0].Ad.Status)
+	assert.Equal(t, "ad not found", results[1].Error)
+}
+
+func TestDeactivateAds_AtomicRollsBackOnFailure(t *testing.T) {
+	svc, repo := newTestService()
+	ad, err := svc.CreateAd(context.Background(), domain.Ad{Title: "Promo", Placement: domain.HomeScreen})
+	require.NoError(t, err)
+
+	results, err := svc.DeactivateAds(context.Background(), []string{ad.ID, "missing"}, true)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "rolled back: ad not found", results[0].Error)
+	assert.Equal(t, "ad not found", results[1].Error)
+
+	stored, err := repo.GetAd(context.Background(), ad.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.StatusActive, stored.Status)
+}
+
+func TestMutationsAreAudited(t *testing.T) {
+	svc, _ := newTestService()
+
+	ctx := auth.WithPrincipal(context.Background(), auth.Principal{Subject: "key-1", Method: "api_key"})
+	ctx = correlation.WithID(ctx, "corr-1")
+
+	ad, err := svc.CreateAd(ctx, domain.Ad{Title: "Promo", Placement: domain.HomeScreen})
+	require.NoError(t, err)
+	_, err = svc.DeactivateAds(context.Background(), []string{ad.ID}, true)
+	require.NoError(t, err)
+
+	history, err := svc.GetAdHistory(context.Background(), ad.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+
+	created := history[0]
+	assert.Equal(t, domain.AuditActionCreated, created.Action)
+	assert.Equal(t, "api_key:key-1", created.Actor)
+	assert.Equal(t, "corr-1", created.CorrelationID)
+	assert.Nil(t, created.Before)
+	assert.Equal(t, domain.StatusActive, created.After.Status)
+
+	deactivated := history[1]
+	assert.Equal(t, domain.AuditActionDeactivated, deactivated.Action)
+	assert.Equal(t, "anonymous", deactivated.Actor)
+	assert.Equal(t, domain.StatusActive, deactivated.Before.Status)
+	assert.Equal(t, domain.StatusInactive, deactivated.After.Status)
+}
+
+func TestChangesAreLoggedWithCorrelationID(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	svc := NewService(zap.New(core), persistence.NewAdRepository(), search.NewIndex(), persistence.NewAuditStore(), persistence.NewVersionStore(), metrics.New(), featureflag.NewStatic())
+
+	ctx := correlation.WithID(context.Background(), "corr-1")
+	ad, err := svc.CreateAd(ctx, domain.Ad{Title: "Promo", Placement: domain.HomeScreen})
+	require.NoError(t, err)
+
+	entries := logs.FilterMessage("Ad changed").All()
+	require.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, "corr-1", fields["correlation_id"])
+	assert.Equal(t, ad.ID, fields["ad_id"])
+	assert.Equal(t, string(domain.AuditActionCreated), fields["action"])
+}
+
+func TestRolledBackBatchIsNotAudited(t *testing.T) {
+	svc, _ := newTestService()
+	ad, err := svc.CreateAd(context.Background(), domain.Ad{Title: "Promo", Placement: domain.HomeScreen})
+	require.NoError(t, err)
+
+	_, err = svc.DeactivateAds(context.Background(), []string{ad.ID, "missing"}, true)
+	require.NoError(t, err)
+
+	history, err := svc.GetAdHistory(context.Background(), ad.ID)
+	require.NoError(t, err)
+	assert.Len(t, history, 1)
+
+	_, err = svc.GetAdHistory(context.Background(), "missing")
+	assert.Error(t, err)
+}
+
+func TestUpdateAd_RecordsVersions(t *testing.T) {
+	svc, _ := newTestService()
+	ad, err := svc.CreateAd(context.Background(), domain.Ad{Title: "Promo", Placement: domain.HomeScreen})
+	require.NoError(t, err)
+
+	title := "Promo 2x1"
+	updated, err := svc.UpdateAd(context.Background(), ad.ID, domain.AdUpdate{Title: &title})
+	require.NoError(t, err)
+	assert.Equal(t, "Promo 2x1", updated.Title)
+	assert.Equal(t, domain.HomeScreen, updated.Placement)
+
+	versions, err := svc.ListAdVersions(context.Background(), ad.ID)
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.Equal(t, 1, versions[0].Version)
+	assert.Equal(t, "Promo", versions[0].Ad.Title)
+	assert.Equal(t, 2, versions[1].Version)
+	assert.Equal(t, domain.AuditActionUpdated, versions[1].Action)
+
+	diff, err := svc.DiffAdVersions(context.Background(), ad.ID, 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.FieldChange{{Field: "title", From: "Promo", To: "Promo 2x1"}}, diff)
+
+	found, err := svc.SearchAds(context.Background(), "2x1", "", 10)
+	require.NoError(t, err)
+	assert.Len(t, found, 1)
+}
+
+func TestRollbackAd_CreatesNewVersion(t *testing.T) {
+	svc, _ := newTestService()
+	ad, err := svc.CreateAd(context.Background(), domain.Ad{Title: "Promo", Placement: domain.HomeScreen})
+	require.NoError(t, err)
+	title := "Typo"
+	_, err = svc.UpdateAd(context.Background(), ad.ID, domain.AdUpdate{Title: &title})
+	require.NoError(t, err)
+	_, err = svc.DeactivateAd(context.Background(), ad.ID)
+	require.NoError(t, err)
+
+	restored, err := svc.RollbackAd(context.Background(), ad.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Promo", restored.Title)
+	assert.Equal(t, domain.StatusActive, restored.Status)
+
+	versions, err := svc.ListAdVersions(context.Background(), ad.ID)
+	require.NoError(t, err)
+	require.Len(t, versions, 4)
+	assert.Equal(t, "Typo", versions[1].Ad.Title)
+	assert.Equal(t, domain.AuditActionRolledBack, versions[3].Action)
+	assert.Equal(t, versions[0].Ad, versions[3].Ad)
+
+	history, err := svc.GetAdHistory(context.Background(), ad.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 4)
+	assert.Equal(t, domain.AuditActionRolledBack, history[3].Action)
+}
+
+func TestRollbackAd_UnknownVersion(t *testing.T) {
+	svc, _ := newTestService()
+	ad, err := svc.CreateAd(context.Background(), domain.Ad{Title: "Promo", Placement: domain.HomeScreen})
+	require.NoError(t, err)
+
+	_, err = svc.RollbackAd(context.Background(), ad.ID, 5)
+	assert.EqualError(t, err, "version not found")
+
+	versions, err := svc.ListAdVersions(context.Background(), ad.ID)
+	require.NoError(t, err)
+	assert.Len(t, versions, 1)
+}
+
+func TestListEligible_NewestFirstFlag(t *testing.T) {
+	repo := persistence.NewAdRepository()
+	flags := featureflag.NewStatic(featureflag.Flag{Name: FlagNewestFirst, Enabled: true})
+	svc := NewService(zap.NewNop(), repo, search.NewIndex(), persistence.NewAuditStore(), persistence.NewVersionStore(), metrics.New(), flags)
+
+	var created []string
+	for _, title := range []string{"Oldest", "Middle", "Newest"} {
+		ad, err := svc.CreateAd(context.Background(), domain.Ad{Title: title, Placement: domain.HomeScreen})
+		require.NoError(t, err)
+		created = append([]string{ad.ID}, created...)
+		time.Sleep(time.Millisecond)
+	}
+
+	ads, err := svc.ListEligibleActiveAdsByPlacement(context.Background(), domain.HomeScreen)
+	require.NoError(t, err)
+	var served []string
+	for _, ad := range ads {
+		served = append(served, ad.ID)
+	}
+	assert.Equal(t, created, served)
+}
+
+func TestTenantsCannotReadEachOthersAds(t *testing.T) {
+	svc, _ := newTestService()
+	bogota := tenant.WithID(context.Background(), "bogota")
+	lima := tenant.WithID(context.Background(), "lima")
+
+	ad, err := svc.CreateAd(bogota, domain.Ad{Title: "Promo Bogota", Placement: domain.HomeScreen})
+	require.NoError(t, err)
+
+	_, err = svc.GetAd(bogota, ad.ID)
+	require.NoError(t, err)
+
+	_, err = svc.GetAd(lima, ad.ID)
+	assert.EqualError(t, err, "ad not found")
+	_, err = svc.DeactivateAd(lima, ad.ID)
+	assert.EqualError(t, err, "ad not found")
+	_, err = svc.GetAdHistory(lima, ad.ID)
+	assert.EqualError(t, err, "ad not found")
+	_, err = svc.ListAdVersions(lima, ad.ID)
+	assert.EqualError(t, err, "ad not found")
+
+	page, err := svc.ListAds(lima, domain.AdQuery{})
+	require.NoError(t, err)
+	assert.Empty(t, page.Ads)
+
+	eligible, err := svc.ListEligibleActiveAdsByPlacement(lima, domain.HomeScreen)
+	require.NoError(t, err)
+	assert.Empty(t, eligible)
+
+	found, err := svc.SearchAds(lima, "bogota", "", 10)
+	require.NoError(t, err)
+	assert.Empty(t, found)
+
+	found, err = svc.SearchAds(bogota, "bogota", "", 10)
+	require.NoError(t, err)
+	assert.Len(t, found, 1)
+}
+
+func TestUnrecordedChangesAreReportedAsSaved(t *testing.T) {
+	auditStore := mocks.NewAuditStore(t)
+	auditStore.On("Append", mock.Anything).Return(errors.New("disk full"))
+	repo := persistence.NewAdRepository()
+	svc := NewService(zap.NewNop(), repo, search.NewIndex(), auditStore, persistence.NewVersionStore(), metrics.New(), featureflag.NewStatic())
+
+	_, err := svc.CreateAd(context.Background(), domain.Ad{Title: "Promo uno", Placement: domain.HomeScreen})
+	assert.ErrorIs(t, err, ErrNotRecorded)
+
+	results, err := svc.CreateAds(context.Background(), []domain.Ad{
+		{Title: "Promo dos", Placement: domain.HomeScreen},
+		{Title: "Promo tres", Placement: domain.MapView},
+	}, false)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	for _, result := range results {
+		assert.False(t, result.Failed())
+		assert.Equal(t, domain.CodeNotRecorded, result.Code)
+		assert.Contains(t, result.Error, ErrNotRecorded.Error())
+		assert.NotEmpty(t, result.ID)
+		require.NotNil(t, result.Ad)
+	}
+
+	page, err := repo.ListAds(context.Background(), domain.AdQuery{}.WithDefaults())
+	require.NoError(t, err)
+	assert.Len(t, page.Ads, 3)
+
+	found, err := svc.SearchAds(context.Background(), "promo", "", 10)
+	require.NoError(t, err)
+	assert.Len(t, found, 3)
+}
@@ -0,0 +1,224 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ads_backend/internal/tenant"
+)
+
+const (
+	apiKeyPrefix      = "adk_"
+	bootstrapKeyID    = "bootstrap"
+	secretBytes       = 32
+	saltBytes         = 16
+	keyIDBytes        = 6
+	keySecretSplitter = "."
+)
+
+var (
+	ErrInvalidAPIKey  = errors.New("invalid api key")
+	ErrAPIKeyNotFound = errors.New("api key not found")
+)
+
+// APIKey is the stored form of a key. Only a salted hash of the secret is
+// kept; the plaintext is returned once, when the key is issued.
+type APIKey struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Role      Role       `json:"role"`
+	Tenant    string     `json:"tenant,omitempty"`
+	Salt      []byte     `json:"-"`
+	Hash      []byte     `json:"-"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+type KeyStore interface {
+	Create(key APIKey) error
+	Get(id string) (APIKey, error)
+	List() ([]APIKey, error)
+	Revoke(id string, at time.Time) (APIKey, error)
+}
+
+type memoryKeyStore struct {
+	keys map[string]APIKey
+	mu   sync.RWMutex
+}
+
+func NewKeyStore() KeyStore {
+	return &memoryKeyStore{keys: make(map[string]APIKey)}
+}
+
+func (s *memoryKeyStore) Create(key APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.keys[key.ID]; exists {
+		return fmt.Errorf("api key %s already exists", key.ID)
+	}
+	s.keys[key.ID] = key
+	return nil
+}
+
+func (s *memoryKeyStore) Get(id string) (APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, ok := s.keys[id]
+	if !ok {
+		return APIKey{}, ErrAPIKeyNotFound
+	}
+	return key, nil
+}
+
+func (s *memoryKeyStore) List() ([]APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]APIKey, 0, len(s.keys))
+	for _, key := range s.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	return keys, nil
+}
+
+func (s *memoryKeyStore) Revoke(id string, at time.Time) (APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, ok := s.keys[id]
+	if !ok {
+		return APIKey{}, ErrAPIKeyNotFound
+	}
+	if key.RevokedAt == nil {
+		key.RevokedAt = &at
+		s.keys[id] = key
+	}
+	return key, nil
+}
+
+// KeyService issues and verifies API keys of the form adk_<id>.<secret>.
+type KeyService struct {
+	store        KeyStore
+	bootstrapKey []byte
+}
+
+func NewKeyService(store KeyStore) *KeyService {
+	return &KeyService{store: store}
+}
+
+// NewKeyServiceFromConfig wraps store. When config.AdminAPIKey is set, that
+// value is also accepted as an admin key so the first real keys can be
+// issued.
+func NewKeyServiceFromConfig(store KeyStore, config Config) *KeyService {
+	s := NewKeyService(store)
+	if config.AdminAPIKey != "" {
+		sum := sha256.Sum256([]byte(config.AdminAPIKey))
+		s.bootstrapKey = sum[:]
+	}
+	return s
+}
+
+// Issue creates a key for role. A non-empty tenantID binds the key to that
+// tenant; otherwise the key works in the default tenant, or in any tenant
+// for admins.
+func (s *KeyService) Issue(name string, role Role, tenantID string) (string, APIKey, error) {
+	if name == "" {
+		return "", APIKey{}, fmt.Errorf("name is required")
+	}
+	if !role.Valid() {
+		return "", APIKey{}, fmt.Errorf("role must be one of viewer, reviewer, editor, admin")
+	}
+	if tenantID != "" && !tenant.Valid(tenantID) {
+		return "", APIKey{}, fmt.Errorf("invalid tenant id")
+	}
+
+	id, err := randomBytes(keyIDBytes)
+	if err != nil {
+		return "", APIKey{}, err
+	}
+	secret, err := randomBytes(secretBytes)
+	if err != nil {
+		return "", APIKey{}, err
+	}
+	salt, err := randomBytes(saltBytes)
+	if err != nil {
+		return "", APIKey{}, err
+	}
+
+	key := APIKey{
+		ID:        hex.EncodeToString(id),
+		Name:      name,
+		Role:      role,
+		Tenant:    tenantID,
+		Salt:      salt,
+		CreatedAt: time.Now(),
+	}
+	plaintextSecret := base64.RawURLEncoding.EncodeToString(secret)
+	key.Hash = hashSecret(salt, plaintextSecret)
+
+	if err := s.store.Create(key); err != nil {
+		return "", APIKey{}, err
+	}
+	return apiKeyPrefix + key.ID + keySecretSplitter + plaintextSecret, key, nil
+}
+
+func (s *KeyService) Authenticate(raw string) (Principal, error) {
+	if s.bootstrapKey != nil {
+		sum := sha256.Sum256([]byte(raw))
+		if subtle.ConstantTimeCompare(sum[:], s.bootstrapKey) == 1 {
+			return Principal{Subject: bootstrapKeyID, Name: "bootstrap admin", Method: MethodAPIKey, Roles: []Role{RoleAdmin}}, nil
+		}
+	}
+
+	id, secret, ok := strings.Cut(strings.TrimPrefix(raw, apiKeyPrefix), keySecretSplitter)
+	if !strings.HasPrefix(raw, apiKeyPrefix) || !ok {
+		return Principal{}, ErrInvalidAPIKey
+	}
+
+	key, err := s.store.Get(id)
+	if err != nil || key.RevokedAt != nil {
+		return Principal{}, ErrInvalidAPIKey
+	}
+	if subtle.ConstantTimeCompare(hashSecret(key.Salt, secret), key.Hash) != 1 {
+		return Principal{}, ErrInvalidAPIKey
+	}
+
+	return Principal{Subject: key.ID, Name: key.Name, Method: MethodAPIKey, Roles: []Role{key.Role}, Tenant: key.Tenant}, nil
+}
+
+func (s *KeyService) Get(id string) (APIKey, error) {
+	return s.store.Get(id)
+}
+
+func (s *KeyService) List() ([]APIKey, error) {
+	return s.store.List()
+}
+
+func (s *KeyService) Revoke(id string) (APIKey, error) {
+	return s.store.Revoke(id, time.Now())
+}
+
+// hashSecret uses a single salted SHA-256: secrets are 256 random bits, so
+// unlike passwords they need no deliberately slow hash.
+func hashSecret(salt []byte, secret string) []byte {
+	h := sha256.New()
+	h.Write(salt)
+	h.Write([]byte(secret))
+	return h.Sum(nil)
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
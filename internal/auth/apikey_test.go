@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyService_IssueAndAuthenticate(t *testing.T) {
+	store := NewKeyStore()
+	keys := NewKeyService(store)
+
+	plaintext, key, err := keys.Issue("ops", RoleEditor, "")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(plaintext, "adk_"+key.ID+"."))
+
+	stored, err := store.Get(key.ID)
+	require.NoError(t, err)
+	assert.NotContains(t, string(stored.Hash), plaintext)
+	assert.Len(t, stored.Salt, saltBytes)
+
+	principal, err := keys.Authenticate(plaintext)
+	require.NoError(t, err)
+	assert.Equal(t, key.ID, principal.Subject)
+	assert.True(t, principal.HasRole(RoleEditor))
+}
+
+func TestKeyService_RejectsWrongSecretAndRevokedKeys(t *testing.T) {
+	keys := NewKeyService(NewKeyStore())
+
+	plaintext, key, err := keys.Issue("ops", RoleAdmin, "")
+	require.NoError(t, err)
+
+	_, err = keys.Authenticate(plaintext + "x")
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+	_, err = keys.Authenticate("not-a-key")
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+
+	revoked, err := keys.Revoke(key.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, revoked.RevokedAt)
+
+	_, err = keys.Authenticate(plaintext)
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+}
+
+func TestKeyService_IssueValidatesInput(t *testing.T) {
+	keys := NewKeyService(NewKeyStore())
+
+	_, _, err := keys.Issue("", RoleEditor, "")
+	assert.Error(t, err)
+	_, _, err = keys.Issue("ops", Role("root"), "")
+	assert.Error(t, err)
+	_, _, err = keys.Issue("ops", RoleEditor, "Bogota!")
+	assert.Error(t, err)
+}
+
+func TestKeyService_KeyCarriesTenant(t *testing.T) {
+	keys := NewKeyService(NewKeyStore())
+
+	plaintext, key, err := keys.Issue("ops", RoleEditor, "bogota")
+	require.NoError(t, err)
+	assert.Equal(t, "bogota", key.Tenant)
+
+	principal, err := keys.Authenticate(plaintext)
+	require.NoError(t, err)
+	assert.Equal(t, "bogota", principal.Tenant)
+}
+
+func TestKeyService_BootstrapKey(t *testing.T) {
+	keys := NewKeyServiceFromConfig(NewKeyStore(), Config{AdminAPIKey: "bootstrap-secret"})
+
+	principal, err := keys.Authenticate("bootstrap-secret")
+	require.NoError(t, err)
+	assert.True(t, principal.HasRole(RoleAdmin))
+}
@@ -0,0 +1,25 @@
+package auth
+
+// Config is the auth section of the app config.
+type Config struct {
+	// AdminAPIKey is accepted as an admin key so the first real keys can be
+	// issued.
+	AdminAPIKey string `yaml:"admin_api_key"`
+	// PublicReads lets anonymous callers use the serving endpoints.
+	PublicReads bool        `yaml:"public_reads"`
+	JWT         JWTSettings `yaml:"jwt"`
+}
+
+// JWTSettings says where NewTokenVerifier finds its keys and what it
+// requires of every token.
+type JWTSettings struct {
+	HS256Secret        string `yaml:"hs256_secret"`
+	RS256PublicKeyFile string `yaml:"rs256_public_key_file"`
+	JWKSFile           string `yaml:"jwks_file"`
+	Audience           string `yaml:"audience"`
+	Issuer             string `yaml:"issuer"`
+}
+
+func DefaultConfig() Config {
+	return Config{PublicReads: true}
+}
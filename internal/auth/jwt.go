@@ -0,0 +1,200 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"ads_backend/internal/tenant"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+const jwtLeeway = 30 * time.Second
+
+var ErrInvalidToken = errors.New("invalid bearer token")
+
+// JWTConfig holds the keys tokens may be signed with, by key ID. A key with
+// an empty ID is used for tokens that carry no "kid" header.
+type JWTConfig struct {
+	HMACKeys map[string][]byte
+	RSAKeys  map[string]*rsa.PublicKey
+	Audience string
+	Issuer   string
+}
+
+type tokenClaims struct {
+	Name   string   `json:"name"`
+	Roles  []string `json:"roles"`
+	Tenant string   `json:"tenant"`
+	jwt.RegisteredClaims
+}
+
+// TokenVerifier verifies HS256 and RS256 bearer tokens and turns their role
+// claims into a Principal.
+type TokenVerifier struct {
+	config JWTConfig
+	parser *jwt.Parser
+}
+
+// NewTokenVerifier loads the keys settings names: an HS256 secret, an RS256
+// public key (PEM) and a JWKS file. With no keys configured every token is
+// rejected. Keys without an audience are accepted but logged as a warning,
+// since any token the same issuer signs for another service then passes.
+func NewTokenVerifier(log *zap.Logger, settings JWTSettings) (*TokenVerifier, error) {
+	config := JWTConfig{
+		HMACKeys: make(map[string][]byte),
+		RSAKeys:  make(map[string]*rsa.PublicKey),
+		Audience: settings.Audience,
+		Issuer:   settings.Issuer,
+	}
+
+	if settings.HS256Secret != "" {
+		config.HMACKeys[""] = []byte(settings.HS256Secret)
+	}
+
+	if path := settings.RS256PublicKeyFile; path != "" {
+		pem, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading JWT public key: %w", err)
+		}
+		key, err := jwt.ParseRSAPublicKeyFromPEM(pem)
+		if err != nil {
+			return nil, fmt.Errorf("parsing JWT public key: %w", err)
+		}
+		config.RSAKeys[""] = key
+	}
+
+	if path := settings.JWKSFile; path != "" {
+		hmacKeys, rsaKeys, err := LoadJWKS(path)
+		if err != nil {
+			return nil, err
+		}
+		for kid, key := range hmacKeys {
+			config.HMACKeys[kid] = key
+		}
+		for kid, key := range rsaKeys {
+			config.RSAKeys[kid] = key
+		}
+	}
+
+	if (len(config.HMACKeys) > 0 || len(config.RSAKeys) > 0) && config.Audience == "" {
+		log.Warn("JWT audience is not configured, tokens issued for any audience are accepted",
+			zap.String("issuer", config.Issuer))
+	}
+	return NewTokenVerifierFromConfig(config), nil
+}
+
+func NewTokenVerifierFromConfig(config JWTConfig) *TokenVerifier {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg(), jwt.SigningMethodRS256.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(jwtLeeway),
+	}
+	if config.Audience != "" {
+		options = append(options, jwt.WithAudience(config.Audience))
+	}
+	if config.Issuer != "" {
+		options = append(options, jwt.WithIssuer(config.Issuer))
+	}
+	return &TokenVerifier{config: config, parser: jwt.NewParser(options...)}
+}
+
+func (v *TokenVerifier) Verify(raw string) (Principal, error) {
+	var claims tokenClaims
+	if _, err := v.parser.ParseWithClaims(raw, &claims, v.key); err != nil {
+		return Principal{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	if claims.Subject == "" {
+		return Principal{}, fmt.Errorf("%w: missing subject", ErrInvalidToken)
+	}
+	if claims.Tenant != "" && !tenant.Valid(claims.Tenant) {
+		return Principal{}, fmt.Errorf("%w: invalid tenant claim", ErrInvalidToken)
+	}
+
+	principal := Principal{Subject: claims.Subject, Name: claims.Name, Method: MethodJWT, Tenant: claims.Tenant}
+	for _, claim := range claims.Roles {
+		if role := Role(claim); role.Valid() {
+			principal.Roles = append(principal.Roles, role)
+		}
+	}
+	return principal, nil
+}
+
+func (v *TokenVerifier) key(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	switch token.Method.Alg() {
+	case jwt.SigningMethodHS256.Alg():
+		if key, ok := v.config.HMACKeys[kid]; ok {
+			return key, nil
+		}
+	case jwt.SigningMethodRS256.Alg():
+		if key, ok := v.config.RSAKeys[kid]; ok {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("no %s key with id %q", token.Method.Alg(), kid)
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	K   string `json:"k"`
+}
+
+// LoadJWKS reads a local JSON Web Key Set, returning its symmetric ("oct")
+// and RSA signing keys by key ID.
+func LoadJWKS(path string) (map[string][]byte, map[string]*rsa.PublicKey, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading JWKS: %w", err)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(raw, &set); err != nil {
+		return nil, nil, fmt.Errorf("parsing JWKS: %w", err)
+	}
+
+	hmacKeys := make(map[string][]byte)
+	rsaKeys := make(map[string]*rsa.PublicKey)
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		switch jwk.Kty {
+		case "oct":
+			secret, err := base64.RawURLEncoding.DecodeString(jwk.K)
+			if err != nil {
+				return nil, nil, fmt.Errorf("JWKS key %q: invalid k: %w", jwk.Kid, err)
+			}
+			hmacKeys[jwk.Kid] = secret
+		case "RSA":
+			n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+			if err != nil {
+				return nil, nil, fmt.Errorf("JWKS key %q: invalid n: %w", jwk.Kid, err)
+			}
+			e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+			if err != nil {
+				return nil, nil, fmt.Errorf("JWKS key %q: invalid e: %w", jwk.Kid, err)
+			}
+			rsaKeys[jwk.Kid] = &rsa.PublicKey{
+				N: new(big.Int).SetBytes(n),
+				E: int(new(big.Int).SetBytes(e).Int64()),
+			}
+		}
+	}
+	return hmacKeys, rsaKeys, nil
+}
@@ -0,0 +1,38 @@
+package auth
+
+type Permission string
+
+const (
+	// PermServeAds covers the read-only serving endpoints the rider app
+	// calls. It is the only permission anonymous callers can be granted.
+	PermServeAds      Permission = "ads:serve"
+	PermReadAds       Permission = "ads:read"
+	PermCreateAds     Permission = "ads:create"
+	PermUpdateAds     Permission = "ads:update"
+	PermDeactivateAds Permission = "ads:deactivate"
+	PermAdmin         Permission = "admin"
+	// PermSystem covers the process-wide admin routes: feature flags, the
+	// log level and metrics. Admins bound to a tenant are never granted it.
+	PermSystem Permission = "system"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleViewer:   {PermServeAds, PermReadAds},
+	RoleReviewer: {PermServeAds, PermReadAds, PermDeactivateAds},
+	RoleEditor:   {PermServeAds, PermReadAds, PermCreateAds, PermUpdateAds, PermDeactivateAds},
+	RoleAdmin:    {PermServeAds, PermReadAds, PermCreateAds, PermUpdateAds, PermDeactivateAds, PermAdmin, PermSystem},
+}
+
+func (p Principal) Can(permission Permission) bool {
+	if permission == PermSystem && p.Tenant != "" {
+		return false
+	}
+	for _, role := range p.Roles {
+		for _, granted := range rolePermissions[role] {
+			if granted == permission {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,267 @@
+// Package client calls the ads API over HTTP, for tools that manage ads
+// from outside the service.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"ads_backend/internal/domain"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// Client sends every request with the credentials and tenant it was
+// configured with.
+type Client struct {
+	baseURL string
+	apiKey  string
+	tenant  string
+	http    *http.Client
+}
+
+// New builds a client for the API at baseURL. apiKey is an API key or a JWT
+// and is sent as a bearer token; tenant, when set, is sent as X-Tenant-ID.
+func New(baseURL, apiKey, tenant string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+		tenant:  tenant,
+		http:    httpClient,
+	}
+}
+
+// APIError is a response the API answered with a status of 400 or above.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// StatusCode is the status of the API's answer when err is an *APIError,
+// and 0 otherwise.
+func StatusCode(err error) int {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// AdInput is the ad to create. TTLMinutes is left to the server's default
+// when nil.
+type AdInput struct {
+	Title      string           `json:"title"`
+	ImageURL   string           `json:"imageUrl"`
+	Placement  domain.Placement `json:"placement"`
+	TTLMinutes *int             `json:"ttlMinutes,omitempty"`
+}
+
+// ImportResult is the API's report on an import. Rejected imports carry it
+// too, with the rows that failed in Errors.
+type ImportResult struct {
+	DryRun  bool             `json:"dry_run"`
+	Rows    int              `json:"rows"`
+	Created int              `json:"created"`
+	IDs     []string         `json:"ids,omitempty"`
+	Errors  []ImportRowError `json:"errors,omitempty"`
+}
+
+type ImportRowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// ImportError is an import rejected because of invalid rows. It unwraps to
+// the *APIError for the 422 response.
+type ImportError struct {
+	Err    *APIError
+	Result ImportResult
+}
+
+func (e *ImportError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ImportError) Unwrap() error {
+	return e.Err
+}
+
+func (c *Client) CreateAd(ctx context.Context, input AdInput) (domain.Ad, error) {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return domain.Ad{}, err
+	}
+	var ad domain.Ad
+	err = c.doJSON(ctx, http.MethodPost, "/adposts", nil, bytes.NewReader(body), "application/json", &ad)
+	return ad, err
+}
+
+func (c *Client) GetAd(ctx context.Context, id string) (domain.Ad, error) {
+	var ad domain.Ad
+	err := c.doJSON(ctx, http.MethodGet, "/adposts/"+url.PathEscape(id), nil, nil, "", &ad)
+	return ad, err
+}
+
+// ListAds fetches one page. query takes the filters GET /adposts does, e.g.
+// status, placement, sort, limit and cursor.
+func (c *Client) ListAds(ctx context.Context, query url.Values) (domain.AdPage, error) {
+	var page domain.AdPage
+	err := c.doJSON(ctx, http.MethodGet, "/adposts", query, nil, "", &page)
+	return page, err
+}
+
+// ListAllAds follows the cursors from query until the last page.
+func (c *Client) ListAllAds(ctx context.Context, query url.Values) ([]domain.Ad, error) {
+	query = cloneValues(query)
+	ads := make([]domain.Ad, 0)
+	for {
+		page, err := c.ListAds(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		ads = append(ads, page.Ads...)
+		if page.NextCursor == "" {
+			return ads, nil
+		}
+		query.Set("cursor", page.NextCursor)
+	}
+}
+
+func (c *Client) DeactivateAd(ctx context.Context, id string) (domain.Ad, error) {
+	var ad domain.Ad
+	err := c.doJSON(ctx, http.MethodPost, "/adposts/"+url.PathEscape(id)+"/deactivate", nil, nil, "", &ad)
+	return ad, err
+}
+
+// ImportAds uploads a CSV or NDJSON file of ads. Imports are all or
+// nothing; one with invalid rows fails with an *ImportError.
+func (c *Client) ImportAds(ctx context.Context, body io.Reader, format string, dryRun bool) (ImportResult, error) {
+	query := url.Values{"format": {format}}
+	if dryRun {
+		query.Set("dry_run", "true")
+	}
+	resp, err := c.do(ctx, http.MethodPost, "/adposts/import", query, body, contentTypeForFormat(format))
+	if err != nil {
+		return ImportResult{}, err
+	}
+	defer resp.Body.Close()
+
+	var result ImportResult
+	if resp.StatusCode == http.StatusUnprocessableEntity {
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return ImportResult{}, err
+		}
+		return result, &ImportError{
+			Err:    &APIError{StatusCode: resp.StatusCode, Message: fmt.Sprintf("%d of %d rows are invalid", len(result.Errors), result.Rows)},
+			Result: result,
+		}
+	}
+	if err := checkResponse(resp); err != nil {
+		return ImportResult{}, err
+	}
+	return result, json.NewDecoder(resp.Body).Decode(&result)
+}
+
+// ExportAds streams the ads matching query to w as CSV or NDJSON.
+func (c *Client) ExportAds(ctx context.Context, w io.Writer, format string, query url.Values) error {
+	query = cloneValues(query)
+	query.Set("format", format)
+	resp, err := c.do(ctx, http.MethodGet, "/adposts/export", query, nil, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+func (c *Client) doJSON(ctx context.Context, method, path string, query url.Values, body io.Reader, contentType string, out any) error {
+	resp, err := c.do(ctx, method, path, query, body, contentType)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body io.Reader, contentType string) (*http.Response, error) {
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	if c.tenant != "" {
+		req.Header.Set("X-Tenant-ID", c.tenant)
+	}
+	return c.http.Do(req)
+}
+
+// checkResponse turns an error status into an *APIError, taking the message
+// from the JSON error envelope or, for plain text errors, the body itself.
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode < http.StatusBadRequest {
+		return nil
+	}
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+
+	var envelope struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	message := strings.TrimSpace(string(raw))
+	if json.Unmarshal(raw, &envelope) == nil && envelope.Error.Message != "" {
+		message = envelope.Error.Message
+	}
+	if message == "" {
+		message = http.StatusText(resp.StatusCode)
+	}
+	return &APIError{StatusCode: resp.StatusCode, Message: message}
+}
+
+func contentTypeForFormat(format string) string {
+	if format == FormatCSV {
+		return "text/csv"
+	}
+	return "application/x-ndjson"
+}
+
+func cloneValues(values url.Values) url.Values {
+	clone := url.Values{}
+	for key, value := range values {
+		clone[key] = append([]string(nil), value...)
+	}
+	return clone
+}
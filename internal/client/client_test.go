@@ -0,0 +1,57 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_SendsCredentialsAndTenant(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer key-1", r.Header.Get("Authorization"))
+		assert.Equal(t, "bogota", r.Header.Get("X-Tenant-ID"))
+		assert.Equal(t, "/adposts/ad%2F1", r.URL.EscapedPath())
+		fmt.Fprint(w, `{"id": "ad/1", "title": "Promo"}`)
+	}))
+	defer server.Close()
+
+	ad, err := New(server.URL+"/", "key-1", "bogota", nil).GetAd(context.Background(), "ad/1")
+	require.NoError(t, err)
+	assert.Equal(t, "Promo", ad.Title)
+}
+
+func TestClient_ReportsAPIErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		message     string
+	}{
+		{name: "json envelope", contentType: "application/json", body: `{"error": {"code": "forbidden", "message": "missing permission"}}`, status: http.StatusForbidden, message: "missing permission"},
+		{name: "plain text", contentType: "text/plain", body: "ad not found\n", status: http.StatusNotFound, message: "ad not found"},
+		{name: "empty body", status: http.StatusBadGateway, message: "Bad Gateway"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			_, err := New(server.URL, "", "", nil).DeactivateAd(context.Background(), "ad-1")
+			var apiErr *APIError
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, tt.status, apiErr.StatusCode)
+			assert.Equal(t, tt.message, apiErr.Message)
+			assert.Equal(t, tt.status, StatusCode(err))
+		})
+	}
+}
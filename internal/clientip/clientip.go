@@ -0,0 +1,178 @@
+package clientip
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+type contextKey string
+
+const ipKey contextKey = "client-ip"
+
+func WithIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, ipKey, ip)
+}
+
+// FromContext returns the IP Resolver.Middleware resolved for the request, or
+// "" outside of it.
+func FromContext(ctx context.Context) string {
+	if ip, ok := ctx.Value(ipKey).(string); ok {
+		return ip
+	}
+	return ""
+}
+
+// Resolver finds the client address of requests that arrive through trusted
+// proxies. Forwarding headers are only believed when the direct peer is
+// trusted, and are read right to left: each trusted hop vouches for the
+// address to its left, so the first untrusted address is the client and
+// anything further left, which the client may have written itself, is
+// ignored.
+type Resolver struct {
+	trusted []netip.Prefix
+}
+
+func NewResolver(trustedProxies []string) (*Resolver, error) {
+	trusted, err := ParsePrefixes(trustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	return &Resolver{trusted: trusted}, nil
+}
+
+// Config is the client_ip section of the app config.
+type Config struct {
+	// TrustedProxies holds addresses or CIDR prefixes. With none, the TCP
+	// peer is always the client.
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
+func (c Config) Validate() error {
+	_, err := ParsePrefixes(c.TrustedProxies)
+	return err
+}
+
+func NewResolverFromConfig(config Config) (*Resolver, error) {
+	return NewResolver(config.TrustedProxies)
+}
+
+// TrustedProxies returns the configured prefixes, for reporting.
+func (res *Resolver) TrustedProxies() []string {
+	proxies := make([]string, len(res.trusted))
+	for i, prefix := range res.trusted {
+		proxies[i] = prefix.String()
+	}
+	return proxies
+}
+
+func (res *Resolver) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = r.WithContext(WithIP(r.Context(), res.Resolve(r)))
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (res *Resolver) Resolve(r *http.Request) string {
+	peer, err := parseAddr(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	if !res.isTrusted(peer) {
+		return peer.String()
+	}
+
+	hops := forwardedFor(r.Header.Values("Forwarded"))
+	if hops == nil {
+		hops = splitList(r.Header.Values("X-Forwarded-For"))
+	}
+
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := parseAddr(hops[i])
+		if err != nil {
+			// An unknown or obfuscated hop breaks the chain; the last
+			// address we could verify is the best we know.
+			break
+		}
+		client = addr
+		if !res.isTrusted(addr) {
+			break
+		}
+	}
+	return client.String()
+}
+
+func (res *Resolver) isTrusted(addr netip.Addr) bool {
+	for _, prefix := range res.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedFor returns the for= parameters of RFC 7239 Forwarded headers, in
+// order, or nil when there are none.
+func forwardedFor(values []string) []string {
+	var hops []string
+	for _, element := range splitList(values) {
+		for _, pair := range strings.Split(element, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if ok && strings.EqualFold(key, "for") {
+				hops = append(hops, strings.Trim(value, `"`))
+			}
+		}
+	}
+	return hops
+}
+
+func splitList(values []string) []string {
+	var items []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
+// parseAddr accepts a bare IP or an IP with a port, with IPv6 optionally in
+// brackets.
+func parseAddr(s string) (netip.Addr, error) {
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	addr, err := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(s, "["), "]"))
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	return addr.Unmap(), nil
+}
+
+// ParsePrefixes reads addresses and CIDR prefixes; a bare address is a
+// prefix of one.
+func ParsePrefixes(entries []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, err
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, err
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
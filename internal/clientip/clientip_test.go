@@ -0,0 +1,120 @@
+package clientip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolver_Resolve(t *testing.T) {
+	resolver, err := NewResolver([]string{"10.0.0.0/8", "2001:db8::1"})
+	require.NoError(t, err)
+
+	cases := map[string]struct {
+		remoteAddr string
+		headers    map[string][]string
+		expected   string
+	}{
+		"direct client": {
+			remoteAddr: "203.0.113.7:5000",
+			expected:   "203.0.113.7",
+		},
+		"untrusted peer cannot spoof": {
+			remoteAddr: "203.0.113.7:5000",
+			headers:    map[string][]string{"X-Forwarded-For": {"198.51.100.1"}},
+			expected:   "203.0.113.7",
+		},
+		"trusted proxy": {
+			remoteAddr: "10.0.0.5:5000",
+			headers:    map[string][]string{"X-Forwarded-For": {"198.51.100.1"}},
+			expected:   "198.51.100.1",
+		},
+		"spoofed leftmost entry is ignored": {
+			remoteAddr: "10.0.0.5:5000",
+			headers:    map[string][]string{"X-Forwarded-For": {"1.2.3.4, 198.51.100.1, 10.0.0.9"}},
+			expected:   "198.51.100.1",
+		},
+		"repeated headers are one list": {
+			remoteAddr: "10.0.0.5:5000",
+			headers:    map[string][]string{"X-Forwarded-For": {"1.2.3.4", "198.51.100.1"}},
+			expected:   "198.51.100.1",
+		},
+		"only proxies": {
+			remoteAddr: "10.0.0.5:5000",
+			headers:    map[string][]string{"X-Forwarded-For": {"10.1.1.1, 10.2.2.2"}},
+			expected:   "10.1.1.1",
+		},
+		"garbage stops the walk": {
+			remoteAddr: "10.0.0.5:5000",
+			headers:    map[string][]string{"X-Forwarded-For": {"198.51.100.1, not-an-ip, 10.0.0.9"}},
+			expected:   "10.0.0.9",
+		},
+		"forwarded header": {
+			remoteAddr: "10.0.0.5:5000",
+			headers:    map[string][]string{"Forwarded": {`for=1.2.3.4, for="[2001:db8:cafe::17]:4711";proto=https, For=10.0.0.9`}},
+			expected:   "2001:db8:cafe::17",
+		},
+		"forwarded wins over x-forwarded-for": {
+			remoteAddr: "10.0.0.5:5000",
+			headers: map[string][]string{
+				"Forwarded":       {"for=198.51.100.1"},
+				"X-Forwarded-For": {"198.51.100.2"},
+			},
+			expected: "198.51.100.1",
+		},
+		"forwarded unknown": {
+			remoteAddr: "10.0.0.5:5000",
+			headers:    map[string][]string{"Forwarded": {"for=unknown"}},
+			expected:   "10.0.0.5",
+		},
+		"ipv6 trusted proxy": {
+			remoteAddr: "[2001:db8::1]:443",
+			headers:    map[string][]string{"X-Forwarded-For": {"198.51.100.1"}},
+			expected:   "198.51.100.1",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/adspots", nil)
+			req.RemoteAddr = tc.remoteAddr
+			for key, values := range tc.headers {
+				for _, value := range values {
+					req.Header.Add(key, value)
+				}
+			}
+			assert.Equal(t, tc.expected, resolver.Resolve(req))
+		})
+	}
+}
+
+func TestResolver_MiddlewareSetsContext(t *testing.T) {
+	resolver, err := NewResolver([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+
+	var got string
+	handler := resolver.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = FromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/adspots", nil)
+	req.RemoteAddr = "10.0.0.5:5000"
+	req.Header.Set("X-Forwarded-For", "198.51.100.1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, "198.51.100.1", got)
+}
+
+func TestNewResolverFromConfig(t *testing.T) {
+	resolver, err := NewResolverFromConfig(Config{TrustedProxies: []string{"10.0.0.0/8", "192.168.1.1"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.0/8", "192.168.1.1/32"}, resolver.TrustedProxies())
+
+	config := Config{TrustedProxies: []string{"10.0.0.0/33"}}
+	assert.Error(t, config.Validate())
+	_, err = NewResolverFromConfig(config)
+	assert.Error(t, err)
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	"ads_backend/internal/auth"
+	"ads_backend/internal/clientip"
+	"ads_backend/internal/featureflag"
+	http_server "ads_backend/internal/http"
+	"ads_backend/internal/idempotency"
+	"ads_backend/internal/logging"
+	"ads_backend/internal/ratelimit"
+	"ads_backend/internal/tracing"
+
+	"go.uber.org/fx"
+	"gopkg.in/yaml.v3"
+)
+
+const masked = "********"
+
+// Config is every setting the app reads at startup, one section per
+// package. Each package defines and validates its own section.
+type Config struct {
+	Server       http_server.ServerConfig `yaml:"server"`
+	Logging      logging.Config           `yaml:"logging"`
+	Auth         auth.Config              `yaml:"auth"`
+	ClientIP     clientip.Config          `yaml:"client_ip"`
+	RateLimit    ratelimit.Config         `yaml:"rate_limit"`
+	Idempotency  idempotency.Config       `yaml:"idempotency"`
+	Tracing      tracing.Config           `yaml:"tracing"`
+	FeatureFlags featureflag.Config       `yaml:"feature_flags"`
+	Reload       ReloadConfig             `yaml:"reload"`
+}
+
+func Default() Config {
+	return Config{
+		Server:      http_server.DefaultServerConfig(),
+		Logging:     logging.DefaultConfig(),
+		Auth:        auth.DefaultConfig(),
+		RateLimit:   ratelimit.DefaultConfig(),
+		Idempotency: idempotency.DefaultConfig(),
+		Tracing:     tracing.DefaultConfig(),
+		Reload:      ReloadConfig{WatchInterval: 10 * time.Second},
+	}
+}
+
+func (c Config) Validate() error {
+	sections := []struct {
+		name     string
+		validate func() error
+	}{
+		{"server", c.Server.Validate},
+		{"logging", c.Logging.Validate},
+		{"client_ip", c.ClientIP.Validate},
+		{"rate_limit", c.RateLimit.Validate},
+		{"idempotency", c.Idempotency.Validate},
+		{"tracing", c.Tracing.Validate},
+		{"reload", c.Reload.Validate},
+	}
+	for _, section := range sections {
+		if err := section.validate(); err != nil {
+			return fmt.Errorf("%s: %w", section.name, err)
+		}
+	}
+	return nil
+}
+
+// Masked returns a copy of c with secrets replaced, safe to print or log.
+func (c Config) Masked() Config {
+	if c.Auth.AdminAPIKey != "" {
+		c.Auth.AdminAPIKey = masked
+	}
+	if c.Auth.JWT.HS256Secret != "" {
+		c.Auth.JWT.HS256Secret = masked
+	}
+	if u, err := url.Parse(c.RateLimit.RedisURL); err == nil && u.User != nil {
+		c.RateLimit.RedisURL = u.Redacted()
+	}
+	return c
+}
+
+// Write prints c, with secrets masked, as YAML that Load accepts back.
+func Write(w io.Writer, c Config) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(c.Masked()); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+type sections struct {
+	fx.Out
+
+	Server       http_server.ServerConfig
+	Logging      logging.Config
+	Auth         auth.Config
+	JWT          auth.JWTSettings
+	ClientIP     clientip.Config
+	RateLimit    ratelimit.Config
+	Idempotency  idempotency.Config
+	Tracing      tracing.Config
+	FeatureFlags featureflag.Config
+}
+
+// Module supplies config, the one loader loaded at startup, and each of its
+// sections, so constructors depend only on the section they use. It also
+// provides the Watcher that reloads it.
+func Module(loader *Loader, config Config) fx.Option {
+	return fx.Module("config",
+		fx.Supply(loader, config),
+		fx.Provide(NewWatcher),
+		fx.Provide(func(c Config) sections {
+			return sections{
+				Server:       c.Server,
+				Logging:      c.Logging,
+				Auth:         c.Auth,
+				JWT:          c.Auth.JWT,
+				ClientIP:     c.ClientIP,
+				RateLimit:    c.RateLimit,
+				Idempotency:  c.Idempotency,
+				Tracing:      c.Tracing,
+				FeatureFlags: c.FeatureFlags,
+			}
+		}),
+	)
+}
@@ -0,0 +1,214 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Loader builds the Config from, in increasing precedence, the defaults, a
+// YAML or JSON file, environment variables and command line flags. Every
+// setting can be given in all three places: flags are named after the
+// setting's path in the file, e.g. -server.port, and environment variables
+// are listed in settings.
+type Loader struct {
+	// File is the config file, from -config or CONFIG_FILE. It may be empty.
+	File string
+	// PrintConfig is set by -print-config.
+	PrintConfig bool
+	// flags holds the settings given on the command line, by key.
+	flags map[string]string
+}
+
+// NewLoader parses args, the command line without the program name. Errors,
+// and the usage when -h is asked for (flag.ErrHelp), are printed to output.
+func NewLoader(name string, args []string, output io.Writer) (*Loader, error) {
+	l := &Loader{File: os.Getenv("CONFIG_FILE"), flags: make(map[string]string)}
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.StringVar(&l.File, "config", l.File, "YAML or JSON config `file` (env CONFIG_FILE)")
+	fs.BoolVar(&l.PrintConfig, "print-config", false, "print the effective config, secrets masked, and exit")
+	for _, s := range (&Config{}).settings() {
+		key := s.key
+		fs.Func(key, fmt.Sprintf("sets %s (env %s)", key, s.env), func(raw string) error {
+			l.flags[key] = raw
+			return nil
+		})
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		err := fmt.Errorf("unexpected argument %q", fs.Arg(0))
+		fmt.Fprintln(output, err)
+		fs.Usage()
+		return nil, err
+	}
+	return l, nil
+}
+
+// Load reads the file and the environment again and returns the validated
+// Config.
+func (l *Loader) Load() (Config, error) {
+	config := Default()
+	if l.File != "" {
+		if err := readFile(l.File, &config); err != nil {
+			return Config{}, err
+		}
+	}
+
+	settings := config.settings()
+	for _, s := range settings {
+		raw := os.Getenv(s.env)
+		if raw == "" {
+			continue
+		}
+		if err := s.set(raw); err != nil {
+			return Config{}, fmt.Errorf("%s: %w", s.env, err)
+		}
+	}
+	for _, s := range settings {
+		raw, ok := l.flags[s.key]
+		if !ok {
+			continue
+		}
+		if err := s.set(raw); err != nil {
+			return Config{}, fmt.Errorf("-%s: %w", s.key, err)
+		}
+	}
+
+	if err := config.Validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid config: %w", err)
+	}
+	return config, nil
+}
+
+// readFile overlays the file at path on config. JSON is read as YAML, of
+// which it is a subset. Unknown keys are rejected so typos do not go
+// unnoticed.
+func readFile(path string, config *Config) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(raw))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// setting is one overridable field of a Config.
+type setting struct {
+	// key is the field's path in the config file.
+	key string
+	env string
+	set func(raw string) error
+}
+
+func (c *Config) settings() []setting {
+	return []setting{
+		{"server.port", "HTTP_PORT", intVar(&c.Server.Port)},
+		{"server.read_timeout", "HTTP_READ_TIMEOUT", durationVar(&c.Server.ReadTimeout)},
+		{"server.read_header_timeout", "HTTP_READ_HEADER_TIMEOUT", durationVar(&c.Server.ReadHeaderTimeout)},
+		{"server.write_timeout", "HTTP_WRITE_TIMEOUT", durationVar(&c.Server.WriteTimeout)},
+		{"server.idle_timeout", "HTTP_IDLE_TIMEOUT", durationVar(&c.Server.IdleTimeout)},
+		{"server.request_timeout", "HTTP_REQUEST_TIMEOUT", durationVar(&c.Server.RequestTimeout)},
+		{"server.drain_delay", "SHUTDOWN_DRAIN_DELAY", durationVar(&c.Server.DrainDelay)},
+		{"server.shutdown_timeout", "SHUTDOWN_TIMEOUT", durationVar(&c.Server.ShutdownTimeout)},
+
+		{"logging.level", "LOG_LEVEL", stringVar(&c.Logging.Level)},
+		{"logging.format", "LOG_FORMAT", stringVar(&c.Logging.Format)},
+		{"logging.sampling", "LOG_SAMPLING", boolVar(&c.Logging.Sampling)},
+		{"logging.output_paths", "LOG_OUTPUT_PATHS", listVar(&c.Logging.OutputPaths)},
+		{"logging.error_output_paths", "LOG_ERROR_OUTPUT_PATHS", listVar(&c.Logging.ErrorOutputPaths)},
+		{"logging.redact_fields", "LOG_REDACT_FIELDS", listVar(&c.Logging.RedactFields)},
+
+		{"auth.admin_api_key", "ADMIN_API_KEY", stringVar(&c.Auth.AdminAPIKey)},
+		{"auth.public_reads", "AUTH_PUBLIC_READS", boolVar(&c.Auth.PublicReads)},
+		{"auth.jwt.hs256_secret", "JWT_HS256_SECRET", stringVar(&c.Auth.JWT.HS256Secret)},
+		{"auth.jwt.rs256_public_key_file", "JWT_RS256_PUBLIC_KEY_FILE", stringVar(&c.Auth.JWT.RS256PublicKeyFile)},
+		{"auth.jwt.jwks_file", "JWT_JWKS_FILE", stringVar(&c.Auth.JWT.JWKSFile)},
+		{"auth.jwt.audience", "JWT_AUDIENCE", stringVar(&c.Auth.JWT.Audience)},
+		{"auth.jwt.issuer", "JWT_ISSUER", stringVar(&c.Auth.JWT.Issuer)},
+
+		{"client_ip.trusted_proxies", "TRUSTED_PROXIES", listVar(&c.ClientIP.TrustedProxies)},
+
+		{"rate_limit.policy_file", "RATE_LIMIT_POLICY_FILE", stringVar(&c.RateLimit.PolicyFile)},
+		{"rate_limit.idle_ttl", "RATE_LIMIT_IDLE_TTL", durationVar(&c.RateLimit.IdleTTL)},
+		{"rate_limit.redis_url", "RATE_LIMIT_REDIS_URL", stringVar(&c.RateLimit.RedisURL)},
+
+		{"idempotency.ttl", "IDEMPOTENCY_TTL", durationVar(&c.Idempotency.TTL)},
+
+		{"tracing.exporter", "OTEL_TRACES_EXPORTER", stringVar(&c.Tracing.Exporter)},
+		{"tracing.file", "OTEL_TRACES_FILE", stringVar(&c.Tracing.File)},
+
+		{"feature_flags.file", "FEATURE_FLAGS_FILE", stringVar(&c.FeatureFlags.File)},
+
+		{"reload.watch_interval", "CONFIG_WATCH_INTERVAL", durationVar(&c.Reload.WatchInterval)},
+	}
+}
+
+func stringVar(p *string) func(string) error {
+	return func(raw string) error {
+		*p = raw
+		return nil
+	}
+}
+
+func intVar(p *int) func(string) error {
+	return func(raw string) error {
+		v, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", raw)
+		}
+		*p = v
+		return nil
+	}
+}
+
+func boolVar(p *bool) func(string) error {
+	return func(raw string) error {
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", raw)
+		}
+		*p = v
+		return nil
+	}
+}
+
+func durationVar(p *time.Duration) func(string) error {
+	return func(raw string) error {
+		v, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration such as 30s", raw)
+		}
+		*p = v
+		return nil
+	}
+}
+
+// listVar parses a comma-separated list. An empty value clears the list.
+func listVar(p *[]string) func(string) error {
+	return func(raw string) error {
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		*p = items
+		return nil
+	}
+}
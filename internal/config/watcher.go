@@ -0,0 +1,330 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"ads_backend/internal/featureflag"
+	http_server "ads_backend/internal/http"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v3"
+)
+
+// reloadable are the settings a reload applies. Changing any other setting
+// takes a restart.
+var reloadable = map[string]bool{
+	"logging.level":          true,
+	"rate_limit.policy_file": true,
+	"reload.watch_interval":  true,
+}
+
+// ReloadConfig is the reload section of the app config.
+type ReloadConfig struct {
+	// WatchInterval is how often the config file and the files it names
+	// are checked for changes. Zero turns watching off; SIGHUP still
+	// reloads.
+	WatchInterval time.Duration `yaml:"watch_interval"`
+}
+
+func (c ReloadConfig) Validate() error {
+	if c.WatchInterval < 0 {
+		return fmt.Errorf("watch_interval must not be negative")
+	}
+	return nil
+}
+
+// Subscriber checks a reloaded config. It returns an error to reject next,
+// or a function that applies it, which runs only once every subscriber has
+// accepted. A nil function means there is nothing to apply.
+type Subscriber func(old, next Config) (apply func(), err error)
+
+type subscription struct {
+	name string
+	fn   Subscriber
+}
+
+// Watcher reloads the config on SIGHUP and when a watched file changes, and
+// hands it to its subscribers. A reload is all or nothing: if loading,
+// validation or any subscriber fails, nothing is applied and the current
+// config stays.
+type Watcher struct {
+	loader      *Loader
+	log         *zap.Logger
+	started     Config
+	current     atomic.Pointer[Config]
+	mu          sync.Mutex
+	subscribers []subscription
+}
+
+// NewWatcher watches from config, the config the app started with, while
+// the app runs.
+func NewWatcher(lc fx.Lifecycle, log *zap.Logger, loader *Loader, config Config) *Watcher {
+	w := &Watcher{loader: loader, log: log, started: config}
+	w.current.Store(&config)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			hangup := make(chan os.Signal, 1)
+			signal.Notify(hangup, syscall.SIGHUP)
+			fingerprints := w.fingerprints()
+			go func() {
+				defer close(done)
+				defer signal.Stop(hangup)
+				w.run(ctx, hangup, fingerprints)
+			}()
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			cancel()
+			<-done
+			return nil
+		},
+	})
+	return w
+}
+
+// Current is the config last applied.
+func (w *Watcher) Current() Config {
+	return *w.current.Load()
+}
+
+// Subscribe adds fn to the subscribers of every later reload. name
+// identifies it in the logs.
+func (w *Watcher) Subscribe(name string, fn Subscriber) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, subscription{name: name, fn: fn})
+}
+
+// Reload loads the config again and applies it. trigger says why, for the
+// logs.
+func (w *Watcher) Reload(trigger string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	log := w.log.With(zap.String("trigger", trigger))
+	old := w.Current()
+	next, err := w.loader.Load()
+	if err != nil {
+		log.Error("Config reload rejected, keeping the current config", zap.Error(err))
+		return err
+	}
+
+	var applies []func()
+	for _, s := range w.subscribers {
+		apply, err := s.fn(old, next)
+		if err != nil {
+			err = fmt.Errorf("%s: %w", s.name, err)
+			log.Error("Config reload rejected, keeping the current config", zap.Error(err))
+			return err
+		}
+		if apply != nil {
+			applies = append(applies, apply)
+		}
+	}
+	for _, apply := range applies {
+		apply()
+	}
+	w.current.Store(&next)
+
+	log.Info("Config reloaded",
+		zap.Strings("changed", changedSettings(old, next)),
+		zap.Int("applied", len(applies)),
+	)
+	var pending []string
+	for _, key := range changedSettings(w.started, next) {
+		if !reloadable[key] {
+			pending = append(pending, key)
+		}
+	}
+	if len(pending) > 0 {
+		log.Warn("Config changes need a restart to take effect", zap.Strings("settings", pending))
+	}
+	return nil
+}
+
+func (w *Watcher) run(ctx context.Context, hangup <-chan os.Signal, fingerprints map[string][sha256.Size]byte) {
+	interval := w.Current().Reload.WatchInterval
+	ticker := newTicker(interval)
+	defer func() { ticker.Stop() }()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			_ = w.Reload("SIGHUP")
+		case <-ticker.C:
+			if reflect.DeepEqual(w.fingerprints(), fingerprints) {
+				continue
+			}
+			_ = w.Reload("file changed")
+		}
+		// A rejected file is not retried until it changes again.
+		fingerprints = w.fingerprints()
+		if next := w.Current().Reload.WatchInterval; next != interval {
+			interval = next
+			ticker.Stop()
+			ticker = newTicker(interval)
+		}
+	}
+}
+
+// newTicker ticks every interval, or never when interval is zero.
+func newTicker(interval time.Duration) *time.Ticker {
+	if interval <= 0 {
+		ticker := time.NewTicker(time.Hour)
+		ticker.Stop()
+		return ticker
+	}
+	return time.NewTicker(interval)
+}
+
+// watchedFiles are the files a reload reads.
+func (w *Watcher) watchedFiles() []string {
+	current := w.Current()
+	files := []string{w.loader.File, current.RateLimit.PolicyFile, current.FeatureFlags.File}
+	var watched []string
+	for _, file := range files {
+		if file != "" {
+			watched = append(watched, file)
+		}
+	}
+	return watched
+}
+
+// fingerprints hashes the watched files. A file that cannot be read hashes
+// to nothing, so it counts as changed once it is back.
+func (w *Watcher) fingerprints() map[string][sha256.Size]byte {
+	sums := make(map[string][sha256.Size]byte)
+	for _, file := range w.watchedFiles() {
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		sums[file] = sha256.Sum256(raw)
+	}
+	return sums
+}
+
+// changedSettings lists the keys of the settings that differ between a and
+// b, sorted.
+func changedSettings(a, b Config) []string {
+	flatA, flatB := flatten(a), flatten(b)
+	var changed []string
+	for key, value := range flatB {
+		if flatA[key] != value {
+			changed = append(changed, key)
+		}
+	}
+	for key := range flatA {
+		if _, ok := flatB[key]; !ok {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// flatten renders c as dotted keys and YAML values, the way settings name
+// them.
+func flatten(c Config) map[string]string {
+	raw, _ := yaml.Marshal(c)
+	var tree map[string]any
+	_ = yaml.Unmarshal(raw, &tree)
+
+	flat := make(map[string]string)
+	var walk func(prefix string, node any)
+	walk = func(prefix string, node any) {
+		if m, ok := node.(map[string]any); ok {
+			for key, child := range m {
+				walk(prefix+"."+key, child)
+			}
+			return
+		}
+		value, _ := yaml.Marshal(node)
+		flat[prefix[1:]] = string(value)
+	}
+	walk("", tree)
+	return flat
+}
+
+// ReloadRateLimits reloads the rate limit policy file into rl. The file is
+// read on every reload, so editing it applies without touching the config.
+func ReloadRateLimits(w *Watcher, rl *http_server.RateLimiter, log *zap.Logger) {
+	w.Subscribe("rate_limit", func(_, next Config) (func(), error) {
+		policy, err := http_server.LoadRateLimitConfig(next.RateLimit.PolicyFile)
+		if err != nil {
+			return nil, err
+		}
+		if reflect.DeepEqual(policy, rl.Config()) {
+			return nil, nil
+		}
+		return func() {
+			// LoadRateLimitConfig has validated policy already.
+			_ = rl.SetConfig(policy)
+			log.Info("Rate limits updated", zap.Any("rate_limit", policy))
+		}, nil
+	})
+}
+
+// ReloadFeatureFlags reads the flags file again into flags, so flags edited
+// by hand apply without a restart. Moving to another file takes one. A flag
+// changed through the admin endpoint between reading the file and applying
+// it wins; it has been saved to the file, so the next check sees it.
+func ReloadFeatureFlags(w *Watcher, flags *featureflag.Flags, log *zap.Logger) {
+	w.Subscribe("feature_flags", func(_, next Config) (func(), error) {
+		loaded, err := flags.Read()
+		if err != nil {
+			return nil, err
+		}
+		current := flags.List()
+		if len(loaded) == 0 && len(current) == 0 || reflect.DeepEqual(loaded, current) {
+			return nil, nil
+		}
+		return func() {
+			if !flags.CompareAndReplace(current, loaded) {
+				log.Info("Feature flags changed during reload, keeping them")
+				return
+			}
+			log.Info("Feature flags updated", zap.Int("flags", len(loaded)))
+		}, nil
+	})
+}
+
+// ReloadLogLevel applies a changed logging.level. A level set through the
+// admin endpoint stays until the configured level itself changes.
+func ReloadLogLevel(w *Watcher, level zap.AtomicLevel, log *zap.Logger) {
+	w.Subscribe("logging", func(old, next Config) (func(), error) {
+		if old.Logging.Level == next.Logging.Level {
+			return nil, nil
+		}
+		parsed, err := zapcore.ParseLevel(next.Logging.Level)
+		if err != nil {
+			return nil, err
+		}
+		return func() {
+			previous := level.Level()
+			level.SetLevel(parsed)
+			log.Warn("Log level changed",
+				zap.Stringer("from", previous),
+				zap.Stringer("to", parsed),
+				zap.String("changed_by", "config"),
+			)
+		}, nil
+	})
+}
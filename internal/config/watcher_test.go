@@ -0,0 +1,145 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"ads_backend/internal/featureflag"
+	http_server "ads_backend/internal/http"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newTestWatcher(t *testing.T, file string) (*Watcher, *fxtest.Lifecycle, *observer.ObservedLogs) {
+	t.Helper()
+	loader, err := NewLoader("ads_backend", []string{"-config", file}, io.Discard)
+	require.NoError(t, err)
+	config, err := loader.Load()
+	require.NoError(t, err)
+
+	core, logs := observer.New(zap.InfoLevel)
+	lc := fxtest.NewLifecycle(t)
+	return NewWatcher(lc, zap.New(core), loader, config), lc, logs
+}
+
+func TestWatcher_ReloadAppliesOnlyWhenEverySubscriberAccepts(t *testing.T) {
+	path := writeFile(t, "config.yaml", "logging:\n  level: info\n")
+	w, _, logs := newTestWatcher(t, path)
+
+	var applied []string
+	w.Subscribe("first", func(old, next Config) (func(), error) {
+		return func() { applied = append(applied, next.Logging.Level) }, nil
+	})
+	veto := false
+	w.Subscribe("second", func(old, next Config) (func(), error) {
+		if veto {
+			return nil, errors.New("not now")
+		}
+		return nil, nil
+	})
+
+	require.NoError(t, os.WriteFile(path, []byte("logging:\n  level: debug\nserver:\n  port: 9000\n"), 0o600))
+	require.NoError(t, w.Reload("test"))
+	assert.Equal(t, []string{"debug"}, applied)
+	assert.Equal(t, "debug", w.Current().Logging.Level)
+
+	reloaded := logs.FilterMessage("Config reloaded").All()
+	require.Len(t, reloaded, 1)
+	assert.Equal(t, []any{"logging.level", "server.port"}, reloaded[0].ContextMap()["changed"])
+	pending := logs.FilterMessage("Config changes need a restart to take effect").All()
+	require.Len(t, pending, 1)
+	assert.Equal(t, []any{"server.port"}, pending[0].ContextMap()["settings"])
+
+	veto = true
+	require.NoError(t, os.WriteFile(path, []byte("logging:\n  level: error\n"), 0o600))
+	assert.EqualError(t, w.Reload("test"), "second: not now")
+	assert.Equal(t, []string{"debug"}, applied, "nothing is applied when a subscriber rejects")
+	assert.Equal(t, "debug", w.Current().Logging.Level)
+
+	require.NoError(t, os.WriteFile(path, []byte("logging:\n  level: loud\n"), 0o600))
+	assert.Error(t, w.Reload("test"))
+	assert.Equal(t, "debug", w.Current().Logging.Level)
+	assert.Equal(t, 2, logs.FilterMessage("Config reload rejected, keeping the current config").Len())
+}
+
+func TestWatcher_ReloadsOnFileChangeAndSIGHUP(t *testing.T) {
+	path := writeFile(t, "config.yaml", "reload:\n  watch_interval: 10ms\n")
+	w, lc, _ := newTestWatcher(t, path)
+	reloads := make(chan string, 10)
+	w.Subscribe("test", func(old, next Config) (func(), error) {
+		return func() { reloads <- next.Logging.Level }, nil
+	})
+	lc.RequireStart()
+	defer lc.RequireStop()
+
+	require.NoError(t, os.WriteFile(path, []byte("reload:\n  watch_interval: 10ms\nlogging:\n  level: warn\n"), 0o600))
+	select {
+	case level := <-reloads:
+		assert.Equal(t, "warn", level)
+	case <-time.After(2 * time.Second):
+		t.Fatal("file change was not picked up")
+	}
+
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+	select {
+	case level := <-reloads:
+		assert.Equal(t, "warn", level)
+	case <-time.After(2 * time.Second):
+		t.Fatal("SIGHUP did not reload")
+	}
+}
+
+func TestReloadRateLimitsAndLogLevel(t *testing.T) {
+	policy := writeFile(t, "policy.json", `{"default": {"rps": 1, "burst": 1}}`)
+	path := writeFile(t, "config.yaml", "rate_limit:\n  policy_file: "+policy+"\n")
+	w, _, _ := newTestWatcher(t, path)
+
+	rl := http_server.NewRateLimiter(1, 1)
+	level := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	ReloadRateLimits(w, rl, zap.NewNop())
+	ReloadLogLevel(w, level, zap.NewNop())
+
+	require.NoError(t, os.WriteFile(policy, []byte(`{"default": {"rps": 5, "burst": 50}}`), 0o600))
+	require.NoError(t, w.Reload("test"))
+	assert.Equal(t, 50, rl.Config().Default.Burst)
+	assert.Equal(t, zapcore.InfoLevel, level.Level(), "an unchanged level is left alone")
+
+	require.NoError(t, os.WriteFile(policy, []byte(`{"default": {"rps": 0, "burst": 50}}`), 0o600))
+	require.NoError(t, os.WriteFile(path, []byte("rate_limit:\n  policy_file: "+policy+"\nlogging:\n  level: debug\n"), 0o600))
+	assert.Error(t, w.Reload("test"))
+	assert.Equal(t, 50, rl.Config().Default.Burst)
+	assert.Equal(t, zapcore.InfoLevel, level.Level(), "the level waits for a valid policy")
+
+	require.NoError(t, os.WriteFile(policy, []byte(`{"default": {"rps": 5, "burst": 10}}`), 0o600))
+	require.NoError(t, w.Reload("test"))
+	assert.Equal(t, 10, rl.Config().Default.Burst)
+	assert.Equal(t, zapcore.DebugLevel, level.Level())
+}
+
+func TestReloadFeatureFlags(t *testing.T) {
+	flagsFile := writeFile(t, "flags.json", `{"serving.newest_first": {"enabled": false}}`)
+	path := writeFile(t, "config.yaml", "feature_flags:\n  file: "+flagsFile+"\n")
+	w, _, _ := newTestWatcher(t, path)
+
+	flags, err := featureflag.NewFromConfig(w.Current().FeatureFlags)
+	require.NoError(t, err)
+	ReloadFeatureFlags(w, flags, zap.NewNop())
+
+	require.NoError(t, os.WriteFile(flagsFile, []byte(`{"serving.newest_first": {"enabled": true}}`), 0o600))
+	require.NoError(t, w.Reload("test"))
+	assert.True(t, flags.Enabled(context.Background(), "serving.newest_first"))
+
+	require.NoError(t, os.WriteFile(flagsFile, []byte(`{"serving.newest_first": {"enabled": true, "percentage": 300}}`), 0o600))
+	assert.Error(t, w.Reload("test"))
+	assert.True(t, flags.Enabled(context.Background(), "serving.newest_first"))
+}
@@ -0,0 +1,18 @@
+package correlation
+
+import "context"
+
+type contextKey string
+
+const idKey contextKey = "correlation-id"
+
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, idKey, id)
+}
+
+func ID(ctx context.Context) string {
+	if id, ok := ctx.Value(idKey).(string); ok {
+		return id
+	}
+	return ""
+}
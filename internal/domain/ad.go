@@ -10,7 +10,7 @@ type Ad struct {
 	ImageUrl     string    `json:"image_url"`
 	Placement    Placement `json:"placement"`
 	Status       Status    `json:"status"`
-	CreatedAt    time.Time `json:"created_at", default:"now"`
+	CreatedAt    time.Time `json:"created_at"`
 	DeactivateAt time.Time `json:"deactivate_at"`
 	TTLMinutes   int       `json:"ttl_minutes"`
 }
@@ -0,0 +1,25 @@
+package domain
+
+import "time"
+
+type AuditAction string
+
+const (
+	AuditActionCreated     AuditAction = "created"
+	AuditActionUpdated     AuditAction = "updated"
+	AuditActionDeactivated AuditAction = "deactivated"
+	AuditActionRolledBack  AuditAction = "rolled_back"
+)
+
+// AuditRecord is one append-only entry in an ad's history. Before is nil for
+// creations.
+type AuditRecord struct {
+	ID            string      `json:"id"`
+	AdID          string      `json:"ad_id"`
+	Action        AuditAction `json:"action"`
+	Actor         string      `json:"actor"`
+	CorrelationID string      `json:"correlation_id,omitempty"`
+	Before        *Ad         `json:"before,omitempty"`
+	After         *Ad         `json:"after"`
+	Timestamp     time.Time   `json:"timestamp"`
+}
@@ -0,0 +1,21 @@
+package domain
+
+// CodeNotRecorded marks a result whose ad was written but whose audit record
+// or version was not. The write stands, so the item must not be retried.
+const CodeNotRecorded = "not_recorded"
+
+// BatchResult reports the outcome of one item of a bulk request. Index is the
+// item's position in the request so clients can match results to inputs.
+type BatchResult struct {
+	Index int    `json:"index"`
+	ID    string `json:"id,omitempty"`
+	Ad    *Ad    `json:"ad,omitempty"`
+	Error string `json:"error,omitempty"`
+	Code  string `json:"code,omitempty"`
+}
+
+// Failed reports whether the item was not written. An item with
+// CodeNotRecorded was written, so it has not failed despite its error.
+func (r BatchResult) Failed() bool {
+	return r.Error != "" && r.Code != CodeNotRecorded
+}
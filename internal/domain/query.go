@@ -0,0 +1,115 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type SortField string
+
+const (
+	SortByCreatedAt    SortField = "created_at"
+	SortByTitle        SortField = "title"
+	SortByDeactivateAt SortField = "deactivate_at"
+)
+
+type SortOrder string
+
+const (
+	SortAsc  SortOrder = "asc"
+	SortDesc SortOrder = "desc"
+)
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// AdQuery describes a filtered, sorted and paginated listing of ads. Zero
+// values mean "no filter" so repositories can push only the set fields down.
+type AdQuery struct {
+	Status        Status
+	Placement     Placement
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	TitleContains string
+	SortBy        SortField
+	Order         SortOrder
+	Limit         int
+	After         *Cursor
+}
+
+// WithDefaults fills in the sort and limit a caller left unset and clamps the
+// limit to MaxPageLimit.
+func (q AdQuery) WithDefaults() AdQuery {
+	if q.SortBy == "" {
+		q.SortBy = SortByCreatedAt
+	}
+	if q.Order == "" {
+		q.Order = SortDesc
+	}
+	if q.Limit <= 0 {
+		q.Limit = DefaultPageLimit
+	}
+	if q.Limit > MaxPageLimit {
+		q.Limit = MaxPageLimit
+	}
+	return q
+}
+
+type AdPage struct {
+	Ads        []Ad   `json:"data"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type AdSearchResult struct {
+	Ad    Ad      `json:"ad"`
+	Score float64 `json:"score"`
+}
+
+// Cursor is a keyset position: the sort value and ID of the last ad of the
+// previous page. Ads inserted later never shift it, unlike an offset.
+type Cursor struct {
+	SortBy SortField `json:"s"`
+	Order  SortOrder `json:"o"`
+	Value  string    `json:"v"`
+	ID     string    `json:"id"`
+}
+
+func NewCursor(ad Ad, sortBy SortField, order SortOrder) Cursor {
+	return Cursor{SortBy: sortBy, Order: order, Value: SortValue(ad, sortBy), ID: ad.ID}
+}
+
+func (c Cursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func DecodeCursor(s string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("invalid cursor")
+	}
+	var c Cursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID == "" {
+		return Cursor{}, fmt.Errorf("invalid cursor")
+	}
+	return c, nil
+}
+
+// SortValue renders the field an ad is sorted by so that string comparison
+// matches the field's natural order.
+func SortValue(ad Ad, sortBy SortField) string {
+	switch sortBy {
+	case SortByTitle:
+		return ad.Title
+	case SortByDeactivateAt:
+		return ad.DeactivateAt.UTC().Format(sortableTimeLayout)
+	default:
+		return ad.CreatedAt.UTC().Format(sortableTimeLayout)
+	}
+}
+
+const sortableTimeLayout = "2006-01-02T15:04:05.000000000Z"
@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"time"
+)
+
+// AdVersion is an immutable snapshot of an ad as it was after one change.
+// Versions are numbered from 1 per ad.
+type AdVersion struct {
+	AdID      string      `json:"ad_id"`
+	Version   int         `json:"version"`
+	Action    AuditAction `json:"action"`
+	Actor     string      `json:"actor"`
+	Ad        Ad          `json:"ad"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// AdUpdate is a partial edit of an ad; nil fields are left unchanged.
+type AdUpdate struct {
+	Title      *string
+	ImageUrl   *string
+	Placement  *Placement
+	TTLMinutes *int
+}
+
+// Apply returns ad with the update's fields set. A new TTL counts from the
+// ad's creation, the same as the TTL it was created with.
+func (u AdUpdate) Apply(ad Ad) Ad {
+	if u.Title != nil {
+		ad.Title = *u.Title
+	}
+	if u.ImageUrl != nil {
+		ad.ImageUrl = *u.ImageUrl
+	}
+	if u.Placement != nil {
+		ad.Placement = *u.Placement
+	}
+	if u.TTLMinutes != nil {
+		ad.TTLMinutes = *u.TTLMinutes
+		ad.DeactivateAt = time.Time{}
+		if ad.TTLMinutes > 0 {
+			ad.DeactivateAt = ad.CreatedAt.Add(time.Duration(ad.TTLMinutes) * time.Minute)
+		}
+	}
+	return ad
+}
+
+type FieldChange struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+// DiffAds lists the fields that differ between two snapshots of an ad, named
+// as in the ad's JSON.
+func DiffAds(from, to Ad) []FieldChange {
+	changes := make([]FieldChange, 0)
+	add := func(field string, a, b any) {
+		if a != b {
+			changes = append(changes, FieldChange{Field: field, From: a, To: b})
+		}
+	}
+
+	add("title", from.Title, to.Title)
+	add("image_url", from.ImageUrl, to.ImageUrl)
+	add("placement", from.Placement, to.Placement)
+	add("status", from.Status, to.Status)
+	add("ttl_minutes", from.TTLMinutes, to.TTLMinutes)
+	if !from.CreatedAt.Equal(to.CreatedAt) {
+		add("created_at", from.CreatedAt, to.CreatedAt)
+	}
+	if !from.DeactivateAt.Equal(to.DeactivateAt) {
+		add("deactivate_at", from.DeactivateAt, to.DeactivateAt)
+	}
+	return changes
+}
@@ -0,0 +1,123 @@
+package featureflag
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func percentage(p float64) *float64 {
+	return &p
+}
+
+func TestFlag_EnabledFor(t *testing.T) {
+	assert.False(t, Flag{Name: "off"}.EnabledFor("rider-1"))
+	assert.True(t, Flag{Name: "on", Enabled: true}.EnabledFor(""))
+
+	rollout := Flag{Name: "serving.newest_first", Enabled: true, Percentage: percentage(25)}
+	assert.False(t, rollout.EnabledFor(""), "users without an ID are left out of rollouts")
+
+	enabled := map[string]bool{}
+	for i := 0; i < 10000; i++ {
+		user := fmt.Sprintf("rider-%d", i)
+		if rollout.EnabledFor(user) {
+			enabled[user] = true
+		}
+		assert.Equal(t, enabled[user], rollout.EnabledFor(user), "bucketing is stable")
+	}
+	assert.InDelta(t, 2500, len(enabled), 200)
+
+	rollout.Percentage = percentage(50)
+	for user := range enabled {
+		assert.True(t, rollout.EnabledFor(user), "raising the percentage keeps users in")
+	}
+	rollout.Percentage = percentage(0)
+	assert.False(t, rollout.EnabledFor("rider-1"))
+	rollout.Percentage = percentage(100)
+	assert.True(t, rollout.EnabledFor("rider-1"))
+}
+
+func TestFlag_Validate(t *testing.T) {
+	assert.NoError(t, Flag{Name: "serving.newest_first", Percentage: percentage(12.5)}.Validate())
+	assert.Error(t, Flag{Name: "Serving New"}.Validate())
+	assert.Error(t, Flag{Name: ""}.Validate())
+	assert.Error(t, Flag{Name: "rollout", Percentage: percentage(101)}.Validate())
+	assert.Error(t, Flag{Name: "rollout", Percentage: percentage(-1)}.Validate())
+}
+
+func TestFlags_EvaluatesForTheUserOnTheContext(t *testing.T) {
+	flags := NewStatic(
+		Flag{Name: "everyone", Enabled: true},
+		Flag{Name: "nobody", Enabled: true, Percentage: percentage(0)},
+	)
+	ctx := WithUser(context.Background(), "rider-1")
+
+	assert.True(t, flags.Enabled(ctx, "everyone"))
+	assert.False(t, flags.Enabled(ctx, "nobody"))
+	assert.False(t, flags.Enabled(ctx, "unknown"), "unknown flags are off")
+	assert.Equal(t, "rider-1", UserFromContext(ctx))
+}
+
+func TestFlags_SavesChangesToTheFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flags.json")
+	flags, err := NewFromConfig(Config{File: path})
+	require.NoError(t, err)
+	assert.Empty(t, flags.List(), "a missing file holds no flags")
+
+	previous, err := flags.Set(Flag{Name: "serving.newest_first", Enabled: true, Percentage: percentage(10)})
+	require.NoError(t, err)
+	assert.Nil(t, previous)
+	previous, err = flags.Set(Flag{Name: "serving.newest_first", Enabled: true, Percentage: percentage(20)})
+	require.NoError(t, err)
+	assert.Equal(t, 10.0, *previous.Percentage)
+	_, err = flags.Set(Flag{Name: "checkout.v2", Description: "New checkout"})
+	require.NoError(t, err)
+	require.NoError(t, flags.Delete("checkout.v2"))
+	assert.ErrorIs(t, flags.Delete("checkout.v2"), ErrFlagNotFound)
+
+	reopened, err := NewFromConfig(Config{File: path})
+	require.NoError(t, err)
+	assert.Equal(t, flags.List(), reopened.List())
+	flag, err := reopened.Get("serving.newest_first")
+	require.NoError(t, err)
+	assert.Equal(t, 20.0, *flag.Percentage)
+
+	_, err = flags.Set(Flag{Name: "Bad Name"})
+	assert.Error(t, err)
+}
+
+func TestFlags_ReadRejectsInvalidFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flags.json")
+	invalid := map[string]string{
+		"bad percentage": `{"rollout": {"enabled": true, "percentage": 150}}`,
+		"bad name":       `{"Roll Out": {"enabled": true}}`,
+		"unknown field":  `{"rollout": {"enabled": true, "percent": 10}}`,
+		"not json":       `rollout: true`,
+	}
+	for name, body := range invalid {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, os.WriteFile(path, []byte(body), 0o600))
+			_, err := NewFromConfig(Config{File: path})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestFlags_CompareAndReplaceKeepsNewerChanges(t *testing.T) {
+	flags := NewStatic(Flag{Name: "checkout.v2"})
+	snapshot := flags.List()
+	fromFile := []Flag{{Name: "checkout.v2", Enabled: true}}
+
+	_, err := flags.Set(Flag{Name: "checkout.v2", Percentage: percentage(10), Enabled: true})
+	require.NoError(t, err)
+	assert.False(t, flags.CompareAndReplace(snapshot, fromFile), "a flag set since the snapshot wins")
+	assert.Equal(t, percentage(10), flags.List()[0].Percentage)
+
+	assert.True(t, flags.CompareAndReplace(flags.List(), fromFile))
+	assert.Equal(t, fromFile, flags.List())
+}
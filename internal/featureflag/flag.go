@@ -0,0 +1,76 @@
+package featureflag
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+)
+
+// buckets is the resolution of a percentage rollout: 0.01%.
+const buckets = 10000
+
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,63}$`)
+
+// Flag is on or off for everyone, or, when Percentage is set, on for that
+// share of users.
+type Flag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Enabled     bool   `json:"enabled"`
+	// Percentage limits an enabled flag to that share of users, 0 to 100.
+	// Users without an ID never get a percentage flag.
+	Percentage *float64 `json:"percentage,omitempty"`
+}
+
+func (f Flag) Validate() error {
+	if !namePattern.MatchString(f.Name) {
+		return fmt.Errorf("flag name %q must be lowercase letters, digits, '.', '_' or '-'", f.Name)
+	}
+	if f.Percentage != nil && (*f.Percentage < 0 || *f.Percentage > 100) {
+		return fmt.Errorf("flag %q: percentage must be between 0 and 100", f.Name)
+	}
+	return nil
+}
+
+// EnabledFor reports whether the flag is on for userID. A user lands in the
+// same bucket of a flag on every request, and raising the percentage only
+// adds users, so nobody flips back and forth during a rollout. Buckets are
+// hashed with the flag name, so separate flags pick separate users.
+func (f Flag) EnabledFor(userID string) bool {
+	if !f.Enabled {
+		return false
+	}
+	if f.Percentage == nil {
+		return true
+	}
+	if userID == "" {
+		return false
+	}
+	return float64(bucket(f.Name, userID)) < *f.Percentage*buckets/100
+}
+
+func bucket(name, userID string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	h.Write([]byte{0})
+	h.Write([]byte(userID))
+	return h.Sum32() % buckets
+}
+
+type contextKey string
+
+const userKey contextKey = "feature-flag-user"
+
+// WithUser sets the user flags are evaluated for during a request.
+func WithUser(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userKey, userID)
+}
+
+// UserFromContext returns the user set by WithUser, or "".
+func UserFromContext(ctx context.Context) string {
+	if userID, ok := ctx.Value(userKey).(string); ok {
+		return userID
+	}
+	return ""
+}
@@ -0,0 +1,185 @@
+package featureflag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+var ErrFlagNotFound = errors.New("feature flag not found")
+
+// Config is the feature_flags section of the app config.
+type Config struct {
+	// File is the JSON file flags are loaded from and saved to. Without
+	// one, flags live in memory and start empty.
+	File string `yaml:"file"`
+}
+
+// Flags evaluates feature flags per request. Unknown flags are off, so code
+// can branch on a flag before it is created.
+type Flags struct {
+	provider Provider
+	flags    atomic.Pointer[map[string]Flag]
+	// mu serializes writes; reads go through flags.
+	mu sync.Mutex
+}
+
+// New loads the flags from provider.
+func New(provider Provider) (*Flags, error) {
+	f := &Flags{provider: provider}
+	flags, err := f.Read()
+	if err != nil {
+		return nil, err
+	}
+	f.Replace(flags)
+	return f, nil
+}
+
+// NewFromConfig reads the flags from config.File, or keeps them in memory
+// when it is empty.
+func NewFromConfig(config Config) (*Flags, error) {
+	if config.File == "" {
+		return New(NewMemoryProvider())
+	}
+	return New(NewFileProvider(config.File))
+}
+
+// NewStatic holds flags in memory, for tests and tools.
+func NewStatic(flags ...Flag) *Flags {
+	f, _ := New(NewMemoryProvider(flags...))
+	return f
+}
+
+// Enabled reports whether the flag called name is on for the user on ctx.
+func (f *Flags) Enabled(ctx context.Context, name string) bool {
+	flag, ok := (*f.flags.Load())[name]
+	return ok && flag.EnabledFor(UserFromContext(ctx))
+}
+
+// List returns every flag, sorted by name.
+func (f *Flags) List() []Flag {
+	current := *f.flags.Load()
+	flags := make([]Flag, 0, len(current))
+	for _, flag := range current {
+		flags = append(flags, flag)
+	}
+	sortFlags(flags)
+	return flags
+}
+
+func (f *Flags) Get(name string) (Flag, error) {
+	flag, ok := (*f.flags.Load())[name]
+	if !ok {
+		return Flag{}, ErrFlagNotFound
+	}
+	return flag, nil
+}
+
+// Set creates or replaces a flag and saves every flag to the provider. It
+// returns the flag's previous state, if any.
+func (f *Flags) Set(flag Flag) (*Flag, error) {
+	if err := flag.Validate(); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	current := *f.flags.Load()
+	next := make(map[string]Flag, len(current)+1)
+	for name, existing := range current {
+		next[name] = existing
+	}
+	next[flag.Name] = flag
+	if err := f.save(next); err != nil {
+		return nil, err
+	}
+
+	if previous, ok := current[flag.Name]; ok {
+		return &previous, nil
+	}
+	return nil, nil
+}
+
+func (f *Flags) Delete(name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	current := *f.flags.Load()
+	if _, ok := current[name]; !ok {
+		return ErrFlagNotFound
+	}
+	next := make(map[string]Flag, len(current))
+	for existing, flag := range current {
+		if existing != name {
+			next[existing] = flag
+		}
+	}
+	return f.save(next)
+}
+
+func (f *Flags) save(next map[string]Flag) error {
+	flags := make([]Flag, 0, len(next))
+	for _, flag := range next {
+		flags = append(flags, flag)
+	}
+	sortFlags(flags)
+	if err := f.provider.Save(flags); err != nil {
+		return err
+	}
+	f.flags.Store(&next)
+	return nil
+}
+
+// Read loads and validates the flags from the provider without applying
+// them, so a reload can be rejected as a whole.
+func (f *Flags) Read() ([]Flag, error) {
+	flags, err := f.provider.Load()
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(flags))
+	for _, flag := range flags {
+		if err := flag.Validate(); err != nil {
+			return nil, err
+		}
+		if seen[flag.Name] {
+			return nil, fmt.Errorf("feature flag %q is defined twice", flag.Name)
+		}
+		seen[flag.Name] = true
+	}
+	return flags, nil
+}
+
+// Replace swaps in flags, as returned by Read.
+func (f *Flags) Replace(flags []Flag) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.replace(flags)
+}
+
+// CompareAndReplace swaps in flags only if the current flags are still old,
+// as returned by List, and reports whether it did. A reload uses it so a
+// flag set through Set after the reload read the file is not overwritten.
+func (f *Flags) CompareAndReplace(old, flags []Flag) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	current := f.List()
+	if len(current) != 0 || len(old) != 0 {
+		if !reflect.DeepEqual(current, old) {
+			return false
+		}
+	}
+	f.replace(flags)
+	return true
+}
+
+func (f *Flags) replace(flags []Flag) {
+	next := make(map[string]Flag, len(flags))
+	for _, flag := range flags {
+		next[flag.Name] = flag
+	}
+	f.flags.Store(&next)
+}
@@ -0,0 +1,118 @@
+package featureflag
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Provider stores the flags.
+type Provider interface {
+	Load() ([]Flag, error)
+	Save(flags []Flag) error
+}
+
+// fileFlag is how a flag is written in the file, under its name.
+type fileFlag struct {
+	Description string   `json:"description,omitempty"`
+	Enabled     bool     `json:"enabled"`
+	Percentage  *float64 `json:"percentage,omitempty"`
+}
+
+// FileProvider keeps the flags in a JSON object keyed by flag name, e.g.
+//
+//	{"serving.newest_first": {"enabled": true, "percentage": 10}}
+type FileProvider struct {
+	path string
+}
+
+func NewFileProvider(path string) *FileProvider {
+	return &FileProvider{path: path}
+}
+
+// Load reads the file. A missing file holds no flags.
+func (p *FileProvider) Load() ([]Flag, error) {
+	raw, err := os.ReadFile(p.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading feature flags: %w", err)
+	}
+
+	var byName map[string]fileFlag
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&byName); err != nil {
+		return nil, fmt.Errorf("parsing feature flags: %w", err)
+	}
+	flags := make([]Flag, 0, len(byName))
+	for name, f := range byName {
+		flags = append(flags, Flag{Name: name, Description: f.Description, Enabled: f.Enabled, Percentage: f.Percentage})
+	}
+	sortFlags(flags)
+	return flags, nil
+}
+
+// Save replaces the file through a rename, so a reader never sees it half
+// written.
+func (p *FileProvider) Save(flags []Flag) error {
+	byName := make(map[string]fileFlag, len(flags))
+	for _, f := range flags {
+		byName[f.Name] = fileFlag{Description: f.Description, Enabled: f.Enabled, Percentage: f.Percentage}
+	}
+	raw, err := json.MarshalIndent(byName, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p.path), filepath.Base(p.path)+".*")
+	if err != nil {
+		return fmt.Errorf("saving feature flags: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(raw, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("saving feature flags: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("saving feature flags: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), p.path); err != nil {
+		return fmt.Errorf("saving feature flags: %w", err)
+	}
+	return nil
+}
+
+// MemoryProvider keeps the flags in process, for when no file is
+// configured. Changes are lost on restart.
+type MemoryProvider struct {
+	mu    sync.Mutex
+	flags []Flag
+}
+
+func NewMemoryProvider(flags ...Flag) *MemoryProvider {
+	return &MemoryProvider{flags: flags}
+}
+
+func (p *MemoryProvider) Load() ([]Flag, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Flag(nil), p.flags...), nil
+}
+
+func (p *MemoryProvider) Save(flags []Flag) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.flags = append([]Flag(nil), flags...)
+	return nil
+}
+
+func sortFlags(flags []Flag) {
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Name < flags[j].Name })
+}
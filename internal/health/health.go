@@ -0,0 +1,138 @@
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/fx"
+)
+
+// checkTimeout bounds each check, so one hung dependency cannot hold the
+// probe past the orchestrator's own timeout.
+const checkTimeout = 2 * time.Second
+
+// Checker reports whether one dependency the app needs to serve traffic is
+// usable.
+type Checker interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+type checkerFunc struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+func (c checkerFunc) Name() string {
+	return c.name
+}
+
+func (c checkerFunc) Check(ctx context.Context) error {
+	return c.check(ctx)
+}
+
+func NewChecker(name string, check func(ctx context.Context) error) Checker {
+	return checkerFunc{name: name, check: check}
+}
+
+// AsChecker registers a constructor's Checker with the readiness probe.
+func AsChecker(f any) any {
+	return fx.Annotate(
+		f,
+		fx.ResultTags(`group:"health_checkers"`),
+	)
+}
+
+type Status string
+
+const (
+	StatusReady    Status = "ready"
+	StatusNotReady Status = "not_ready"
+	StatusDraining Status = "draining"
+)
+
+type CheckResult struct {
+	Name  string `json:"name"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status Status        `json:"status"`
+	Checks []CheckResult `json:"checks"`
+}
+
+func (r Report) Ready() bool {
+	return r.Status == StatusReady
+}
+
+// Health aggregates the registered checkers into a readiness report. Once
+// Drain is called the app reports itself draining, and not ready, for the
+// rest of its life.
+type Health struct {
+	checkers []Checker
+	timeout  time.Duration
+	draining atomic.Bool
+}
+
+func NewHealth(checkers []Checker) *Health {
+	sorted := append([]Checker(nil), checkers...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name() < sorted[j].Name() })
+	return &Health{checkers: sorted, timeout: checkTimeout}
+}
+
+// Drain marks the app as shutting down, so load balancers stop sending it
+// new requests while the ones in flight finish.
+func (h *Health) Drain() {
+	h.draining.Store(true)
+}
+
+func (h *Health) Draining() bool {
+	return h.draining.Load()
+}
+
+// Ready runs every checker concurrently. A checker still running after the
+// timeout fails, whether or not it honours its ctx.
+func (h *Health) Ready(ctx context.Context) Report {
+	results := make([]CheckResult, len(h.checkers))
+	var wg sync.WaitGroup
+	for i, checker := range h.checkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = CheckResult{Name: checker.Name(), OK: true}
+			if err := check(ctx, checker, h.timeout); err != nil {
+				results[i] = CheckResult{Name: checker.Name(), Error: err.Error()}
+			}
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusReady, Checks: results}
+	for _, result := range results {
+		if !result.OK {
+			report.Status = StatusNotReady
+		}
+	}
+	if h.Draining() {
+		report.Status = StatusDraining
+	}
+	return report
+}
+
+func check(ctx context.Context, checker Checker, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- checker.Check(ctx) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,61 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func passing(name string) Checker {
+	return NewChecker(name, func(context.Context) error { return nil })
+}
+
+func TestHealth_ReadyWhenAllChecksPass(t *testing.T) {
+	h := NewHealth([]Checker{passing("repository"), passing("config")})
+
+	report := h.Ready(context.Background())
+
+	assert.True(t, report.Ready())
+	assert.Equal(t, []CheckResult{{Name: "config", OK: true}, {Name: "repository", OK: true}}, report.Checks)
+}
+
+func TestHealth_NotReadyWhenACheckFails(t *testing.T) {
+	h := NewHealth([]Checker{
+		passing("config"),
+		NewChecker("redis", func(context.Context) error { return errors.New("connection refused") }),
+	})
+
+	report := h.Ready(context.Background())
+
+	assert.Equal(t, StatusNotReady, report.Status)
+	assert.Equal(t, CheckResult{Name: "redis", Error: "connection refused"}, report.Checks[1])
+}
+
+func TestHealth_HungCheckTimesOut(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	h := NewHealth([]Checker{NewChecker("stuck", func(context.Context) error {
+		<-block
+		return nil
+	})})
+	h.timeout = 10 * time.Millisecond
+
+	report := h.Ready(context.Background())
+
+	assert.Equal(t, StatusNotReady, report.Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks[0].Error)
+}
+
+func TestHealth_DrainingIsNeverReady(t *testing.T) {
+	h := NewHealth([]Checker{passing("config")})
+	h.Drain()
+
+	report := h.Ready(context.Background())
+
+	assert.False(t, report.Ready())
+	assert.Equal(t, StatusDraining, report.Status)
+	assert.True(t, report.Checks[0].OK, "checks still run for the report")
+}
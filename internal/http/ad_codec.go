@@ -0,0 +1,228 @@
+package http_server
+
+import (
+	"ads_backend/internal/domain"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	formatCSV    = "csv"
+	formatNDJSON = "ndjson"
+)
+
+var exportColumns = []string{"id", "title", "image_url", "placement", "status", "created_at", "deactivate_at", "ttl_minutes"}
+
+func contentTypeForFormat(format string) string {
+	if format == formatCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
+
+// formatFromContentType maps an upload's Content-Type to an import format,
+// returning "" when it names neither.
+func formatFromContentType(contentType string) string {
+	switch {
+	case strings.HasPrefix(contentType, "text/csv"):
+		return formatCSV
+	case strings.HasPrefix(contentType, "application/x-ndjson"), strings.HasPrefix(contentType, "application/ndjson"):
+		return formatNDJSON
+	}
+	return ""
+}
+
+type adEncoder interface {
+	Encode(ad domain.Ad) error
+	Flush() error
+}
+
+func newAdEncoder(w io.Writer, format string) (adEncoder, error) {
+	if format == formatCSV {
+		cw := csv.NewWriter(w)
+		if err := cw.Write(exportColumns); err != nil {
+			return nil, err
+		}
+		return &csvAdEncoder{w: cw}, nil
+	}
+	return &ndjsonAdEncoder{enc: json.NewEncoder(w)}, nil
+}
+
+type csvAdEncoder struct {
+	w *csv.Writer
+}
+
+func (e *csvAdEncoder) Encode(ad domain.Ad) error {
+	deactivateAt := ""
+	if !ad.DeactivateAt.IsZero() {
+		deactivateAt = ad.DeactivateAt.Format(time.RFC3339)
+	}
+	return e.w.Write([]string{
+		ad.ID,
+		escapeCSVCell(ad.Title),
+		escapeCSVCell(ad.ImageUrl),
+		string(ad.Placement),
+		string(ad.Status),
+		ad.CreatedAt.Format(time.RFC3339),
+		deactivateAt,
+		strconv.Itoa(ad.TTLMinutes),
+	})
+}
+
+// formulaPrefixes are the first characters that make a spreadsheet read a
+// cell as a formula.
+const formulaPrefixes = "=+-@\t\r"
+
+// escapeCSVCell prefixes a cell that a spreadsheet would read as a formula
+// with a quote, so opening an export never runs what a client typed as a
+// title. decodeCSVImport strips the quote again.
+func escapeCSVCell(cell string) string {
+	if cell != "" && strings.ContainsRune(formulaPrefixes, rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+// unescapeCSVCell undoes escapeCSVCell.
+func unescapeCSVCell(cell string) string {
+	if len(cell) > 1 && cell[0] == '\'' && strings.ContainsRune(formulaPrefixes, rune(cell[1])) {
+		return cell[1:]
+	}
+	return cell
+}
+
+func (e *csvAdEncoder) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type ndjsonAdEncoder struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonAdEncoder) Encode(ad domain.Ad) error {
+	return e.enc.Encode(ad)
+}
+
+func (e *ndjsonAdEncoder) Flush() error {
+	return nil
+}
+
+// decodeImport parses r row by row and calls fn with the 1-based row number
+// as a spreadsheet shows it (the CSV header is row 1) and either the parsed
+// request or the reason the row could not be parsed. A non-nil error from
+// decodeImport means the input as a whole is unreadable.
+func decodeImport(r io.Reader, format string, fn func(row int, req createAdRequest, err error)) error {
+	if format == formatCSV {
+		return decodeCSVImport(r, fn)
+	}
+	return decodeNDJSONImport(r, fn)
+}
+
+func decodeCSVImport(r io.Reader, fn func(row int, req createAdRequest, err error)) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return fmt.Errorf("csv is empty")
+	}
+	if err != nil {
+		return err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		// Accept both the export's snake_case and the API's camelCase names.
+		name = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(name), "_", ""))
+		columns[name] = i
+	}
+	for _, required := range []string{"title", "imageurl", "placement"} {
+		if _, ok := columns[required]; !ok {
+			return fmt.Errorf("csv header is missing column %q", required)
+		}
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	for row := 2; ; row++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			fn(row, createAdRequest{}, parseErr.Err)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		req := createAdRequest{
+			Title:     unescapeCSVCell(field(record, "title")),
+			ImageURL:  unescapeCSVCell(field(record, "imageurl")),
+			Placement: domain.Placement(field(record, "placement")),
+		}
+		if ttl := field(record, "ttlminutes"); ttl != "" {
+			minutes, err := strconv.Atoi(ttl)
+			if err != nil {
+				fn(row, createAdRequest{}, fmt.Errorf("ttl_minutes must be an integer"))
+				continue
+			}
+			req.TTLMinutes = &minutes
+		}
+		fn(row, req, nil)
+	}
+}
+
+// ndjsonImportRow accepts both the export's snake_case names and the API's
+// camelCase ones, like the CSV header does.
+type ndjsonImportRow struct {
+	createAdRequest
+	ImageURLSnake   string `json:"image_url"`
+	TTLMinutesSnake *int   `json:"ttl_minutes"`
+}
+
+func (row ndjsonImportRow) request() createAdRequest {
+	req := row.createAdRequest
+	if req.ImageURL == "" {
+		req.ImageURL = row.ImageURLSnake
+	}
+	if req.TTLMinutes == nil {
+		req.TTLMinutes = row.TTLMinutesSnake
+	}
+	return req
+}
+
+func decodeNDJSONImport(r io.Reader, fn func(row int, req createAdRequest, err error)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for row := 1; scanner.Scan(); row++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var decoded ndjsonImportRow
+		if err := json.Unmarshal([]byte(line), &decoded); err != nil {
+			fn(row, createAdRequest{}, err)
+			continue
+		}
+		fn(row, decoded.request(), nil)
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,145 @@
+package http_server
+
+import (
+	"ads_backend/internal/auth"
+	"ads_backend/internal/logging"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+type issueAPIKeyRequest struct {
+	Name   string    `json:"name"`
+	Role   auth.Role `json:"role"`
+	Tenant string    `json:"tenant"`
+}
+
+type issuedAPIKey struct {
+	auth.APIKey
+	Key string `json:"key"`
+}
+
+type APIKeyHandler struct {
+	log  *zap.Logger
+	keys *auth.KeyService
+}
+
+// NewAPIKeyRoutes exposes the admin endpoints that issue, list and revoke
+// API keys.
+func NewAPIKeyRoutes(log *zap.Logger, keys *auth.KeyService) []Route {
+	h := &APIKeyHandler{log: log, keys: keys}
+	return []Route{
+		newRoute("POST /admin/apikeys", auth.PermAdmin, h.issue),
+		newRoute("GET /admin/apikeys", auth.PermAdmin, h.list),
+		newRoute("POST /admin/apikeys/{id}/revoke", auth.PermAdmin, h.revoke),
+	}
+}
+
+func (h *APIKeyHandler) issue(w http.ResponseWriter, r *http.Request) {
+	var req issueAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Role == "" {
+		req.Role = auth.RoleEditor
+	}
+
+	// Admins bound to a tenant only issue keys for it, so they cannot
+	// reach other tenants through the keys they hand out.
+	issuer, _ := auth.PrincipalFromContext(r.Context())
+	if issuer.Tenant != "" {
+		if req.Tenant == "" {
+			req.Tenant = issuer.Tenant
+		}
+		if req.Tenant != issuer.Tenant {
+			writeError(w, http.StatusForbidden, fmt.Sprintf("credentials are not valid for tenant %q", req.Tenant))
+			return
+		}
+	}
+
+	plaintext, key, err := h.keys.Issue(req.Name, req.Role, req.Tenant)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	logging.For(r.Context(), h.log).Info("API key issued",
+		zap.String("key_id", key.ID),
+		zap.String("role", string(key.Role)),
+		zap.String("tenant", key.Tenant),
+		zap.String("issued_by", issuer.Subject),
+	)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(issuedAPIKey{APIKey: key, Key: plaintext})
+}
+
+func (h *APIKeyHandler) list(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.keys.List()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	lister, _ := auth.PrincipalFromContext(r.Context())
+	if lister.Tenant != "" {
+		visible := make([]auth.APIKey, 0, len(keys))
+		for _, key := range keys {
+			if key.Tenant == lister.Tenant {
+				visible = append(visible, key)
+			}
+		}
+		keys = visible
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(keys)
+}
+
+func (h *APIKeyHandler) revoke(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	// A tenant-bound admin sees another tenant's keys as missing, the same
+	// as they are missing from its list.
+	revoker, _ := auth.PrincipalFromContext(r.Context())
+	if revoker.Tenant != "" {
+		key, err := h.keys.Get(id)
+		if err == nil && key.Tenant != revoker.Tenant {
+			err = auth.ErrAPIKeyNotFound
+		}
+		if errors.Is(err, auth.ErrAPIKeyNotFound) {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	key, err := h.keys.Revoke(id)
+	if errors.Is(err, auth.ErrAPIKeyNotFound) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	logging.For(r.Context(), h.log).Info("API key revoked",
+		zap.String("key_id", key.ID),
+		zap.String("revoked_by", revoker.Subject),
+		zap.Time("revoked_at", *key.RevokedAt),
+	)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(key)
+}
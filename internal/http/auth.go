@@ -0,0 +1,172 @@
+package http_server
+
+import (
+	"ads_backend/internal/auth"
+	"ads_backend/internal/tenant"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+const (
+	apiKeyHeader = "X-API-Key"
+	tenantHeader = "X-Tenant-ID"
+)
+
+// Authenticator resolves an API key or a JWT bearer token into an
+// auth.Principal on the request context, and authorizes each route against
+// the permissions the principal's roles grant. Anonymous callers may use
+// the serving endpoints unless public reads are turned off.
+//
+// It also puts the request's tenant on the context; see resolveTenant.
+type Authenticator struct {
+	log         *zap.Logger
+	keys        *auth.KeyService
+	tokens      *auth.TokenVerifier
+	publicReads bool
+}
+
+func NewAuthenticator(log *zap.Logger, keys *auth.KeyService, tokens *auth.TokenVerifier) *Authenticator {
+	return NewAuthenticatorFromConfig(log, keys, tokens, auth.DefaultConfig())
+}
+
+func NewAuthenticatorFromConfig(log *zap.Logger, keys *auth.KeyService, tokens *auth.TokenVerifier, config auth.Config) *Authenticator {
+	return &Authenticator{
+		log:         log,
+		keys:        keys,
+		tokens:      tokens,
+		publicReads: config.PublicReads,
+	}
+}
+
+// identity is what Identify made of a request's credentials.
+type identity struct {
+	principal auth.Principal
+	found     bool
+	err       error
+}
+
+type identityKey struct{}
+
+// Identify resolves the request's credentials and puts a valid principal on
+// the context, without rejecting anything. It runs in front of the rate
+// limiter, so clients are limited by who they are and failed logins are
+// limited like any other request; Middleware rejects them afterwards.
+func (a *Authenticator) Identify(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var id identity
+		id.principal, id.found, id.err = a.authenticate(r)
+		ctx := context.WithValue(r.Context(), identityKey{}, id)
+		if id.found {
+			ctx = auth.WithPrincipal(ctx, id.principal)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Middleware rejects invalid credentials and scopes the request to its
+// tenant. It reuses what Identify found, or authenticates the request
+// itself when Identify did not run.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, identified := r.Context().Value(identityKey{}).(identity)
+		if !identified {
+			id.principal, id.found, id.err = a.authenticate(r)
+		}
+		principal, found, err := id.principal, id.found, id.err
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+		if found {
+			r = r.WithContext(auth.WithPrincipal(r.Context(), principal))
+		}
+
+		tenantID, status, err := resolveTenant(r.Header.Get(tenantHeader), principal, found)
+		if err != nil {
+			writeError(w, status, err.Error())
+			return
+		}
+		r = r.WithContext(tenant.WithID(r.Context(), tenantID))
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (a *Authenticator) authenticate(r *http.Request) (auth.Principal, bool, error) {
+	if key := r.Header.Get(apiKeyHeader); key != "" {
+		principal, err := a.keys.Authenticate(key)
+		return principal, err == nil, err
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return auth.Principal{}, false, nil
+	}
+
+	// A JWT has three dot-separated segments; an API key has two.
+	if strings.Count(token, ".") == 2 {
+		principal, err := a.tokens.Verify(token)
+		return principal, err == nil, err
+	}
+	principal, err := a.keys.Authenticate(token)
+	return principal, err == nil, err
+}
+
+// resolveTenant picks the tenant a request is scoped to. Credentials bound
+// to a tenant always use it, and naming another in X-Tenant-ID is
+// forbidden. Unbound admins and anonymous callers may name any tenant;
+// everyone else gets the default tenant. Trusting the header from anonymous
+// callers is deliberate: riders' apps pick their city with it, and without
+// credentials they only reach the public serving routes, which show a
+// tenant's active ads to anyone. Nothing else, rate limits included, goes
+// by the header alone.
+func resolveTenant(header string, principal auth.Principal, authenticated bool) (string, int, error) {
+	if header != "" && !tenant.Valid(header) {
+		return "", http.StatusBadRequest, fmt.Errorf("invalid %s header", tenantHeader)
+	}
+
+	bound := principal.Tenant
+	if authenticated && bound == "" && !principal.HasRole(auth.RoleAdmin) {
+		bound = tenant.DefaultID
+	}
+	if bound == "" {
+		if header == "" {
+			return tenant.DefaultID, 0, nil
+		}
+		return header, 0, nil
+	}
+
+	if header != "" && header != bound {
+		return "", http.StatusForbidden, fmt.Errorf("credentials are not valid for tenant %q", header)
+	}
+	return bound, 0, nil
+}
+
+// Authorize wraps a route so it only runs when the caller's roles grant the
+// permission the route requires for the request.
+func (a *Authenticator) Authorize(route Route) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		permission := route.Permission(r)
+
+		principal, ok := auth.PrincipalFromContext(r.Context())
+		if !ok {
+			if permission == auth.PermServeAds && a.publicReads {
+				route.ServeHTTP(w, r)
+				return
+			}
+			writeError(w, http.StatusUnauthorized, "authentication required")
+			return
+		}
+
+		if !principal.Can(permission) {
+			writeError(w, http.StatusForbidden, fmt.Sprintf("missing permission %q", permission))
+			return
+		}
+
+		route.ServeHTTP(w, r)
+	})
+}
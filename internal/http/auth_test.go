@@ -0,0 +1,428 @@
+package http_server
+
+import (
+	"ads_backend/internal/auth"
+	"ads_backend/internal/featureflag"
+	"ads_backend/internal/metrics"
+	"ads_backend/internal/tenant"
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+const (
+	testAudience   = "ads-admin"
+	testHMACSecret = "test-hmac-secret"
+)
+
+func newAuthTestServer(t *testing.T, publicReads bool, tokens *auth.TokenVerifier) (http.Handler, *auth.KeyService) {
+	t.Helper()
+
+	keys := auth.NewKeyService(auth.NewKeyStore())
+	if tokens == nil {
+		tokens = auth.NewTokenVerifierFromConfig(auth.JWTConfig{})
+	}
+	authenticator := NewAuthenticator(zap.NewNop(), keys, tokens)
+	authenticator.publicReads = publicReads
+
+	echoSubject := func(w http.ResponseWriter, r *http.Request) {
+		principal, _ := auth.PrincipalFromContext(r.Context())
+		_, _ = w.Write([]byte(principal.Subject))
+	}
+	routes := []Route{
+		newRoute("GET /adspots", auth.PermServeAds, echoSubject),
+		newRoute("GET /adposts", auth.PermReadAds, echoSubject),
+		newRoute("POST /adposts", auth.PermCreateAds, echoSubject),
+		newRoute("POST /adposts/{id}/deactivate", auth.PermDeactivateAds, echoSubject),
+	}
+	routes = append(routes, NewAPIKeyRoutes(zap.NewNop(), keys)...)
+
+	return NewAPIHandler(NewServeMux(routes, authenticator), authenticator, NewRateLimiter(1000, 1000), nil), keys
+}
+
+func serveAs(handler http.Handler, method, target, header, credential string, body any) *httptest.ResponseRecorder {
+	bodyBytes := []byte{}
+	if body != nil {
+		bodyBytes, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, target, bytes.NewReader(bodyBytes))
+	if credential != "" {
+		req.Header.Set(header, credential)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func serveWithKey(handler http.Handler, method, target, key string, body any) *httptest.ResponseRecorder {
+	return serveAs(handler, method, target, apiKeyHeader, key, body)
+}
+
+func serveWithToken(handler http.Handler, method, target, token string) *httptest.ResponseRecorder {
+	return serveAs(handler, method, target, "Authorization", "Bearer "+token, nil)
+}
+
+func mintToken(t *testing.T, method jwt.SigningMethod, key any, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func validClaims(roles ...string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":   "user-1",
+		"aud":   testAudience,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"roles": roles,
+	}
+}
+
+func hmacVerifier() *auth.TokenVerifier {
+	return auth.NewTokenVerifierFromConfig(auth.JWTConfig{
+		HMACKeys: map[string][]byte{"": []byte(testHMACSecret)},
+		Audience: testAudience,
+	})
+}
+
+func TestAuthenticator_WritesRequireKey(t *testing.T) {
+	handler, keys := newAuthTestServer(t, true, nil)
+
+	w := serveWithKey(handler, http.MethodPost, "/adposts", "", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	var resp errorResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, "unauthorized", resp.Error.Code)
+
+	editor, key, err := keys.Issue("editor", auth.RoleEditor, "")
+	require.NoError(t, err)
+
+	w = serveWithKey(handler, http.MethodPost, "/adposts", editor, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, key.ID, w.Body.String())
+
+	viewer, _, err := keys.Issue("viewer", auth.RoleViewer, "")
+	require.NoError(t, err)
+
+	w = serveWithKey(handler, http.MethodPost, "/adposts", viewer, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, "forbidden", resp.Error.Code)
+}
+
+func TestAuthenticator_PublicReadsAreConfigurable(t *testing.T) {
+	handler, _ := newAuthTestServer(t, true, nil)
+	assert.Equal(t, http.StatusOK, serveWithKey(handler, http.MethodGet, "/adspots", "", nil).Code)
+	assert.Equal(t, http.StatusUnauthorized, serveWithKey(handler, http.MethodGet, "/adposts", "", nil).Code)
+
+	handler, _ = newAuthTestServer(t, false, nil)
+	assert.Equal(t, http.StatusUnauthorized, serveWithKey(handler, http.MethodGet, "/adspots", "", nil).Code)
+}
+
+func TestAuthenticator_InvalidKeyIsRejectedEvenOnReads(t *testing.T) {
+	handler, _ := newAuthTestServer(t, true, nil)
+
+	w := serveWithKey(handler, http.MethodGet, "/adspots", "adk_nope.nope", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAuthenticator_HS256RolesMapToRoutePermissions(t *testing.T) {
+	handler, _ := newAuthTestServer(t, false, hmacVerifier())
+
+	cases := []struct {
+		role     string
+		method   string
+		target   string
+		expected int
+	}{
+		{"viewer", http.MethodGet, "/adposts", http.StatusOK},
+		{"viewer", http.MethodPost, "/adposts/1/deactivate", http.StatusForbidden},
+		{"reviewer", http.MethodPost, "/adposts/1/deactivate", http.StatusOK},
+		{"reviewer", http.MethodPost, "/adposts", http.StatusForbidden},
+		{"editor", http.MethodPost, "/adposts", http.StatusOK},
+		{"editor", http.MethodGet, "/admin/apikeys", http.StatusForbidden},
+		{"admin", http.MethodGet, "/admin/apikeys", http.StatusOK},
+		{"unknown", http.MethodGet, "/adspots", http.StatusForbidden},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.role+" "+tc.method+" "+tc.target, func(t *testing.T) {
+			token := mintToken(t, jwt.SigningMethodHS256, []byte(testHMACSecret), "", validClaims(tc.role))
+			w := serveWithToken(handler, tc.method, tc.target, token)
+			assert.Equal(t, tc.expected, w.Code)
+		})
+	}
+}
+
+func TestAuthenticator_RejectsBadTokens(t *testing.T) {
+	handler, _ := newAuthTestServer(t, true, hmacVerifier())
+
+	expired := validClaims("admin")
+	expired["exp"] = time.Now().Add(-time.Hour).Unix()
+
+	wrongAudience := validClaims("admin")
+	wrongAudience["aud"] = "someone-else"
+
+	noExpiry := validClaims("admin")
+	delete(noExpiry, "exp")
+
+	noSubject := validClaims("admin")
+	delete(noSubject, "sub")
+
+	tokens := map[string]string{
+		"expired":        mintToken(t, jwt.SigningMethodHS256, []byte(testHMACSecret), "", expired),
+		"wrong audience": mintToken(t, jwt.SigningMethodHS256, []byte(testHMACSecret), "", wrongAudience),
+		"no expiry":      mintToken(t, jwt.SigningMethodHS256, []byte(testHMACSecret), "", noExpiry),
+		"no subject":     mintToken(t, jwt.SigningMethodHS256, []byte(testHMACSecret), "", noSubject),
+		"wrong secret":   mintToken(t, jwt.SigningMethodHS256, []byte("other"), "", validClaims("admin")),
+		"alg none":       mintToken(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "", validClaims("admin")),
+	}
+
+	for name, token := range tokens {
+		t.Run(name, func(t *testing.T) {
+			w := serveWithToken(handler, http.MethodGet, "/adspots", token)
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+			var resp errorResponse
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+			assert.Equal(t, "unauthorized", resp.Error.Code)
+		})
+	}
+}
+
+func TestNewTokenVerifier_WarnsWithoutAudience(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+
+	_, err := auth.NewTokenVerifier(zap.New(core), auth.JWTSettings{HS256Secret: testHMACSecret, Audience: testAudience})
+	require.NoError(t, err)
+	_, err = auth.NewTokenVerifier(zap.New(core), auth.JWTSettings{})
+	require.NoError(t, err)
+	assert.Zero(t, logs.Len(), "no warning with an audience, or with no keys at all")
+
+	_, err = auth.NewTokenVerifier(zap.New(core), auth.JWTSettings{HS256Secret: testHMACSecret, Issuer: "https://id.example.com"})
+	require.NoError(t, err)
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, "JWT audience is not configured, tokens issued for any audience are accepted", logs.All()[0].Message)
+}
+
+func TestAuthenticator_RS256FromJWKSFile(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	jwks := map[string]any{"keys": []map[string]string{
+		{
+			"kty": "RSA",
+			"kid": "key-1",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(privateKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(privateKey.E)).Bytes()),
+		},
+		{
+			"kty": "oct",
+			"kid": "shared",
+			"k":   base64.RawURLEncoding.EncodeToString([]byte(testHMACSecret)),
+		},
+	}}
+	raw, _ := json.Marshal(jwks)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, raw, 0o600))
+
+	verifier, err := auth.NewTokenVerifier(zap.NewNop(), auth.JWTSettings{JWKSFile: path, Audience: testAudience})
+	require.NoError(t, err)
+
+	handler, _ := newAuthTestServer(t, false, verifier)
+
+	token := mintToken(t, jwt.SigningMethodRS256, privateKey, "key-1", validClaims("editor"))
+	w := serveWithToken(handler, http.MethodPost, "/adposts", token)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "user-1", w.Body.String())
+
+	token = mintToken(t, jwt.SigningMethodHS256, []byte(testHMACSecret), "shared", validClaims("viewer"))
+	assert.Equal(t, http.StatusOK, serveWithToken(handler, http.MethodGet, "/adposts", token).Code)
+
+	token = mintToken(t, jwt.SigningMethodRS256, privateKey, "unknown-kid", validClaims("editor"))
+	assert.Equal(t, http.StatusUnauthorized, serveWithToken(handler, http.MethodPost, "/adposts", token).Code)
+}
+
+func TestAPIKeyRoutes_IssueListRevoke(t *testing.T) {
+	handler, keys := newAuthTestServer(t, true, nil)
+	admin, _, err := keys.Issue("admin", auth.RoleAdmin, "")
+	require.NoError(t, err)
+	editor, _, err := keys.Issue("editor", auth.RoleEditor, "")
+	require.NoError(t, err)
+
+	w := serveWithKey(handler, http.MethodGet, "/admin/apikeys", editor, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = serveWithKey(handler, http.MethodPost, "/admin/apikeys", admin, map[string]string{"name": "ci"})
+	require.Equal(t, http.StatusCreated, w.Code)
+	var issued issuedAPIKey
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&issued))
+	assert.Equal(t, auth.RoleEditor, issued.Role)
+	assert.NotEmpty(t, issued.Key)
+
+	w = serveWithKey(handler, http.MethodGet, "/admin/apikeys", admin, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), issued.Key)
+	var listed []auth.APIKey
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&listed))
+	assert.Len(t, listed, 3)
+
+	w = serveWithKey(handler, http.MethodPost, "/admin/apikeys/"+issued.ID+"/revoke", admin, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = serveWithKey(handler, http.MethodPost, "/adposts", issued.Key, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = serveWithKey(handler, http.MethodPost, "/admin/apikeys/missing/revoke", admin, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestAPIKeyRoutes_TenantAdminIssuesOnlyForItsTenant(t *testing.T) {
+	handler, keys := newAuthTestServer(t, true, nil)
+	admin, _, err := keys.Issue("bogota admin", auth.RoleAdmin, "bogota")
+	require.NoError(t, err)
+
+	for _, tenantID := range []string{"lima", tenant.DefaultID} {
+		w := serveWithKey(handler, http.MethodPost, "/admin/apikeys", admin, map[string]string{"name": "ci", "role": "admin", "tenant": tenantID})
+		assert.Equal(t, http.StatusForbidden, w.Code, tenantID)
+	}
+
+	w := serveWithKey(handler, http.MethodPost, "/admin/apikeys", admin, map[string]string{"name": "ci"})
+	require.Equal(t, http.StatusCreated, w.Code)
+	var issued issuedAPIKey
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&issued))
+	assert.Equal(t, "bogota", issued.Tenant, "defaults to the issuer's tenant")
+
+	unbound, _, err := keys.Issue("root", auth.RoleAdmin, "")
+	require.NoError(t, err)
+	w = serveWithKey(handler, http.MethodPost, "/admin/apikeys", unbound, map[string]string{"name": "ci", "tenant": "lima"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
+
+func TestAPIKeyRoutes_TenantAdminManagesOnlyItsTenantsKeys(t *testing.T) {
+	handler, keys := newAuthTestServer(t, true, nil)
+	admin, own, err := keys.Issue("bogota admin", auth.RoleAdmin, "bogota")
+	require.NoError(t, err)
+	_, sibling, err := keys.Issue("bogota ci", auth.RoleEditor, "bogota")
+	require.NoError(t, err)
+	_, other, err := keys.Issue("lima ci", auth.RoleEditor, "lima")
+	require.NoError(t, err)
+	_, unbound, err := keys.Issue("root", auth.RoleAdmin, "")
+	require.NoError(t, err)
+
+	w := serveWithKey(handler, http.MethodGet, "/admin/apikeys", admin, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var listed []auth.APIKey
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&listed))
+	ids := make([]string, 0, len(listed))
+	for _, key := range listed {
+		ids = append(ids, key.ID)
+	}
+	assert.ElementsMatch(t, []string{own.ID, sibling.ID}, ids)
+
+	for _, id := range []string{other.ID, unbound.ID, "missing"} {
+		w = serveWithKey(handler, http.MethodPost, "/admin/apikeys/"+id+"/revoke", admin, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code, id)
+	}
+	revoked, err := keys.Get(other.ID)
+	require.NoError(t, err)
+	assert.Nil(t, revoked.RevokedAt)
+
+	w = serveWithKey(handler, http.MethodPost, "/admin/apikeys/"+sibling.ID+"/revoke", admin, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestSystemRoutes_RequireAnAdminWithoutTenant(t *testing.T) {
+	keys := auth.NewKeyService(auth.NewKeyStore())
+	authenticator := NewAuthenticator(zap.NewNop(), keys, auth.NewTokenVerifierFromConfig(auth.JWTConfig{}))
+	routes := append(NewFeatureFlagRoutes(zap.NewNop(), featureflag.NewStatic()), NewMetricsRoute(metrics.New()))
+	handler := NewAPIHandler(NewServeMux(routes, authenticator), authenticator, NewRateLimiter(1000, 1000), nil)
+
+	unbound, _, err := keys.Issue("root", auth.RoleAdmin, "")
+	require.NoError(t, err)
+	bound, _, err := keys.Issue("bogota admin", auth.RoleAdmin, "bogota")
+	require.NoError(t, err)
+
+	for _, target := range []string{"/admin/flags", "/metrics"} {
+		assert.Equal(t, http.StatusOK, serveWithKey(handler, http.MethodGet, target, unbound, nil).Code, target)
+		assert.Equal(t, http.StatusForbidden, serveWithKey(handler, http.MethodGet, target, bound, nil).Code, target)
+	}
+}
+
+func TestResolveTenant(t *testing.T) {
+	editor := auth.Principal{Subject: "k1", Roles: []auth.Role{auth.RoleEditor}}
+	bound := auth.Principal{Subject: "k2", Roles: []auth.Role{auth.RoleEditor}, Tenant: "bogota"}
+	admin := auth.Principal{Subject: "k3", Roles: []auth.Role{auth.RoleAdmin}}
+
+	cases := map[string]struct {
+		header        string
+		principal     auth.Principal
+		authenticated bool
+		tenant        string
+		status        int
+	}{
+		"anonymous default":       {tenant: "default"},
+		"anonymous header":        {header: "lima", tenant: "lima"},
+		"invalid header":          {header: "Lima!", status: http.StatusBadRequest},
+		"bound key":               {principal: bound, authenticated: true, tenant: "bogota"},
+		"bound key same header":   {header: "bogota", principal: bound, authenticated: true, tenant: "bogota"},
+		"bound key other header":  {header: "lima", principal: bound, authenticated: true, status: http.StatusForbidden},
+		"unbound editor":          {principal: editor, authenticated: true, tenant: "default"},
+		"unbound editor header":   {header: "lima", principal: editor, authenticated: true, status: http.StatusForbidden},
+		"unbound admin picks any": {header: "lima", principal: admin, authenticated: true, tenant: "lima"},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			tenantID, status, err := resolveTenant(tc.header, tc.principal, tc.authenticated)
+			if tc.status != 0 {
+				assert.Error(t, err)
+				assert.Equal(t, tc.status, status)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.tenant, tenantID)
+		})
+	}
+}
+
+func TestJWT_TenantClaimBindsToken(t *testing.T) {
+	handler, _ := newAuthTestServer(t, true, hmacVerifier())
+
+	claims := validClaims("editor")
+	claims["tenant"] = "bogota"
+	token := mintToken(t, jwt.SigningMethodHS256, []byte(testHMACSecret), "", claims)
+
+	req := httptest.NewRequest(http.MethodGet, "/adposts", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set(tenantHeader, "lima")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	claims["tenant"] = "Not A Tenant"
+	token = mintToken(t, jwt.SigningMethodHS256, []byte(testHMACSecret), "", claims)
+	assert.Equal(t, http.StatusUnauthorized, serveWithToken(handler, http.MethodGet, "/adposts", token).Code)
+}
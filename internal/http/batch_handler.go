@@ -0,0 +1,133 @@
+package http_server
+
+import (
+	"ads_backend/internal/ads_service"
+	"ads_backend/internal/auth"
+	"ads_backend/internal/domain"
+	"encoding/json"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+type batchResponse struct {
+	Atomic    bool                 `json:"atomic"`
+	Succeeded int                  `json:"succeeded"`
+	Failed    int                  `json:"failed"`
+	Results   []domain.BatchResult `json:"results"`
+}
+
+// BatchHandler serves bulk create and bulk deactivate so a whole campaign
+// costs one request against the rate limiter instead of one per ad.
+type BatchHandler struct {
+	log     *zap.Logger
+	service ads_service.Service
+}
+
+func NewBatchHandler(log *zap.Logger, service ads_service.Service) *BatchHandler {
+	return &BatchHandler{log: log, service: service}
+}
+
+func (h *BatchHandler) Pattern() string {
+	return "POST /adposts/batch/{action}"
+}
+
+func (h *BatchHandler) Permission(r *http.Request) auth.Permission {
+	if r.PathValue("action") == "deactivate" {
+		return auth.PermDeactivateAds
+	}
+	return auth.PermCreateAds
+}
+
+func (h *BatchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.PathValue("action") {
+	case "create":
+		var req batchCreateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if err := validateBatchSize(len(req.Items)); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		results := make([]domain.BatchResult, len(req.Items))
+		ads := make([]domain.Ad, 0, len(req.Items))
+		positions := make([]int, 0, len(req.Items))
+		for i := range req.Items {
+			if err := req.Items[i].Validate(); err != nil {
+				results[i] = domain.BatchResult{Index: i, Error: err.Error()}
+				continue
+			}
+			ads = append(ads, req.Items[i].toAd())
+			positions = append(positions, i)
+		}
+
+		if req.Atomic && len(ads) < len(req.Items) {
+			for _, i := range positions {
+				results[i] = domain.BatchResult{Index: i, Error: "not attempted: batch contains invalid items"}
+			}
+			h.writeResults(w, req.Atomic, results)
+			return
+		}
+
+		created, err := h.service.CreateAds(r.Context(), ads, req.Atomic)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		for i, result := range created {
+			result.Index = positions[i]
+			results[positions[i]] = result
+		}
+
+		h.writeResults(w, req.Atomic, results)
+
+	case "deactivate":
+		var req batchDeactivateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if err := validateBatchSize(len(req.IDs)); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		results, err := h.service.DeactivateAds(r.Context(), req.IDs, req.Atomic)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+
+		h.writeResults(w, req.Atomic, results)
+
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// writeResults answers 200 with per-item outcomes, except that an atomic
+// batch with any failure wrote nothing and is answered 422.
+func (h *BatchHandler) writeResults(w http.ResponseWriter, atomic bool, results []domain.BatchResult) {
+	resp := batchResponse{Atomic: atomic, Results: results}
+	for _, result := range results {
+		if result.Failed() {
+			resp.Failed++
+		} else {
+			resp.Succeeded++
+		}
+	}
+
+	status := http.StatusOK
+	if atomic && resp.Failed > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
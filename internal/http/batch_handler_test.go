@@ -0,0 +1,128 @@
+package http_server
+
+import (
+	"ads_backend/internal/domain"
+	"ads_backend/mocks"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func serveBatch(handler *BatchHandler, action string, body any) *httptest.ResponseRecorder {
+	bodyBytes, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/adposts/batch/"+action, bytes.NewBuffer(bodyBytes))
+	req.SetPathValue("action", action)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestBatchCreate_ReportsInvalidItemsAndCreatesTheRest(t *testing.T) {
+	mockService := mocks.NewService(t)
+	mockService.On("CreateAds", mock.Anything, mock.MatchedBy(func(ads []domain.Ad) bool {
+		return len(ads) == 1 && ads[0].Title == "Valid"
+	}), false).Return([]domain.BatchResult{{Index: 0, ID: "new-id", Ad: &domain.Ad{ID: "new-id", Title: "Valid"}}}, nil)
+
+	handler := NewBatchHandler(zap.NewNop(), mockService)
+
+	w := serveBatch(handler, "create", map[string]any{
+		"items": []map[string]any{
+			{"title": "", "imageUrl": "http://example.com/a.jpg", "placement": "home_screen"},
+			{"title": "Valid", "imageUrl": "http://example.com/b.jpg", "placement": "home_screen"},
+		},
+	})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp batchResponse
+	err := json.NewDecoder(w.Body).Decode(&resp)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, resp.Succeeded)
+	assert.Equal(t, 1, resp.Failed)
+	assert.Equal(t, "title is required", resp.Results[0].Error)
+	assert.Equal(t, 1, resp.Results[1].Index)
+	assert.Equal(t, "new-id", resp.Results[1].ID)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestBatchCreate_AtomicWithInvalidItemWritesNothing(t *testing.T) {
+	mockService := mocks.NewService(t)
+
+	handler := NewBatchHandler(zap.NewNop(), mockService)
+
+	w := serveBatch(handler, "create", map[string]any{
+		"atomic": true,
+		"items": []map[string]any{
+			{"title": "Valid", "imageUrl": "http://example.com/b.jpg", "placement": "home_screen"},
+			{"title": "No image", "placement": "home_screen"},
+		},
+	})
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	var resp batchResponse
+	err := json.NewDecoder(w.Body).Decode(&resp)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, resp.Succeeded)
+	assert.Equal(t, 2, resp.Failed)
+
+	mockService.AssertNotCalled(t, "CreateAds")
+}
+
+func TestBatchDeactivate_AtomicFailureReturns422(t *testing.T) {
+	mockService := mocks.NewService(t)
+	mockService.On("DeactivateAds", mock.Anything, []string{"1", "missing"}, true).Return([]domain.BatchResult{
+		{Index: 0, Error: "rolled back: ad not found"},
+		{Index: 1, Error: "ad not found"},
+	}, nil)
+
+	handler := NewBatchHandler(zap.NewNop(), mockService)
+
+	w := serveBatch(handler, "deactivate", map[string]any{"ids": []string{"1", "missing"}, "atomic": true})
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestBatchDeactivate_KeepsResultsOfUnrecordedItems(t *testing.T) {
+	mockService := mocks.NewService(t)
+	mockService.On("DeactivateAds", mock.Anything, []string{"1", "2"}, false).Return([]domain.BatchResult{
+		{Index: 0, ID: "1", Ad: &domain.Ad{ID: "1"}},
+		{Index: 1, ID: "2", Ad: &domain.Ad{ID: "2"}, Error: "ad saved but its change was not recorded: ad 2: disk full", Code: domain.CodeNotRecorded},
+	}, nil)
+
+	handler := NewBatchHandler(zap.NewNop(), mockService)
+
+	w := serveBatch(handler, "deactivate", map[string]any{"ids": []string{"1", "2"}})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp batchResponse
+	err := json.NewDecoder(w.Body).Decode(&resp)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, resp.Succeeded)
+	assert.Equal(t, 0, resp.Failed)
+	assert.Equal(t, "2", resp.Results[1].ID)
+	assert.Equal(t, domain.CodeNotRecorded, resp.Results[1].Code)
+}
+
+func TestBatch_RejectsEmptyAndUnknownAction(t *testing.T) {
+	mockService := mocks.NewService(t)
+
+	handler := NewBatchHandler(zap.NewNop(), mockService)
+
+	w := serveBatch(handler, "deactivate", map[string]any{"ids": []string{}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "at least one item")
+
+	w = serveBatch(handler, "purge", map[string]any{"ids": []string{"1"}})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
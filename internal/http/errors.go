@@ -0,0 +1,65 @@
+package http_server
+
+import (
+	"ads_backend/internal/ads_service"
+	"ads_backend/internal/domain"
+	"ads_backend/internal/persistence"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// codeNotRecorded is the error code for an ad that was saved but whose change
+// was not recorded.
+const codeNotRecorded = domain.CodeNotRecorded
+
+type errorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type errorResponse struct {
+	Error errorBody `json:"error"`
+}
+
+// writeError writes the standard JSON error envelope. The code is the
+// status text in snake_case, e.g. "too_many_requests".
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeErrorCode(w, status, strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_"), message)
+}
+
+func writeErrorCode(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(errorResponse{Error: errorBody{Code: code, Message: message}})
+}
+
+// serviceErrorStatus is the status for an error from the service: 404 for a
+// missing ad or version, 504 when the request ran out of time, 503 when it
+// was canceled before it finished, and 500 for anything else.
+func serviceErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, persistence.ErrAdNotFound), errors.Is(err, persistence.ErrVersionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, context.Canceled):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// writeServiceError writes an error from the service with the status from
+// serviceErrorStatus. An ad that was saved but whose change was not recorded
+// gets codeNotRecorded instead, so the client can tell it from a write that
+// failed and does not retry it.
+func writeServiceError(w http.ResponseWriter, err error) {
+	if errors.Is(err, ads_service.ErrNotRecorded) {
+		writeErrorCode(w, http.StatusInternalServerError, codeNotRecorded, err.Error())
+		return
+	}
+	writeError(w, serviceErrorStatus(err), err.Error())
+}
@@ -0,0 +1,118 @@
+package http_server
+
+import (
+	"ads_backend/internal/auth"
+	"ads_backend/internal/featureflag"
+	"ads_backend/internal/logging"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+type setFlagRequest struct {
+	Description string   `json:"description"`
+	Enabled     bool     `json:"enabled"`
+	Percentage  *float64 `json:"percentage"`
+}
+
+type FeatureFlagHandler struct {
+	log   *zap.Logger
+	flags *featureflag.Flags
+}
+
+// NewFeatureFlagRoutes exposes the admin endpoints that list, read, set and
+// delete feature flags. Changes are saved to the flags file, when there is
+// one.
+func NewFeatureFlagRoutes(log *zap.Logger, flags *featureflag.Flags) []Route {
+	h := &FeatureFlagHandler{log: log, flags: flags}
+	return []Route{
+		newRoute("GET /admin/flags", auth.PermSystem, h.list),
+		newRoute("GET /admin/flags/{name}", auth.PermSystem, h.get),
+		newRoute("PUT /admin/flags/{name}", auth.PermSystem, h.set),
+		newRoute("DELETE /admin/flags/{name}", auth.PermSystem, h.delete),
+	}
+}
+
+func (h *FeatureFlagHandler) list(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(h.flags.List())
+}
+
+func (h *FeatureFlagHandler) get(w http.ResponseWriter, r *http.Request) {
+	flag, err := h.flags.Get(r.PathValue("name"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(flag)
+}
+
+func (h *FeatureFlagHandler) set(w http.ResponseWriter, r *http.Request) {
+	var req setFlagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	flag := featureflag.Flag{
+		Name:        r.PathValue("name"),
+		Description: req.Description,
+		Enabled:     req.Enabled,
+		Percentage:  req.Percentage,
+	}
+	if err := flag.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	previous, err := h.flags.Set(flag)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	changer, _ := auth.PrincipalFromContext(r.Context())
+	fields := []zap.Field{
+		zap.String("flag", flag.Name),
+		zap.Bool("enabled", flag.Enabled),
+		zap.String("changed_by", changer.Subject),
+	}
+	if flag.Percentage != nil {
+		fields = append(fields, zap.Float64("percentage", *flag.Percentage))
+	}
+	if previous != nil {
+		fields = append(fields, zap.Any("previous", previous))
+	}
+	logging.For(r.Context(), h.log).Info("Feature flag changed", fields...)
+
+	status := http.StatusOK
+	if previous == nil {
+		status = http.StatusCreated
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(flag)
+}
+
+func (h *FeatureFlagHandler) delete(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	err := h.flags.Delete(name)
+	if errors.Is(err, featureflag.ErrFlagNotFound) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	changer, _ := auth.PrincipalFromContext(r.Context())
+	logging.For(r.Context(), h.log).Info("Feature flag deleted",
+		zap.String("flag", name),
+		zap.String("changed_by", changer.Subject),
+	)
+	w.WriteHeader(http.StatusNoContent)
+}
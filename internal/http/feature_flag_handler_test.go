@@ -0,0 +1,92 @@
+package http_server
+
+import (
+	"ads_backend/internal/auth"
+	"ads_backend/internal/domain"
+	"ads_backend/internal/featureflag"
+	"ads_backend/mocks"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestFeatureFlagRoutes(t *testing.T) {
+	keys := auth.NewKeyService(auth.NewKeyStore())
+	admin, _, err := keys.Issue("ops", auth.RoleAdmin, "")
+	require.NoError(t, err)
+	editor, _, err := keys.Issue("panel", auth.RoleEditor, "")
+	require.NoError(t, err)
+
+	flags := featureflag.NewStatic()
+	authenticator := NewAuthenticator(zap.NewNop(), keys, auth.NewTokenVerifierFromConfig(auth.JWTConfig{}))
+	handler := NewAPIHandler(NewServeMux(NewFeatureFlagRoutes(zap.NewNop(), flags), authenticator), authenticator, NewRateLimiter(100, 100), nil)
+
+	serve := func(method, target, body, apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set(apiKeyHeader, apiKey)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(http.MethodPut, "/admin/flags/serving.newest_first", `{"enabled": true, "percentage": 10}`, admin)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	w = serve(http.MethodPut, "/admin/flags/serving.newest_first", `{"enabled": true, "percentage": 30}`, admin)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"name": "serving.newest_first", "enabled": true, "percentage": 30}`, w.Body.String())
+
+	w = serve(http.MethodGet, "/admin/flags", "", admin)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var listed []featureflag.Flag
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&listed))
+	require.Len(t, listed, 1)
+	assert.Equal(t, 30.0, *listed[0].Percentage)
+
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/admin/flags/serving.newest_first", "", admin).Code)
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/admin/flags/unknown", "", admin).Code)
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPut, "/admin/flags/rollout", `{"enabled": true, "percentage": 120}`, admin).Code)
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPut, "/admin/flags/Roll%20Out", `{"enabled": true}`, admin).Code)
+	assert.Equal(t, http.StatusForbidden, serve(http.MethodPut, "/admin/flags/rollout", `{"enabled": true}`, editor).Code)
+
+	assert.Equal(t, http.StatusNoContent, serve(http.MethodDelete, "/admin/flags/serving.newest_first", "", admin).Code)
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodDelete, "/admin/flags/serving.newest_first", "", admin).Code)
+	assert.Empty(t, flags.List())
+}
+
+func TestAdsHandler_CachesAdspotsForRidersInTheRollout(t *testing.T) {
+	half := 50.0
+	rollout := featureflag.Flag{Name: FlagCacheAdspots, Enabled: true, Percentage: &half}
+	var in, out string
+	for i := 0; in == "" || out == ""; i++ {
+		rider := fmt.Sprintf("rider-%d", i)
+		if rollout.EnabledFor(rider) {
+			in = rider
+		} else {
+			out = rider
+		}
+	}
+
+	mockService := mocks.NewService(t)
+	mockService.On("ListEligibleActiveAdsByPlacement", mock.Anything, domain.HomeScreen).Return([]domain.Ad{}, nil)
+	handler := NewAdsHandler(zap.NewNop(), mockService, featureflag.NewStatic(rollout))
+
+	cacheControl := map[string]string{}
+	for _, rider := range []string{in, out} {
+		req := httptest.NewRequest(http.MethodGet, "/adspots?placement=home_screen", nil)
+		req.Header.Set(userIDHeader, rider)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		cacheControl[rider] = w.Header().Get("Cache-Control")
+	}
+
+	assert.Equal(t, map[string]string{in: "private, max-age=30", out: ""}, cacheControl)
+}
@@ -2,7 +2,9 @@ package http_server
 
 import (
 	"ads_backend/internal/ads_service"
+	"ads_backend/internal/auth"
 	"ads_backend/internal/domain"
+	"ads_backend/internal/featureflag"
 	"encoding/json"
 	"net/http"
 	"strings"
@@ -10,45 +12,105 @@ import (
 	"go.uber.org/zap"
 )
 
+// userIDHeader identifies the rider an ad request is for. Feature flags
+// rolled out to a percentage of users are bucketed by it.
+const userIDHeader = "X-User-ID"
+
+// FlagCacheAdspots lets the rider app reuse a GET /adspots answer for
+// adspotsMaxAge instead of asking again on every screen.
+const FlagCacheAdspots = "serving.cache_adspots"
+
+const adspotsMaxAge = "30"
+
+// AdsHandler serves the ad CRUD and serving endpoints. ServeHTTP binds the
+// request's user to its context first, so flags.Enabled(r.Context(), name)
+// evaluates a flag for the rider being served.
 type AdsHandler struct {
 	log     *zap.Logger
 	service ads_service.Service
+	flags   *featureflag.Flags
 }
 
-func NewAdsHandler(log *zap.Logger, service ads_service.Service) *AdsHandler {
-	return &AdsHandler{log: log, service: service}
+func NewAdsHandler(log *zap.Logger, service ads_service.Service, flags *featureflag.Flags) *AdsHandler {
+	return &AdsHandler{log: log, service: service, flags: flags}
 }
 
 func (h *AdsHandler) Pattern() string {
 	return "/"
 }
 
+func (h *AdsHandler) Permission(r *http.Request) auth.Permission {
+	switch {
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/deactivate"):
+		return auth.PermDeactivateAds
+	case r.Method == http.MethodPost:
+		return auth.PermCreateAds
+	case r.Method == http.MethodPatch:
+		return auth.PermUpdateAds
+	case r.URL.Path == "/adposts":
+		return auth.PermReadAds
+	default:
+		return auth.PermServeAds
+	}
+}
+
+// adsRouteLabel is the pattern ServeHTTP serves r under, or "unmatched".
+func adsRouteLabel(r *http.Request) string {
+	switch r.Method {
+	case http.MethodGet, http.MethodPost, http.MethodPatch:
+	default:
+		return "unmatched"
+	}
+
+	path := r.URL.Path
+	if path == "/adposts" || path == "/adspots" {
+		return r.Method + " " + path
+	}
+	rest, ok := strings.CutPrefix(path, "/adposts/")
+	if !ok {
+		return "unmatched"
+	}
+	if id, ok := strings.CutSuffix(rest, "/deactivate"); ok && id != "" && !strings.Contains(id, "/") {
+		return r.Method + " /adposts/{id}/deactivate"
+	}
+	if rest != "" && !strings.Contains(rest, "/") {
+		return r.Method + " /adposts/{id}"
+	}
+	return "unmatched"
+}
+
+// flagUser is who feature flags are evaluated for: the rider named by
+// X-User-ID, or else the authenticated caller.
+func flagUser(r *http.Request) string {
+	if userID := r.Header.Get(userIDHeader); userID != "" {
+		return userID
+	}
+	if principal, ok := auth.PrincipalFromContext(r.Context()); ok {
+		return principal.Method + ":" + principal.Subject
+	}
+	return ""
+}
+
 func (h *AdsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r = r.WithContext(featureflag.WithUser(r.Context(), flagUser(r)))
 	path := r.URL.Path
 	switch {
 	case r.Method == http.MethodPost && path == "/adposts":
 		var req createAdRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			writeError(w, http.StatusBadRequest, err.Error())
 			return
 		}
 
 		err := req.Validate()
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			writeError(w, http.StatusBadRequest, err.Error())
 			return
 		}
 
-		ad := domain.Ad{
-			Title:      req.Title,
-			ImageUrl:   req.ImageURL,
-			Placement:  req.Placement,
-			TTLMinutes: *req.TTLMinutes,
-		}
-
-		created, err := h.service.CreateAd(ad)
+		created, err := h.service.CreateAd(r.Context(), req.toAd())
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			writeServiceError(w, err)
 			return
 		}
 
@@ -56,21 +118,67 @@ func (h *AdsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusCreated)
 		_ = json.NewEncoder(w).Encode(created)
 
+	case r.Method == http.MethodGet && path == "/adposts":
+		query, err := parseListAdsQuery(r.URL.Query())
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		page, err := h.service.ListAds(r.Context(), query)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(page)
+
 	case r.Method == http.MethodGet && strings.HasPrefix(path, "/adposts/"):
 		const prefix = "/adposts/"
 		id := strings.TrimPrefix(path, prefix)
 		if id == "" || strings.Contains(id, "/") {
-			http.NotFound(w, r)
+			writeError(w, http.StatusNotFound, "not found")
+			return
+		}
+
+		ad, err := h.service.GetAd(r.Context(), id)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(ad)
+
+	case r.Method == http.MethodPatch && strings.HasPrefix(path, "/adposts/"):
+		const prefix = "/adposts/"
+		id := strings.TrimPrefix(path, prefix)
+		if id == "" || strings.Contains(id, "/") {
+			writeError(w, http.StatusNotFound, "not found")
+			return
+		}
+
+		var req updateAdRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
 			return
 		}
 
-		ad, err := h.service.GetAd(id)
+		if err := req.Validate(); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		ad, err := h.service.UpdateAd(r.Context(), id, req.toUpdate())
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusNotFound)
+			writeServiceError(w, err)
 			return
 		}
 
 		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
 		_ = json.NewEncoder(w).Encode(ad)
 
 	case r.Method == http.MethodPost && strings.HasPrefix(path, "/adposts/") && strings.HasSuffix(path, "/deactivate"):
@@ -80,13 +188,13 @@ func (h *AdsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		id := strings.TrimSuffix(pathWithoutPrefix, suffix)
 
 		if id == "" || strings.Contains(id, "/") {
-			http.NotFound(w, r)
+			writeError(w, http.StatusNotFound, "not found")
 			return
 		}
 
-		ad, err := h.service.DeactivateAd(id)
+		ad, err := h.service.DeactivateAd(r.Context(), id)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusNotFound)
+			writeServiceError(w, err)
 			return
 		}
 
@@ -99,40 +207,57 @@ func (h *AdsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		statusStr := r.URL.Query().Get("status")
 
 		if statusStr != "" && statusStr != "active" {
-			http.Error(w, "status parameter must be 'active'", http.StatusBadRequest)
+			writeError(w, http.StatusBadRequest, "status parameter must be 'active'")
 			return
 		}
 
 		if placementStr == "" {
-			http.Error(w, "placement parameter is required", http.StatusBadRequest)
+			writeError(w, http.StatusBadRequest, "placement parameter is required")
 			return
 		}
 
 		placement := domain.Placement(placementStr)
-		if placement != domain.HomeScreen && placement != domain.RideSummary && placement != domain.MapView {
-			http.Error(w, "invalid placement value", http.StatusBadRequest)
+		if !isValidPlacement(placement) {
+			writeError(w, http.StatusBadRequest, "invalid placement value")
 			return
 		}
 
-		ads, err := h.service.ListEligibleActiveAdsByPlacement(placement)
+		ads, err := h.service.ListEligibleActiveAdsByPlacement(r.Context(), placement)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			writeError(w, http.StatusInternalServerError, err.Error())
 			return
 		}
 
+		if h.flags.Enabled(r.Context(), FlagCacheAdspots) {
+			w.Header().Set("Cache-Control", "private, max-age="+adspotsMaxAge)
+		}
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		_ = json.NewEncoder(w).Encode(ads)
 
 	default:
-		http.NotFound(w, r)
+		writeError(w, http.StatusNotFound, "not found")
 	}
 }
 
-func NewServeMux(routes []Route) *http.ServeMux {
+func NewServeMux(routes []Route, authenticator *Authenticator) *http.ServeMux {
 	mux := http.NewServeMux()
 	for _, route := range routes {
-		mux.Handle(route.Pattern(), route)
+		mux.Handle(route.Pattern(), routeHandler{route: route, Handler: authenticator.Authorize(route)})
 	}
 	return mux
 }
+
+// routeHandler is what NewServeMux registers for a route, so middleware in
+// front of the mux can tell which Route a request is for.
+type routeHandler struct {
+	route Route
+	http.Handler
+}
+
+// routeFor is the Route mux would serve r with.
+func routeFor(mux *http.ServeMux, r *http.Request) (Route, bool) {
+	handler, _ := mux.Handler(r)
+	rh, ok := handler.(routeHandler)
+	return rh.route, ok
+}
@@ -1,18 +1,29 @@
 package http_server
 
 import (
+	"ads_backend/internal/ads_service"
+	"ads_backend/internal/auth"
 	"ads_backend/internal/domain"
+	"ads_backend/internal/featureflag"
+	"ads_backend/internal/idempotency"
+	"ads_backend/internal/metrics"
+	"ads_backend/internal/persistence"
+	"ads_backend/internal/search"
 	"ads_backend/mocks"
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 )
 
@@ -41,10 +52,10 @@ func TestListEligibleActiveAdsByPlacement_Success(t *testing.T) {
 	}
 
 	mockService := mocks.NewService(t)
-	mockService.On("ListEligibleActiveAdsByPlacement", domain.HomeScreen).
+	mockService.On("ListEligibleActiveAdsByPlacement", mock.Anything, domain.HomeScreen).
 		Return(mockAds, nil)
 
-	handler := NewAdsHandler(zap.NewNop(), mockService)
+	handler := NewAdsHandler(zap.NewNop(), mockService, featureflag.NewStatic())
 
 	req := httptest.NewRequest(http.MethodGet, "/adspots?placement=home_screen&status=active", nil)
 	w := httptest.NewRecorder()
@@ -67,7 +78,7 @@ func TestListEligibleActiveAdsByPlacement_Success(t *testing.T) {
 func TestListEligibleActiveAdsByPlacement_MissingPlacement(t *testing.T) {
 	mockService := mocks.NewService(t)
 
-	handler := NewAdsHandler(zap.NewNop(), mockService)
+	handler := NewAdsHandler(zap.NewNop(), mockService, featureflag.NewStatic())
 
 	req := httptest.NewRequest(http.MethodGet, "/adspots?status=active", nil)
 	w := httptest.NewRecorder()
@@ -83,7 +94,7 @@ func TestListEligibleActiveAdsByPlacement_MissingPlacement(t *testing.T) {
 func TestListEligibleActiveAdsByPlacement_InvalidPlacement(t *testing.T) {
 	mockService := mocks.NewService(t)
 
-	handler := NewAdsHandler(zap.NewNop(), mockService)
+	handler := NewAdsHandler(zap.NewNop(), mockService, featureflag.NewStatic())
 
 	req := httptest.NewRequest(http.MethodGet, "/adspots?placement=invalid_placement&status=active", nil)
 	w := httptest.NewRecorder()
@@ -99,7 +110,7 @@ func TestListEligibleActiveAdsByPlacement_InvalidPlacement(t *testing.T) {
 func TestListEligibleActiveAdsByPlacement_InvalidStatus(t *testing.T) {
 	mockService := mocks.NewService(t)
 
-	handler := NewAdsHandler(zap.NewNop(), mockService)
+	handler := NewAdsHandler(zap.NewNop(), mockService, featureflag.NewStatic())
 
 	req := httptest.NewRequest(http.MethodGet, "/adspots?placement=home_screen&status=inactive", nil)
 	w := httptest.NewRecorder()
@@ -114,10 +125,10 @@ func TestListEligibleActiveAdsByPlacement_InvalidStatus(t *testing.T) {
 
 func TestListEligibleActiveAdsByPlacement_ServiceError(t *testing.T) {
 	mockService := mocks.NewService(t)
-	mockService.On("ListEligibleActiveAdsByPlacement", domain.RideSummary).
+	mockService.On("ListEligibleActiveAdsByPlacement", mock.Anything, domain.RideSummary).
 		Return([]domain.Ad{}, errors.New("database connection failed"))
 
-	handler := NewAdsHandler(zap.NewNop(), mockService)
+	handler := NewAdsHandler(zap.NewNop(), mockService, featureflag.NewStatic())
 
 	req := httptest.NewRequest(http.MethodGet, "/adspots?placement=ride_summary&status=active", nil)
 	w := httptest.NewRecorder()
@@ -132,10 +143,10 @@ func TestListEligibleActiveAdsByPlacement_ServiceError(t *testing.T) {
 
 func TestListEligibleActiveAdsByPlacement_EmptyResult(t *testing.T) {
 	mockService := mocks.NewService(t)
-	mockService.On("ListEligibleActiveAdsByPlacement", domain.MapView).
+	mockService.On("ListEligibleActiveAdsByPlacement", mock.Anything, domain.MapView).
 		Return([]domain.Ad{}, nil)
 
-	handler := NewAdsHandler(zap.NewNop(), mockService)
+	handler := NewAdsHandler(zap.NewNop(), mockService, featureflag.NewStatic())
 
 	req := httptest.NewRequest(http.MethodGet, "/adspots?placement=map_view&status=active", nil)
 	w := httptest.NewRecorder()
@@ -163,10 +174,10 @@ func TestListEligibleActiveAdsByPlacement_WithoutStatusParam(t *testing.T) {
 	}
 
 	mockService := mocks.NewService(t)
-	mockService.On("ListEligibleActiveAdsByPlacement", domain.HomeScreen).
+	mockService.On("ListEligibleActiveAdsByPlacement", mock.Anything, domain.HomeScreen).
 		Return(mockAds, nil)
 
-	handler := NewAdsHandler(zap.NewNop(), mockService)
+	handler := NewAdsHandler(zap.NewNop(), mockService, featureflag.NewStatic())
 
 	req := httptest.NewRequest(http.MethodGet, "/adspots?placement=home_screen", nil)
 	w := httptest.NewRecorder()
@@ -205,14 +216,14 @@ func TestCreateAd_Success(t *testing.T) {
 	}
 
 	mockService := mocks.NewService(t)
-	mockService.On("CreateAd", mock.MatchedBy(func(ad domain.Ad) bool {
+	mockService.On("CreateAd", mock.Anything, mock.MatchedBy(func(ad domain.Ad) bool {
 		return ad.Title == "New Ad" &&
 			ad.ImageUrl == "http://example.com/ad.jpg" &&
 			ad.Placement == domain.HomeScreen &&
 			ad.TTLMinutes == 60
 	})).Return(expectedAd, nil)
 
-	handler := NewAdsHandler(zap.NewNop(), mockService)
+	handler := NewAdsHandler(zap.NewNop(), mockService, featureflag.NewStatic())
 
 	req := httptest.NewRequest(http.MethodPost, "/adposts", bytes.NewBuffer(bodyBytes))
 	req.Header.Set("Content-Type", "application/json")
@@ -232,6 +243,20 @@ func TestCreateAd_Success(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+func TestCreateAd_RejectsUnknownPlacement(t *testing.T) {
+	mockService := mocks.NewService(t)
+	handler := NewAdsHandler(zap.NewNop(), mockService, featureflag.NewStatic())
+
+	body := `{"title":"New Ad","imageUrl":"http://example.com/ad.jpg","placement":"lobby"}`
+	req := httptest.NewRequest(http.MethodPost, "/adposts", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid placement value")
+	mockService.AssertNotCalled(t, "CreateAd")
+}
+
 func TestGetAd_Success(t *testing.T) {
 	expectedAd := domain.Ad{
 		ID:           "123",
@@ -245,9 +270,9 @@ func TestGetAd_Success(t *testing.T) {
 	}
 
 	mockService := mocks.NewService(t)
-	mockService.On("GetAd", "123").Return(expectedAd, nil)
+	mockService.On("GetAd", mock.Anything, "123").Return(expectedAd, nil)
 
-	handler := NewAdsHandler(zap.NewNop(), mockService)
+	handler := NewAdsHandler(zap.NewNop(), mockService, featureflag.NewStatic())
 
 	req := httptest.NewRequest(http.MethodGet, "/adposts/123", nil)
 	w := httptest.NewRecorder()
@@ -280,9 +305,9 @@ func TestDeactivateAd_Success(t *testing.T) {
 	}
 
 	mockService := mocks.NewService(t)
-	mockService.On("DeactivateAd", "456").Return(deactivatedAd, nil)
+	mockService.On("DeactivateAd", mock.Anything, "456").Return(deactivatedAd, nil)
 
-	handler := NewAdsHandler(zap.NewNop(), mockService)
+	handler := NewAdsHandler(zap.NewNop(), mockService, featureflag.NewStatic())
 
 	req := httptest.NewRequest(http.MethodPost, "/adposts/456/deactivate", nil)
 	w := httptest.NewRecorder()
@@ -300,3 +325,209 @@ func TestDeactivateAd_Success(t *testing.T) {
 
 	mockService.AssertExpectations(t)
 }
+
+func TestUpdateAd_Success(t *testing.T) {
+	mockService := mocks.NewService(t)
+	mockService.On("UpdateAd", mock.Anything, "123", mock.MatchedBy(func(u domain.AdUpdate) bool {
+		return u.Title != nil && *u.Title == "Promo 2x1" && u.ImageUrl == nil && u.Placement == nil && u.TTLMinutes == nil
+	})).Return(domain.Ad{ID: "123", Title: "Promo 2x1"}, nil)
+
+	handler := NewAdsHandler(zap.NewNop(), mockService, featureflag.NewStatic())
+
+	req := httptest.NewRequest(http.MethodPatch, "/adposts/123", strings.NewReader(`{"title":"Promo 2x1"}`))
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var ad domain.Ad
+	err := json.NewDecoder(w.Body).Decode(&ad)
+	assert.NoError(t, err)
+	assert.Equal(t, "Promo 2x1", ad.Title)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestUpdateAd_InvalidBody(t *testing.T) {
+	cases := map[string]string{
+		"empty":     `{}`,
+		"title":     `{"title":""}`,
+		"placement": `{"placement":"nowhere"}`,
+		"ttl":       `{"ttlMinutes":-1}`,
+	}
+
+	for name, body := range cases {
+		t.Run(name, func(t *testing.T) {
+			mockService := mocks.NewService(t)
+			handler := NewAdsHandler(zap.NewNop(), mockService, featureflag.NewStatic())
+
+			req := httptest.NewRequest(http.MethodPatch, "/adposts/123", strings.NewReader(body))
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			mockService.AssertNotCalled(t, "UpdateAd")
+		})
+	}
+}
+
+func TestListAds_Success(t *testing.T) {
+	page := domain.AdPage{
+		Ads:        []domain.Ad{{ID: "1", Title: "Summer Sale", Placement: domain.HomeScreen, Status: domain.StatusActive}},
+		NextCursor: "next",
+	}
+
+	mockService := mocks.NewService(t)
+	mockService.On("ListAds", mock.Anything, mock.MatchedBy(func(q domain.AdQuery) bool {
+		return q.Status == domain.StatusActive &&
+			q.Placement == domain.HomeScreen &&
+			q.TitleContains == "sale" &&
+			q.SortBy == domain.SortByTitle &&
+			q.Order == domain.SortAsc &&
+			q.Limit == 10 &&
+			q.After == nil
+	})).Return(page, nil)
+
+	handler := NewAdsHandler(zap.NewNop(), mockService, featureflag.NewStatic())
+
+	req := httptest.NewRequest(http.MethodGet, "/adposts?status=active&placement=home_screen&title=sale&sort=title&order=asc&limit=10", nil)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var got domain.AdPage
+	err := json.NewDecoder(w.Body).Decode(&got)
+	assert.NoError(t, err)
+	assert.Len(t, got.Ads, 1)
+	assert.Equal(t, "next", got.NextCursor)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestListAds_Defaults(t *testing.T) {
+	mockService := mocks.NewService(t)
+	mockService.On("ListAds", mock.Anything, mock.MatchedBy(func(q domain.AdQuery) bool {
+		return q.SortBy == domain.SortByCreatedAt && q.Order == domain.SortDesc && q.Limit == domain.DefaultPageLimit
+	})).Return(domain.AdPage{Ads: []domain.Ad{}}, nil)
+
+	handler := NewAdsHandler(zap.NewNop(), mockService, featureflag.NewStatic())
+
+	req := httptest.NewRequest(http.MethodGet, "/adposts", nil)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestListAds_InvalidParams(t *testing.T) {
+	otherSortCursor := domain.Cursor{SortBy: domain.SortByTitle, Order: domain.SortAsc, Value: "a", ID: "1"}.Encode()
+
+	cases := map[string]string{
+		"status":          "/adposts?status=paused",
+		"placement":       "/adposts?placement=nowhere",
+		"sort":            "/adposts?sort=image_url",
+		"order":           "/adposts?order=up",
+		"limit":           "/adposts?limit=1000",
+		"created_after":   "/adposts?created_after=yesterday",
+		"cursor":          "/adposts?cursor=not-a-cursor",
+		"cursor_mismatch": "/adposts?cursor=" + otherSortCursor,
+	}
+
+	for name, target := range cases {
+		t.Run(name, func(t *testing.T) {
+			mockService := mocks.NewService(t)
+			handler := NewAdsHandler(zap.NewNop(), mockService, featureflag.NewStatic())
+
+			req := httptest.NewRequest(http.MethodGet, target, nil)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			mockService.AssertNotCalled(t, "ListAds")
+		})
+	}
+}
+
+func TestAdsHandler_Permission(t *testing.T) {
+	handler := NewAdsHandler(zap.NewNop(), mocks.NewService(t), featureflag.NewStatic())
+
+	cases := map[string]auth.Permission{
+		"GET /adspots?placement=home_screen": auth.PermServeAds,
+		"GET /adposts/123":                   auth.PermServeAds,
+		"GET /adposts":                       auth.PermReadAds,
+		"POST /adposts":                      auth.PermCreateAds,
+		"POST /adposts/123/deactivate":       auth.PermDeactivateAds,
+		"PATCH /adposts/123":                 auth.PermUpdateAds,
+	}
+
+	for request, expected := range cases {
+		method, target, _ := strings.Cut(request, " ")
+		req := httptest.NewRequest(method, target, nil)
+		assert.Equal(t, expected, handler.Permission(req), request)
+	}
+}
+
+func TestGetAd_ErrorStatuses(t *testing.T) {
+	repo := persistence.NewAdRepository()
+	service := ads_service.NewService(zap.NewNop(), repo, search.NewIndex(), persistence.NewAuditStore(), persistence.NewVersionStore(), metrics.New(), featureflag.NewStatic())
+	created, err := service.CreateAd(context.Background(), domain.Ad{Title: "Ad", ImageUrl: "https://example.com/ad.png", Placement: domain.HomeScreen})
+	require.NoError(t, err)
+	handler := NewAdsHandler(zap.NewNop(), service, featureflag.NewStatic())
+
+	serve := func(ctx context.Context, id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/adposts/"+id, nil).WithContext(ctx)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+
+	assert.Equal(t, http.StatusOK, serve(context.Background(), created.ID).Code)
+	assert.Equal(t, http.StatusNotFound, serve(context.Background(), "missing").Code)
+	assert.Equal(t, http.StatusServiceUnavailable, serve(canceled, created.ID).Code, "a canceled request is not a missing ad")
+	assert.Equal(t, http.StatusGatewayTimeout, serve(expired, created.ID).Code)
+}
+
+func TestUpdateAd_ServiceErrorIsNotNotFound(t *testing.T) {
+	mockService := mocks.NewService(t)
+	mockService.On("UpdateAd", mock.Anything, "123", mock.Anything).
+		Return(domain.Ad{}, errors.New("database connection failed"))
+
+	handler := NewAdsHandler(zap.NewNop(), mockService, featureflag.NewStatic())
+
+	req := httptest.NewRequest(http.MethodPatch, "/adposts/123", strings.NewReader(`{"title":"New"}`))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestCreateAd_UnrecordedChangeIsNotRetried(t *testing.T) {
+	mockService := mocks.NewService(t)
+	mockService.On("CreateAd", mock.Anything, mock.Anything).
+		Return(domain.Ad{}, fmt.Errorf("%w: ad 123: disk full", ads_service.ErrNotRecorded)).Once()
+
+	middleware := NewIdempotency(zap.NewNop(), idempotency.NewMemoryStore(time.Hour))
+	handler := middleware.Middleware(NewAdsHandler(zap.NewNop(), mockService, featureflag.NewStatic()))
+
+	body := `{"title":"Promo","imageUrl":"https://example.com/a.png","placement":"home_screen"}`
+	for range 2 {
+		w := postWithKey(handler, "abc", body)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		var resp errorResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		assert.Equal(t, codeNotRecorded, resp.Error.Code)
+	}
+}
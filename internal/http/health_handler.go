@@ -0,0 +1,54 @@
+package http_server
+
+import (
+	"ads_backend/internal/health"
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// HealthHandler serves the orchestrator's probes. They are mounted ahead of
+// the middleware chain, so they need no credentials and are neither rate
+// limited nor logged.
+type HealthHandler struct {
+	health *health.Health
+}
+
+func NewHealthHandler(health *health.Health) *HealthHandler {
+	return &HealthHandler{health: health}
+}
+
+// NewRateLimitConfigChecker checks that the rate limit policies the server
+// runs with are valid.
+func NewRateLimitConfigChecker(rateLimiter *RateLimiter) health.Checker {
+	return health.NewChecker("rate_limit_config", func(ctx context.Context) error {
+		return rateLimiter.Config().Validate()
+	})
+}
+
+// Live answers as long as the process can serve HTTP at all.
+func (h *HealthHandler) Live(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{"status": "alive"})
+}
+
+// Ready answers 200 only when every checker passes and the server is not
+// shutting down, and 503 with the failing checks otherwise.
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	report := h.health.Ready(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	if !report.Ready() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(report)
+}
+
+// Mount puts the probes in front of handler.
+func (h *HealthHandler) Mount(handler http.Handler) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", h.Live)
+	mux.HandleFunc("GET /readyz", h.Ready)
+	mux.Handle("/", handler)
+	return mux
+}
@@ -0,0 +1,56 @@
+package http_server
+
+import (
+	"ads_backend/internal/health"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serveProbe(handler http.Handler, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	return w
+}
+
+func TestHealthHandler_Probes(t *testing.T) {
+	storeErr := error(nil)
+	h := health.NewHealth([]health.Checker{
+		health.NewChecker("rate_limit_store", func(context.Context) error { return storeErr }),
+	})
+	behind := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	handler := NewHealthHandler(h).Mount(behind)
+
+	assert.Equal(t, http.StatusOK, serveProbe(handler, "/healthz").Code)
+	assert.Equal(t, http.StatusOK, serveProbe(handler, "/readyz").Code)
+	assert.Equal(t, http.StatusUnauthorized, serveProbe(handler, "/adposts").Code, "other paths reach the app")
+
+	storeErr = errors.New("connection refused")
+	w := serveProbe(handler, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	var report health.Report
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&report))
+	assert.Equal(t, health.StatusNotReady, report.Status)
+	assert.Equal(t, "connection refused", report.Checks[0].Error)
+
+	storeErr = nil
+	h.Drain()
+	assert.Equal(t, http.StatusServiceUnavailable, serveProbe(handler, "/readyz").Code)
+	assert.Equal(t, http.StatusOK, serveProbe(handler, "/healthz").Code, "a draining process is still alive")
+}
+
+func TestRateLimitConfigChecker(t *testing.T) {
+	checker := NewRateLimitConfigChecker(NewRateLimiter(10, 20))
+	assert.NoError(t, checker.Check(context.Background()))
+
+	checker = NewRateLimitConfigChecker(NewRateLimiter(0, 20))
+	assert.Error(t, checker.Check(context.Background()))
+}
@@ -0,0 +1,40 @@
+package http_server
+
+import (
+	"ads_backend/internal/ads_service"
+	"ads_backend/internal/auth"
+	"encoding/json"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+// HistoryHandler serves an ad's audit trail, oldest change first.
+type HistoryHandler struct {
+	log     *zap.Logger
+	service ads_service.Service
+}
+
+func NewHistoryHandler(log *zap.Logger, service ads_service.Service) *HistoryHandler {
+	return &HistoryHandler{log: log, service: service}
+}
+
+func (h *HistoryHandler) Pattern() string {
+	return "GET /adposts/{id}/history"
+}
+
+func (h *HistoryHandler) Permission(*http.Request) auth.Permission {
+	return auth.PermReadAds
+}
+
+func (h *HistoryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	records, err := h.service.GetAdHistory(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(records)
+}
@@ -0,0 +1,57 @@
+package http_server
+
+import (
+	"ads_backend/internal/domain"
+	"ads_backend/internal/persistence"
+	"ads_backend/mocks"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func TestHistory_Success(t *testing.T) {
+	records := []domain.AuditRecord{
+		{ID: "r1", AdID: "123", Action: domain.AuditActionCreated, Actor: "api_key:k1"},
+		{ID: "r2", AdID: "123", Action: domain.AuditActionDeactivated, Actor: "jwt:u1"},
+	}
+
+	mockService := mocks.NewService(t)
+	mockService.On("GetAdHistory", mock.Anything, "123").Return(records, nil)
+
+	handler := NewHistoryHandler(zap.NewNop(), mockService)
+
+	req := httptest.NewRequest(http.MethodGet, "/adposts/123/history", nil)
+	req.SetPathValue("id", "123")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var got []domain.AuditRecord
+	err := json.NewDecoder(w.Body).Decode(&got)
+	assert.NoError(t, err)
+	assert.Equal(t, records, got)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestHistory_NotFound(t *testing.T) {
+	mockService := mocks.NewService(t)
+	mockService.On("GetAdHistory", mock.Anything, "missing").Return([]domain.AuditRecord{}, persistence.ErrAdNotFound)
+
+	handler := NewHistoryHandler(zap.NewNop(), mockService)
+
+	req := httptest.NewRequest(http.MethodGet, "/adposts/missing/history", nil)
+	req.SetPathValue("id", "missing")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,155 @@
+package http_server
+
+import (
+	"ads_backend/internal/auth"
+	"ads_backend/internal/idempotency"
+	"ads_backend/internal/logging"
+	"ads_backend/internal/tenant"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+const (
+	idempotencyKeyHeader = "Idempotency-Key"
+	// idempotentPath is the only path whose POSTs are stored. Other routes,
+	// like POST /admin/apikeys which answers with a secret, are never
+	// replayed.
+	idempotentPath = "/adposts"
+)
+
+// replayedHeaders are the response headers stored with an idempotent
+// response. They are the ones the ads handler sets itself; headers set
+// around it, such as RateLimit-*, Retry-After or the correlation and trace
+// IDs, describe the current request and are never replayed.
+var replayedHeaders = []string{"Content-Type", "Location"}
+
+// Idempotency makes POST /adposts requests that carry an Idempotency-Key
+// safe to retry: the first final response is stored and replayed for later
+// requests with the same key and body.
+type Idempotency struct {
+	log   *zap.Logger
+	store idempotency.Store
+}
+
+func NewIdempotency(log *zap.Logger, store idempotency.Store) *Idempotency {
+	return &Idempotency{log: log, store: store}
+}
+
+func (i *Idempotency) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if r.Method != http.MethodPost || r.URL.Path != idempotentPath || key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		// Keys are per tenant and caller, so two clients picking the same
+		// key never see each other's responses.
+		key = tenant.ID(r.Context()) + ":" + key
+		if principal, ok := auth.PrincipalFromContext(r.Context()); ok {
+			key = principal.Subject + ":" + key
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxImportBytes))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		sum := sha256.Sum256(append([]byte(r.Method+" "+r.URL.RequestURI()+"\n"), body...))
+		fingerprint := hex.EncodeToString(sum[:])
+
+		record, err := i.store.Reserve(key, fingerprint)
+		switch {
+		case errors.Is(err, idempotency.ErrFingerprintMismatch):
+			writeError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		case errors.Is(err, idempotency.ErrKeyInFlight):
+			writeError(w, http.StatusConflict, err.Error())
+			return
+		case err != nil:
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		case record != nil:
+			for name, values := range record.Header {
+				w.Header()[name] = values
+			}
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(record.StatusCode)
+			_, _ = w.Write(record.Body)
+			return
+		}
+
+		recorder := &recordingWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		if !storable(recorder.statusCode, recorder.body.Bytes()) {
+			if err := i.store.Release(key); err != nil {
+				logging.For(r.Context(), i.log).Error("failed to release idempotency key", zap.Error(err))
+			}
+			return
+		}
+
+		header := make(http.Header)
+		for _, name := range replayedHeaders {
+			if values := recorder.Header().Values(name); len(values) > 0 {
+				header[name] = values
+			}
+		}
+		err = i.store.Complete(key, idempotency.Record{
+			Fingerprint: fingerprint,
+			StatusCode:  recorder.statusCode,
+			Header:      header,
+			Body:        recorder.body.Bytes(),
+		})
+		if err != nil {
+			logging.For(r.Context(), i.log).Error("failed to store idempotent response", zap.Error(err))
+		}
+	})
+}
+
+// storable reports whether a response is final for its request: a success,
+// a validation error that the same body always gets, or a write that was
+// saved but not recorded. Anything else, such as another server error, an
+// auth failure or a 429, is released so the client's retry runs again.
+func storable(statusCode int, body []byte) bool {
+	switch {
+	case statusCode >= 200 && statusCode < 300:
+		return true
+	case statusCode == http.StatusBadRequest, statusCode == http.StatusUnprocessableEntity:
+		return true
+	case statusCode == http.StatusInternalServerError:
+		var resp errorResponse
+		return json.Unmarshal(body, &resp) == nil && resp.Error.Code == codeNotRecorded
+	default:
+		return false
+	}
+}
+
+// recordingWriter passes the response through while keeping a copy of it.
+type recordingWriter struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (rw *recordingWriter) WriteHeader(statusCode int) {
+	rw.statusCode = statusCode
+	rw.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rw *recordingWriter) Write(b []byte) (int, error) {
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
+
+func (rw *recordingWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
@@ -0,0 +1,153 @@
+package http_server
+
+import (
+	"ads_backend/internal/idempotency"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func newCountingHandler(status int, calls *int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"id":"generated"}`))
+	})
+}
+
+func postWithKey(handler http.Handler, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/adposts", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(idempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotency_ReplaysFirstResponse(t *testing.T) {
+	calls := 0
+	middleware := NewIdempotency(zap.NewNop(), idempotency.NewMemoryStore(time.Hour))
+	handler := middleware.Middleware(newCountingHandler(http.StatusCreated, &calls))
+
+	first := postWithKey(handler, "abc", `{"title":"Ad"}`)
+	second := postWithKey(handler, "abc", `{"title":"Ad"}`)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "application/json", second.Header().Get("Content-Type"))
+	assert.Equal(t, "true", second.Header().Get("Idempotent-Replayed"))
+}
+
+func TestIdempotency_DifferentBodyReturns422(t *testing.T) {
+	calls := 0
+	middleware := NewIdempotency(zap.NewNop(), idempotency.NewMemoryStore(time.Hour))
+	handler := middleware.Middleware(newCountingHandler(http.StatusCreated, &calls))
+
+	postWithKey(handler, "abc", `{"title":"Ad"}`)
+	w := postWithKey(handler, "abc", `{"title":"Other"}`)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+}
+
+func TestIdempotency_ServerErrorsAreNotStored(t *testing.T) {
+	calls := 0
+	middleware := NewIdempotency(zap.NewNop(), idempotency.NewMemoryStore(time.Hour))
+	handler := middleware.Middleware(newCountingHandler(http.StatusInternalServerError, &calls))
+
+	postWithKey(handler, "abc", `{"title":"Ad"}`)
+	postWithKey(handler, "abc", `{"title":"Ad"}`)
+
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotency_RequestsWithoutKeyPassThrough(t *testing.T) {
+	calls := 0
+	middleware := NewIdempotency(zap.NewNop(), idempotency.NewMemoryStore(time.Hour))
+	handler := middleware.Middleware(newCountingHandler(http.StatusCreated, &calls))
+
+	postWithKey(handler, "", `{"title":"Ad"}`)
+	postWithKey(handler, "", `{"title":"Ad"}`)
+
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotency_OnlyFinalResponsesAreStored(t *testing.T) {
+	statuses := map[int]bool{
+		http.StatusCreated:             true,
+		http.StatusBadRequest:          true,
+		http.StatusUnprocessableEntity: true,
+		http.StatusUnauthorized:        false,
+		http.StatusForbidden:           false,
+		http.StatusConflict:            false,
+		http.StatusTooManyRequests:     false,
+		http.StatusServiceUnavailable:  false,
+	}
+	for status, stored := range statuses {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			calls := 0
+			middleware := NewIdempotency(zap.NewNop(), idempotency.NewMemoryStore(time.Hour))
+			handler := middleware.Middleware(newCountingHandler(status, &calls))
+
+			postWithKey(handler, "abc", `{"title":"Ad"}`)
+			w := postWithKey(handler, "abc", `{"title":"Ad"}`)
+
+			assert.Equal(t, status, w.Code)
+			if stored {
+				assert.Equal(t, 1, calls)
+				assert.Equal(t, "true", w.Header().Get("Idempotent-Replayed"))
+			} else {
+				assert.Equal(t, 2, calls)
+				assert.Empty(t, w.Header().Get("Idempotent-Replayed"))
+			}
+		})
+	}
+}
+
+func TestIdempotency_OnlyCreatingAdsIsStored(t *testing.T) {
+	calls := 0
+	middleware := NewIdempotency(zap.NewNop(), idempotency.NewMemoryStore(time.Hour))
+	handler := middleware.Middleware(newCountingHandler(http.StatusCreated, &calls))
+
+	for _, target := range []string{"/admin/apikeys", "/adposts/import", "/adposts/abc/deactivate"} {
+		for i := 0; i < 2; i++ {
+			req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(`{"name":"ci"}`))
+			req.Header.Set(idempotencyKeyHeader, "abc")
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			assert.Empty(t, w.Header().Get("Idempotent-Replayed"), target)
+		}
+	}
+	assert.Equal(t, 6, calls)
+}
+
+func TestIdempotency_ReplaysOnlyTheHandlersHeaders(t *testing.T) {
+	calls := 0
+	middleware := NewIdempotency(zap.NewNop(), idempotency.NewMemoryStore(time.Hour))
+	inner := middleware.Middleware(newCountingHandler(http.StatusCreated, &calls))
+	remaining := 10
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		remaining--
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(remaining))
+		w.Header().Set("X-Correlation-ID", "req-"+strconv.Itoa(remaining))
+		inner.ServeHTTP(w, r)
+	})
+
+	postWithKey(handler, "abc", `{"title":"Ad"}`)
+	w := postWithKey(handler, "abc", `{"title":"Ad"}`)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, "true", w.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, "8", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "req-8", w.Header().Get("X-Correlation-ID"))
+}
@@ -0,0 +1,218 @@
+package http_server
+
+import (
+	"ads_backend/internal/ads_service"
+	"ads_backend/internal/auth"
+	"ads_backend/internal/domain"
+	"ads_backend/internal/logging"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+const (
+	exportPath = "/adposts/export"
+
+	exportFlushEvery = domain.MaxPageLimit
+
+	maxImportRows  = 10000
+	maxImportBytes = 10 << 20
+)
+
+// ExportHandler streams every matching ad from a single pass over the
+// repository, flushing every exportFlushEvery ads so the response is never
+// buffered whole.
+type ExportHandler struct {
+	log     *zap.Logger
+	service ads_service.Service
+}
+
+func NewExportHandler(log *zap.Logger, service ads_service.Service) *ExportHandler {
+	return &ExportHandler{log: log, service: service}
+}
+
+func (h *ExportHandler) Pattern() string {
+	return "GET " + exportPath
+}
+
+func (h *ExportHandler) Permission(*http.Request) auth.Permission {
+	return auth.PermReadAds
+}
+
+func (h *ExportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = formatCSV
+	}
+	if format != formatCSV && format != formatNDJSON {
+		writeError(w, http.StatusBadRequest, "format must be 'csv' or 'ndjson'")
+		return
+	}
+
+	query, err := parseListAdsQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	query.After = nil
+
+	// The headers go out with the first ad, so an export that fails before
+	// writing anything still gets a proper error status.
+	log := logging.For(r.Context(), h.log)
+	var enc adEncoder
+	started := false
+	flusher := http.NewResponseController(w)
+	start := func() error {
+		started = true
+		w.Header().Set("Content-Type", contentTypeForFormat(format))
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="ads.%s"`, format))
+		w.WriteHeader(http.StatusOK)
+		var err error
+		enc, err = newAdEncoder(w, format)
+		return err
+	}
+	flush := func() error {
+		if err := enc.Flush(); err != nil {
+			return err
+		}
+		_ = flusher.Flush()
+		return nil
+	}
+
+	written := 0
+	err = h.service.ExportAds(r.Context(), query, func(ad domain.Ad) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		if err := enc.Encode(ad); err != nil {
+			return err
+		}
+		if written++; written%exportFlushEvery == 0 {
+			return flush()
+		}
+		return nil
+	})
+	switch {
+	case err != nil && !started:
+		writeServiceError(w, err)
+		return
+	case err != nil:
+		log.Error("export aborted", zap.Error(err))
+		return
+	case !started:
+		err = start()
+	}
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		log.Error("export aborted", zap.Error(err))
+	}
+}
+
+type importRowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+type importResponse struct {
+	DryRun  bool             `json:"dry_run"`
+	Rows    int              `json:"rows"`
+	Created int              `json:"created"`
+	IDs     []string         `json:"ids,omitempty"`
+	Errors  []importRowError `json:"errors,omitempty"`
+}
+
+// ImportHandler creates ads from an uploaded CSV or NDJSON file. Imports are
+// all-or-nothing: any invalid row rejects the file with row-numbered errors,
+// and dry_run=true validates without writing.
+type ImportHandler struct {
+	log     *zap.Logger
+	service ads_service.Service
+}
+
+func NewImportHandler(log *zap.Logger, service ads_service.Service) *ImportHandler {
+	return &ImportHandler{log: log, service: service}
+}
+
+func (h *ImportHandler) Pattern() string {
+	return "POST /adposts/import"
+}
+
+func (h *ImportHandler) Permission(*http.Request) auth.Permission {
+	return auth.PermCreateAds
+}
+
+func (h *ImportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = formatFromContentType(r.Header.Get("Content-Type"))
+	}
+	if format != formatCSV && format != formatNDJSON {
+		writeError(w, http.StatusBadRequest, "format must be 'csv' or 'ndjson'")
+		return
+	}
+
+	resp := importResponse{DryRun: r.URL.Query().Get("dry_run") == "true"}
+	ads := make([]domain.Ad, 0)
+
+	body := http.MaxBytesReader(w, r.Body, maxImportBytes)
+	err := decodeImport(body, format, func(row int, req createAdRequest, err error) {
+		resp.Rows++
+		if err == nil {
+			err = req.Validate()
+		}
+		if err != nil {
+			resp.Errors = append(resp.Errors, importRowError{Row: row, Error: err.Error()})
+			return
+		}
+		ads = append(ads, req.toAd())
+	})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if resp.Rows == 0 {
+		writeError(w, http.StatusBadRequest, "import contains no rows")
+		return
+	}
+	if resp.Rows > maxImportRows {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("import must contain at most %d rows", maxImportRows))
+		return
+	}
+	if len(resp.Errors) > 0 {
+		h.writeResponse(w, http.StatusUnprocessableEntity, resp)
+		return
+	}
+	if resp.DryRun {
+		h.writeResponse(w, http.StatusOK, resp)
+		return
+	}
+
+	results, err := h.service.CreateAds(r.Context(), ads, true)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	for _, result := range results {
+		if result.Failed() {
+			writeError(w, http.StatusInternalServerError, result.Error)
+			return
+		}
+		resp.IDs = append(resp.IDs, result.ID)
+	}
+	resp.Created = len(resp.IDs)
+
+	h.writeResponse(w, http.StatusCreated, resp)
+}
+
+func (h *ImportHandler) writeResponse(w http.ResponseWriter, status int, resp importResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,252 @@
+package http_server
+
+import (
+	"ads_backend/internal/domain"
+	"ads_backend/mocks"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// onExportAds makes service stream ads to the export callback for queries
+// matching query.
+func onExportAds(service *mocks.Service, query any, ads ...domain.Ad) *mock.Call {
+	return service.On("ExportAds", mock.Anything, query, mock.Anything).
+		Run(func(args mock.Arguments) {
+			fn := args.Get(2).(func(domain.Ad) error)
+			for _, ad := range ads {
+				if fn(ad) != nil {
+					return
+				}
+			}
+		}).
+		Return(nil)
+}
+
+func TestExport_StreamsEveryAdAsCSV(t *testing.T) {
+	created := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+	first := domain.Ad{ID: "1", Title: "Uno", ImageUrl: "http://example.com/1.jpg", Placement: domain.HomeScreen, Status: domain.StatusActive, CreatedAt: created}
+	second := domain.Ad{ID: "2", Title: "Dos, con coma", ImageUrl: "http://example.com/2.jpg", Placement: domain.MapView, Status: domain.StatusInactive, CreatedAt: created, DeactivateAt: created}
+
+	mockService := mocks.NewService(t)
+	onExportAds(mockService, mock.MatchedBy(func(q domain.AdQuery) bool {
+		return q.After == nil && q.Status == domain.StatusActive
+	}), first, second).Once()
+
+	handler := NewExportHandler(zap.NewNop(), mockService)
+
+	req := httptest.NewRequest(http.MethodGet, "/adposts/export?format=csv&status=active", nil)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+
+	records, err := csv.NewReader(w.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, exportColumns, records[0])
+	assert.Equal(t, []string{"1", "Uno", "http://example.com/1.jpg", "home_screen", "active", "2025-10-01T12:00:00Z", "", "0"}, records[1])
+	assert.Equal(t, "Dos, con coma", records[2][1])
+
+	mockService.AssertExpectations(t)
+}
+
+func TestExport_EscapesFormulasInCSV(t *testing.T) {
+	titles := []string{"=HYPERLINK(\"http://evil.example\")", "+1 ride", "-20% off", "@home", "\t=1+2", "\r=1+2", "Plain - title"}
+	var ads []domain.Ad
+	for i, title := range titles {
+		ads = append(ads, domain.Ad{ID: strconv.Itoa(i), Title: title, ImageUrl: "http://example.com/a.jpg", Placement: domain.HomeScreen})
+	}
+
+	mockService := mocks.NewService(t)
+	onExportAds(mockService, mock.Anything, ads...)
+
+	w := httptest.NewRecorder()
+	NewExportHandler(zap.NewNop(), mockService).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/adposts/export", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	exported := w.Body.String()
+
+	records, err := csv.NewReader(strings.NewReader(exported)).ReadAll()
+	require.NoError(t, err)
+	var got []string
+	for _, record := range records[1:] {
+		got = append(got, record[1])
+	}
+	assert.Equal(t, []string{"'=HYPERLINK(\"http://evil.example\")", "'+1 ride", "'-20% off", "'@home", "'\t=1+2", "'\r=1+2", "Plain - title"}, got)
+
+	var imported []string
+	require.NoError(t, decodeImport(strings.NewReader(exported), formatCSV, func(row int, req createAdRequest, err error) {
+		require.NoError(t, err)
+		imported = append(imported, req.Title)
+	}))
+	assert.Equal(t, titles, imported, "importing an export gives back the original titles")
+}
+
+func TestExport_NDJSON(t *testing.T) {
+	mockService := mocks.NewService(t)
+	onExportAds(mockService, mock.Anything, domain.Ad{ID: "1"}, domain.Ad{ID: "2"})
+
+	handler := NewExportHandler(zap.NewNop(), mockService)
+
+	req := httptest.NewRequest(http.MethodGet, "/adposts/export?format=ndjson", nil)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	assert.Len(t, lines, 2)
+}
+
+func TestExport_ImportsBackInEitherFormat(t *testing.T) {
+	ads := []domain.Ad{
+		{ID: "1", Title: "Uno", ImageUrl: "http://example.com/1.jpg", Placement: domain.HomeScreen, Status: domain.StatusActive, TTLMinutes: 30},
+		{ID: "2", Title: "Dos", ImageUrl: "http://example.com/2.jpg", Placement: domain.MapView, Status: domain.StatusActive},
+	}
+
+	for _, format := range []string{formatCSV, formatNDJSON} {
+		t.Run(format, func(t *testing.T) {
+			mockService := mocks.NewService(t)
+			onExportAds(mockService, mock.Anything, ads...)
+			var imported []domain.Ad
+			mockService.On("CreateAds", mock.Anything, mock.Anything, true).
+				Run(func(args mock.Arguments) { imported = args.Get(1).([]domain.Ad) }).
+				Return([]domain.BatchResult{{Index: 0, ID: "a"}, {Index: 1, ID: "b"}}, nil)
+
+			w := httptest.NewRecorder()
+			NewExportHandler(zap.NewNop(), mockService).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/adposts/export?format="+format, nil))
+			require.Equal(t, http.StatusOK, w.Code)
+
+			w, resp := serveImport(NewImportHandler(zap.NewNop(), mockService), "/adposts/import", w.Header().Get("Content-Type"), w.Body.String())
+			require.Equal(t, http.StatusCreated, w.Code, resp.Errors)
+
+			want := []domain.Ad{
+				{Title: "Uno", ImageUrl: "http://example.com/1.jpg", Placement: domain.HomeScreen, TTLMinutes: 30},
+				{Title: "Dos", ImageUrl: "http://example.com/2.jpg", Placement: domain.MapView},
+			}
+			assert.Equal(t, want, imported)
+		})
+	}
+}
+
+func TestExport_ErrorBeforeAnyAdIsReported(t *testing.T) {
+	mockService := mocks.NewService(t)
+	mockService.On("ExportAds", mock.Anything, mock.Anything, mock.Anything).Return(context.DeadlineExceeded)
+
+	w := httptest.NewRecorder()
+	NewExportHandler(zap.NewNop(), mockService).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/adposts/export", nil))
+
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	assert.Empty(t, w.Header().Get("Content-Disposition"))
+}
+
+func TestExport_EmptyCSVHasTheHeader(t *testing.T) {
+	mockService := mocks.NewService(t)
+	onExportAds(mockService, mock.Anything)
+
+	w := httptest.NewRecorder()
+	NewExportHandler(zap.NewNop(), mockService).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/adposts/export", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	records, err := csv.NewReader(w.Body).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{exportColumns}, records)
+}
+
+func serveImport(handler *ImportHandler, target, contentType, body string) (*httptest.ResponseRecorder, importResponse) {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	var resp importResponse
+	_ = json.NewDecoder(w.Body).Decode(&resp)
+	return w, resp
+}
+
+func TestImport_ReportsRowNumberedErrors(t *testing.T) {
+	mockService := mocks.NewService(t)
+
+	handler := NewImportHandler(zap.NewNop(), mockService)
+
+	body := "title,image_url,placement,ttl_minutes\n" +
+		"Valid,http://example.com/a.jpg,home_screen,30\n" +
+		",http://example.com/b.jpg,home_screen,\n" +
+		"Bad TTL,http://example.com/c.jpg,map_view,soon\n" +
+		"Bad placement,http://example.com/d.jpg,lobby,\n"
+
+	w, resp := serveImport(handler, "/adposts/import", "text/csv", body)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, 4, resp.Rows)
+	assert.Equal(t, []importRowError{
+		{Row: 3, Error: "title is required"},
+		{Row: 4, Error: "ttl_minutes must be an integer"},
+		{Row: 5, Error: "invalid placement value"},
+	}, resp.Errors)
+
+	mockService.AssertNotCalled(t, "CreateAds")
+}
+
+func TestImport_DryRunWritesNothing(t *testing.T) {
+	mockService := mocks.NewService(t)
+
+	handler := NewImportHandler(zap.NewNop(), mockService)
+
+	body := `{"title":"Uno","imageUrl":"http://example.com/1.jpg","placement":"home_screen"}` + "\n"
+
+	w, resp := serveImport(handler, "/adposts/import?dry_run=true", "application/x-ndjson", body)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, resp.DryRun)
+	assert.Equal(t, 1, resp.Rows)
+	assert.Equal(t, 0, resp.Created)
+
+	mockService.AssertNotCalled(t, "CreateAds")
+}
+
+func TestImport_CreatesAllRowsAtomically(t *testing.T) {
+	mockService := mocks.NewService(t)
+	mockService.On("CreateAds", mock.Anything, mock.MatchedBy(func(ads []domain.Ad) bool {
+		return len(ads) == 2 && ads[0].Title == "Uno" && ads[1].TTLMinutes == 15
+	}), true).Return([]domain.BatchResult{{Index: 0, ID: "a"}, {Index: 1, ID: "b"}}, nil)
+
+	handler := NewImportHandler(zap.NewNop(), mockService)
+
+	body := `{"title":"Uno","imageUrl":"http://example.com/1.jpg","placement":"home_screen"}` + "\n\n" +
+		`{"title":"Dos","imageUrl":"http://example.com/2.jpg","placement":"ride_summary","ttlMinutes":15}` + "\n"
+
+	w, resp := serveImport(handler, "/adposts/import?format=ndjson", "application/octet-stream", body)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, 2, resp.Created)
+	assert.Equal(t, []string{"a", "b"}, resp.IDs)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestImport_RejectsMissingColumnsAndUnknownFormat(t *testing.T) {
+	mockService := mocks.NewService(t)
+
+	handler := NewImportHandler(zap.NewNop(), mockService)
+
+	w, _ := serveImport(handler, "/adposts/import", "text/csv", "title,placement\nUno,home_screen\n")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w, _ = serveImport(handler, "/adposts/import", "application/xml", "<ads/>")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,62 @@
+package http_server
+
+import (
+	"ads_backend/internal/auth"
+	"ads_backend/internal/logging"
+	"encoding/json"
+	"net/http"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type logLevel struct {
+	Level string `json:"level"`
+}
+
+type LogLevelHandler struct {
+	log   *zap.Logger
+	level zap.AtomicLevel
+}
+
+// NewLogLevelRoutes exposes the admin endpoints that read and change the
+// log level of the running process. The change is not persisted; a restart
+// goes back to the configured level.
+func NewLogLevelRoutes(log *zap.Logger, level zap.AtomicLevel) []Route {
+	h := &LogLevelHandler{log: log, level: level}
+	return []Route{
+		newRoute("GET /admin/log-level", auth.PermSystem, h.get),
+		newRoute("PUT /admin/log-level", auth.PermSystem, h.set),
+	}
+}
+
+func (h *LogLevelHandler) get(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(logLevel{Level: h.level.String()})
+}
+
+func (h *LogLevelHandler) set(w http.ResponseWriter, r *http.Request) {
+	var req logLevel
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	level, err := zapcore.ParseLevel(req.Level)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	previous := h.level.Level()
+	h.level.SetLevel(level)
+
+	changer, _ := auth.PrincipalFromContext(r.Context())
+	logging.For(r.Context(), h.log).Warn("Log level changed",
+		zap.Stringer("from", previous),
+		zap.Stringer("to", level),
+		zap.String("changed_by", changer.Subject),
+	)
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(logLevel{Level: level.String()})
+}
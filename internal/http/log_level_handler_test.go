@@ -0,0 +1,48 @@
+package http_server
+
+import (
+	"ads_backend/internal/auth"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLogLevelRoutes(t *testing.T) {
+	keys := auth.NewKeyService(auth.NewKeyStore())
+	admin, _, err := keys.Issue("ops", auth.RoleAdmin, "")
+	require.NoError(t, err)
+	editor, _, err := keys.Issue("panel", auth.RoleEditor, "")
+	require.NoError(t, err)
+
+	level := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	authenticator := NewAuthenticator(zap.NewNop(), keys, auth.NewTokenVerifierFromConfig(auth.JWTConfig{}))
+	mux := NewServeMux(NewLogLevelRoutes(zap.NewNop(), level), authenticator)
+	handler := NewAPIHandler(mux, authenticator, NewRateLimiter(100, 100), nil)
+
+	serve := func(method, body, apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/admin/log-level", strings.NewReader(body))
+		req.Header.Set(apiKeyHeader, apiKey)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(http.MethodGet, "", admin)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"level":"info"}`, w.Body.String())
+
+	w = serve(http.MethodPut, `{"level":"debug"}`, admin)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"level":"debug"}`, w.Body.String())
+	assert.Equal(t, zapcore.DebugLevel, level.Level())
+
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPut, `{"level":"verbose"}`, admin).Code)
+	assert.Equal(t, http.StatusForbidden, serve(http.MethodPut, `{"level":"error"}`, editor).Code)
+	assert.Equal(t, zapcore.DebugLevel, level.Level())
+}
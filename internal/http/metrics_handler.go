@@ -0,0 +1,51 @@
+package http_server
+
+import (
+	"ads_backend/internal/auth"
+	"ads_backend/internal/metrics"
+	"net/http"
+	"time"
+)
+
+// NewMetricsRoute serves the Prometheus metrics to admins without a tenant.
+// Scrapers can send such an admin API key as a bearer token.
+func NewMetricsRoute(m *metrics.Metrics) Route {
+	return newRoute("GET /metrics", auth.PermSystem, m.Handler().ServeHTTP)
+}
+
+// RequestMetrics counts and times every request by the route that served
+// it, including those rejected before reaching it.
+type RequestMetrics struct {
+	metrics *metrics.Metrics
+	mux     *http.ServeMux
+}
+
+func NewRequestMetrics(m *metrics.Metrics, mux *http.ServeMux) *RequestMetrics {
+	return &RequestMetrics{metrics: m, mux: mux}
+}
+
+func (rm *RequestMetrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		_, pattern := rm.mux.Handler(r)
+		route := routeLabel(pattern, r)
+
+		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(wrapped, r)
+
+		rm.metrics.ObserveRequest(route, wrapped.statusCode, time.Since(start))
+	})
+}
+
+// routeLabel names the route that serves r for metrics, given the mux
+// pattern it matched. Patterns keep ad IDs out of the label; AdsHandler
+// matches everything under "/" and is labelled by adsRouteLabel instead.
+func routeLabel(pattern string, r *http.Request) string {
+	switch pattern {
+	case "":
+		return "unmatched"
+	case "/":
+		return adsRouteLabel(r)
+	}
+	return pattern
+}
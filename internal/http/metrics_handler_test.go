@@ -0,0 +1,77 @@
+package http_server
+
+import (
+	"ads_backend/internal/auth"
+	"ads_backend/internal/metrics"
+	"ads_backend/internal/ratelimit"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRouteLabel(t *testing.T) {
+	tests := []struct {
+		method, path, pattern, want string
+	}{
+		{http.MethodGet, "/adposts/3f2a/history", "GET /adposts/{id}/history", "GET /adposts/{id}/history"},
+		{http.MethodGet, "/adposts/3f2a", "/", "GET /adposts/{id}"},
+		{http.MethodPatch, "/adposts/3f2a", "/", "PATCH /adposts/{id}"},
+		{http.MethodPost, "/adposts/3f2a/deactivate", "/", "POST /adposts/{id}/deactivate"},
+		{http.MethodPost, "/adposts", "/", "POST /adposts"},
+		{http.MethodGet, "/adspots", "/", "GET /adspots"},
+		{http.MethodGet, "/adposts/3f2a/unknown", "/", "unmatched"},
+		{http.MethodDelete, "/adposts/3f2a", "/", "unmatched"},
+		{http.MethodGet, "/wp-login.php", "/", "unmatched"},
+		{http.MethodGet, "/metrics", "", "unmatched"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			assert.Equal(t, tt.want, routeLabel(tt.pattern, r))
+		})
+	}
+}
+
+func TestRequestMetrics_CountsRequestsAndRejections(t *testing.T) {
+	m := metrics.New()
+	keys := auth.NewKeyService(auth.NewKeyStore())
+	admin, _, err := keys.Issue("prometheus", auth.RoleAdmin, "")
+	require.NoError(t, err)
+
+	rl, err := NewRateLimiterFromConfig(RateLimitConfig{
+		Default: RateLimitPolicy{RequestsPerSecond: 1, Burst: 1},
+		Routes:  map[auth.Permission]RateLimitPolicy{auth.PermAdmin: {RequestsPerSecond: 1, Burst: 10}},
+	}, ratelimit.NewMemoryStore(time.Minute), m, zap.NewNop())
+	require.NoError(t, err)
+
+	authenticator := NewAuthenticator(zap.NewNop(), keys, auth.NewTokenVerifierFromConfig(auth.JWTConfig{}))
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	mux := NewServeMux([]Route{
+		newRoute("GET /adposts/{id}/history", auth.PermServeAds, ok),
+		NewMetricsRoute(m),
+	}, authenticator)
+	handler := NewRequestMetrics(m, mux).Middleware(NewAPIHandler(mux, authenticator, rl, nil))
+
+	for _, id := range []string{"a", "b", "c"} {
+		serveFrom(handler, http.MethodGet, "/adposts/"+id+"/history", "10.0.0.1:1234", "")
+	}
+	assert.Equal(t, http.StatusUnauthorized, serveFrom(handler, http.MethodGet, "/metrics", "10.0.0.1:1234", ""))
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer "+admin)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	body := w.Body.String()
+	assert.Contains(t, body, `http_requests_total{route="GET /adposts/{id}/history",status="200"} 1`)
+	assert.Contains(t, body, `http_requests_total{route="GET /adposts/{id}/history",status="429"} 2`)
+	assert.Contains(t, body, `http_requests_total{route="GET /metrics",status="401"} 1`)
+	assert.Contains(t, body, `rate_limit_rejections_total{route="GET /adposts/{id}/history"} 2`)
+	assert.NotContains(t, body, `/adposts/a/`)
+}
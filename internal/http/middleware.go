@@ -1,73 +1,17 @@
 package http_server
 
 import (
+	"ads_backend/internal/correlation"
 	"context"
-	"net"
 	"net/http"
-	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
-	"golang.org/x/time/rate"
 )
 
-type visitor struct {
-	limiter  *rate.Limiter
-	lastSeen time.Time
-}
-
-type RateLimiter struct {
-	visitors map[string]*visitor
-	mu       sync.Mutex
-	rate     rate.Limit
-	burst    int
-}
-
-func NewRateLimiter(requestsPerSecond int, burst int) *RateLimiter {
-	return &RateLimiter{
-		visitors: make(map[string]*visitor),
-		rate:     rate.Limit(requestsPerSecond),
-		burst:    burst,
-	}
-}
-
-func (rl *RateLimiter) getVisitor(ip string) *rate.Limiter {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
-
-	v, exists := rl.visitors[ip]
-	if !exists {
-		limiter := rate.NewLimiter(rl.rate, rl.burst)
-		rl.visitors[ip] = &visitor{limiter: limiter, lastSeen: time.Now()}
-		return limiter
-	}
-
-	v.lastSeen = time.Now()
-	return v.limiter
-}
-
-func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ip, _, err := net.SplitHostPort(r.RemoteAddr)
-		if err != nil {
-			ip = r.RemoteAddr
-		}
-
-		limiter := rl.getVisitor(ip)
-		if !limiter.Allow() {
-			http.Error(w, "Too many requests", http.StatusTooManyRequests)
-			return
-		}
-
-		next.ServeHTTP(w, r)
-	})
-}
-
-type contextKey string
-
-const correlationIDKey contextKey = "correlation-id"
-
 type RequestLogger struct {
 	log *zap.Logger
 }
@@ -80,23 +24,33 @@ func (rl *RequestLogger) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
+		// Without a correlation ID from the caller, the trace ID is used, so
+		// either one leads to the other. The span records it regardless.
+		span := trace.SpanFromContext(r.Context())
+		traceID := span.SpanContext().TraceID()
 		correlationID := r.Header.Get("X-Correlation-ID")
+		if correlationID == "" && traceID.IsValid() {
+			correlationID = traceID.String()
+		}
 		if correlationID == "" {
 			correlationID = uuid.New().String()
 		}
+		span.SetAttributes(attribute.String("correlation_id", correlationID))
 
 		w.Header().Set("X-Correlation-ID", correlationID)
 
-		ctx := context.WithValue(r.Context(), correlationIDKey, correlationID)
+		ctx := correlation.WithID(r.Context(), correlationID)
 		r = r.WithContext(ctx)
 
 		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
 
 		rl.log.Info("HTTP request started",
 			zap.String("correlation_id", correlationID),
+			zap.String("trace_id", traceID.String()),
 			zap.String("method", r.Method),
 			zap.String("path", r.URL.Path),
 			zap.String("remote_addr", r.RemoteAddr),
+			zap.String("client_ip", clientIP(r)),
 			zap.String("user_agent", r.UserAgent()),
 		)
 
@@ -105,6 +59,7 @@ func (rl *RequestLogger) Middleware(next http.Handler) http.Handler {
 		duration := time.Since(start)
 		rl.log.Info("HTTP request completed",
 			zap.String("correlation_id", correlationID),
+			zap.String("trace_id", traceID.String()),
 			zap.String("method", r.Method),
 			zap.String("path", r.URL.Path),
 			zap.Int("status", wrapped.statusCode),
@@ -124,9 +79,12 @@ func (rw *responseWriter) WriteHeader(statusCode int) {
 	rw.ResponseWriter.WriteHeader(statusCode)
 }
 
+// Unwrap lets http.ResponseController reach the underlying writer, so
+// streaming handlers can still flush through the logger.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 func GetCorrelationID(ctx context.Context) string {
-	if id, ok := ctx.Value(correlationIDKey).(string); ok {
-		return id
-	}
-	return ""
+	return correlation.ID(ctx)
 }
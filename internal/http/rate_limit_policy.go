@@ -0,0 +1,134 @@
+package http_server
+
+import (
+	"ads_backend/internal/auth"
+	"ads_backend/internal/clientip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// RateLimitPolicy is a token bucket: RequestsPerSecond refill rate and Burst
+// capacity.
+type RateLimitPolicy struct {
+	RequestsPerSecond float64 `json:"rps"`
+	Burst             int     `json:"burst"`
+}
+
+func (p RateLimitPolicy) String() string {
+	return fmt.Sprintf("%g req/s, burst %d", p.RequestsPerSecond, p.Burst)
+}
+
+func (p RateLimitPolicy) validate() error {
+	if p.RequestsPerSecond <= 0 {
+		return fmt.Errorf("rps must be greater than 0")
+	}
+	if p.Burst < 1 {
+		return fmt.Errorf("burst must be at least 1")
+	}
+	return nil
+}
+
+type RateLimitAllowlist struct {
+	// IPs holds addresses or CIDR prefixes.
+	IPs     []string `json:"ips,omitempty"`
+	APIKeys []string `json:"api_keys,omitempty"`
+}
+
+// RateLimitConfig picks a policy per request. Every client gets its own
+// bucket per route class. For authenticated clients the first of APIKeys
+// (by key ID), Tenants (by the tenant the credentials are bound to) and
+// Routes (by the route's permission) that has an entry sets its size;
+// anonymous clients get Anonymous when it is set, and Routes otherwise. The
+// fallback is Default. Allowlisted clients are never limited.
+type RateLimitConfig struct {
+	Default   RateLimitPolicy                     `json:"default"`
+	Anonymous *RateLimitPolicy                    `json:"anonymous,omitempty"`
+	Routes    map[auth.Permission]RateLimitPolicy `json:"routes,omitempty"`
+	APIKeys   map[string]RateLimitPolicy          `json:"api_keys,omitempty"`
+	Tenants   map[string]RateLimitPolicy          `json:"tenants,omitempty"`
+	Allowlist RateLimitAllowlist                  `json:"allowlist,omitempty"`
+}
+
+// DefaultRateLimitConfig is the limit every client and route got before
+// policies were configurable.
+func DefaultRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{Default: RateLimitPolicy{RequestsPerSecond: 10, Burst: 20}}
+}
+
+// LoadRateLimitConfig reads the JSON policy file at path, or returns
+// DefaultRateLimitConfig when path is empty. Policies the file leaves out
+// keep their defaults.
+func LoadRateLimitConfig(path string) (RateLimitConfig, error) {
+	config := DefaultRateLimitConfig()
+
+	if path == "" {
+		return config, nil
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return RateLimitConfig{}, fmt.Errorf("reading rate limit policy: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return RateLimitConfig{}, fmt.Errorf("parsing rate limit policy: %w", err)
+	}
+	return config, config.Validate()
+}
+
+func (c RateLimitConfig) Validate() error {
+	if err := c.Default.validate(); err != nil {
+		return fmt.Errorf("rate limit default: %w", err)
+	}
+	if c.Anonymous != nil {
+		if err := c.Anonymous.validate(); err != nil {
+			return fmt.Errorf("rate limit anonymous: %w", err)
+		}
+	}
+	for permission, policy := range c.Routes {
+		if err := policy.validate(); err != nil {
+			return fmt.Errorf("rate limit route %q: %w", permission, err)
+		}
+	}
+	for id, policy := range c.APIKeys {
+		if err := policy.validate(); err != nil {
+			return fmt.Errorf("rate limit api key %q: %w", id, err)
+		}
+	}
+	for id, policy := range c.Tenants {
+		if err := policy.validate(); err != nil {
+			return fmt.Errorf("rate limit tenant %q: %w", id, err)
+		}
+	}
+	if _, err := clientip.ParsePrefixes(c.Allowlist.IPs); err != nil {
+		return fmt.Errorf("rate limit allowlist: %w", err)
+	}
+	return nil
+}
+
+// policyFor picks the policy for a client. Tenant policies only apply to
+// credentials bound to the tenant, never to the X-Tenant-ID header, which
+// any caller can set.
+func (c RateLimitConfig) policyFor(permission auth.Permission, principal auth.Principal, authenticated bool) RateLimitPolicy {
+	if !authenticated {
+		if c.Anonymous != nil {
+			return *c.Anonymous
+		}
+	} else {
+		if principal.Method == auth.MethodAPIKey {
+			if policy, ok := c.APIKeys[principal.Subject]; ok {
+				return policy
+			}
+		}
+		if policy, ok := c.Tenants[principal.Tenant]; ok && principal.Tenant != "" {
+			return policy
+		}
+	}
+	if policy, ok := c.Routes[permission]; ok {
+		return policy
+	}
+	return c.Default
+}
@@ -0,0 +1,197 @@
+package http_server
+
+import (
+	"ads_backend/internal/auth"
+	"ads_backend/internal/clientip"
+	"ads_backend/internal/logging"
+	"ads_backend/internal/metrics"
+	"ads_backend/internal/ratelimit"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// RateLimiter gives every client a token bucket per route class, sized by
+// its RateLimitConfig and kept in a ratelimit.Store. Clients are API keys and
+// JWT subjects with valid credentials, and remote IPs otherwise. SetConfig
+// swaps the policies while requests are being served.
+type RateLimiter struct {
+	store   ratelimit.Store
+	rules   atomic.Pointer[rateLimitRules]
+	metrics *metrics.Metrics
+	log     *zap.Logger
+}
+
+// rateLimitRules is a RateLimitConfig with its allowlist parsed.
+type rateLimitRules struct {
+	config      RateLimitConfig
+	allowedIPs  []netip.Prefix
+	allowedKeys map[string]bool
+}
+
+func newRateLimitRules(config RateLimitConfig) (*rateLimitRules, error) {
+	allowedIPs, err := clientip.ParsePrefixes(config.Allowlist.IPs)
+	if err != nil {
+		return nil, err
+	}
+	allowedKeys := make(map[string]bool, len(config.Allowlist.APIKeys))
+	for _, id := range config.Allowlist.APIKeys {
+		allowedKeys[id] = true
+	}
+	return &rateLimitRules{config: config, allowedIPs: allowedIPs, allowedKeys: allowedKeys}, nil
+}
+
+// NewRateLimiter limits every client and route to the same policy, counting
+// in process.
+func NewRateLimiter(requestsPerSecond int, burst int) *RateLimiter {
+	rl, _ := NewRateLimiterFromConfig(RateLimitConfig{
+		Default: RateLimitPolicy{RequestsPerSecond: float64(requestsPerSecond), Burst: burst},
+	}, ratelimit.NewMemoryStore(time.Minute), metrics.New(), zap.NewNop())
+	return rl
+}
+
+func NewRateLimiterFromConfig(config RateLimitConfig, store ratelimit.Store, metrics *metrics.Metrics, log *zap.Logger) (*RateLimiter, error) {
+	rules, err := newRateLimitRules(config)
+	if err != nil {
+		return nil, err
+	}
+	rl := &RateLimiter{store: store, metrics: metrics, log: log}
+	rl.rules.Store(rules)
+	return rl, nil
+}
+
+// NewRateLimiterMiddleware builds the server's limiter from the policy file
+// the config names, over the store ratelimit.NewStore chose.
+func NewRateLimiterMiddleware(log *zap.Logger, store ratelimit.Store, metrics *metrics.Metrics, settings ratelimit.Config) (*RateLimiter, error) {
+	config, err := LoadRateLimitConfig(settings.PolicyFile)
+	if err != nil {
+		return nil, err
+	}
+	return NewRateLimiterFromConfig(config, store, metrics, log)
+}
+
+// Config is the effective configuration, for reporting.
+func (rl *RateLimiter) Config() RateLimitConfig {
+	return rl.rules.Load().config
+}
+
+// SetConfig validates config and applies it to the requests that follow.
+// Buckets are kept, so clients do not get a fresh burst out of a reload.
+func (rl *RateLimiter) SetConfig(config RateLimitConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	rules, err := newRateLimitRules(config)
+	if err != nil {
+		return err
+	}
+	rl.rules.Store(rules)
+	return nil
+}
+
+// Middleware limits every request before it is authenticated or replayed
+// from the idempotency store, so neither bad credentials nor retries get
+// around the limit. Requests draw from the bucket for the permission of the
+// route mux serves them with; requests that match no route share one
+// bucket. It runs after Authenticator.Identify, which tells authenticated
+// clients apart; everyone else is limited by IP address.
+func (rl *RateLimiter) Middleware(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rules := rl.rules.Load()
+		ip := clientIP(r)
+		principal, authenticated := auth.PrincipalFromContext(r.Context())
+		if rules.allowlisted(ip, principal, authenticated) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var permission auth.Permission
+		label := "unmatched"
+		if route, ok := routeFor(mux, r); ok {
+			permission = route.Permission(r)
+			label = routeLabel(route.Pattern(), r)
+		}
+
+		client := "ip:" + ip
+		if authenticated {
+			client = principal.Method + ":" + principal.Subject
+		}
+		policy := rules.config.policyFor(permission, principal, authenticated)
+
+		result, err := rl.store.Take(r.Context(), string(permission)+"|"+client, policy.RequestsPerSecond, policy.Burst)
+		if err != nil {
+			// An unreachable store must not take the API down with it.
+			logging.For(r.Context(), rl.log).Warn("Rate limit store failed, allowing request", zap.Error(err))
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		setRateLimitHeaders(w.Header(), policy, result.Tokens)
+		if !result.Allowed {
+			rl.metrics.RateLimited(label)
+			retryAfter := ceilSeconds(result.RetryAfter)
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			writeError(w, http.StatusTooManyRequests, fmt.Sprintf("rate limit exceeded, retry in %ds", retryAfter))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// setRateLimitHeaders reports the bucket as the RateLimit header fields
+// describe it: its capacity, the whole tokens left, and the seconds until it
+// is full again.
+func setRateLimitHeaders(header http.Header, policy RateLimitPolicy, tokens float64) {
+	remaining := int(math.Max(0, math.Floor(tokens)))
+	missing := math.Max(0, float64(policy.Burst)-tokens)
+	reset := ceilSeconds(time.Duration(missing / policy.RequestsPerSecond * float64(time.Second)))
+
+	header.Set("RateLimit-Limit", strconv.Itoa(policy.Burst))
+	header.Set("RateLimit-Remaining", strconv.Itoa(remaining))
+	header.Set("RateLimit-Reset", strconv.Itoa(reset))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+func (rules *rateLimitRules) allowlisted(ip string, principal auth.Principal, authenticated bool) bool {
+	if authenticated && principal.Method == auth.MethodAPIKey && rules.allowedKeys[principal.Subject] {
+		return true
+	}
+	if len(rules.allowedIPs) == 0 {
+		return false
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range rules.allowedIPs {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP is the address clientip.Resolver resolved for r, or the TCP peer
+// when no resolver ran.
+func clientIP(r *http.Request) string {
+	if ip := clientip.FromContext(r.Context()); ip != "" {
+		return ip
+	}
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return ip
+}
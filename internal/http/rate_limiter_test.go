@@ -0,0 +1,363 @@
+package http_server
+
+import (
+	"ads_backend/internal/auth"
+	"ads_backend/internal/clientip"
+	"ads_backend/internal/idempotency"
+	"ads_backend/internal/metrics"
+	"ads_backend/internal/ratelimit"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTestRateLimiter(t *testing.T, config RateLimitConfig) *RateLimiter {
+	rl, err := NewRateLimiterFromConfig(config, ratelimit.NewMemoryStore(time.Minute), metrics.New(), zap.NewNop())
+	require.NoError(t, err)
+	return rl
+}
+
+func serveFrom(handler http.Handler, method, target, remoteAddr, apiKey string) int {
+	req := httptest.NewRequest(method, target, nil)
+	req.RemoteAddr = remoteAddr
+	if apiKey != "" {
+		req.Header.Set(apiKeyHeader, apiKey)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w.Code
+}
+
+func newLimitedServer(keys *auth.KeyService, rl *RateLimiter) http.Handler {
+	return newLimitedServerWith(keys, rl, nil)
+}
+
+func newLimitedServerWith(keys *auth.KeyService, rl *RateLimiter, idempotency *Idempotency) http.Handler {
+	authenticator := NewAuthenticator(zap.NewNop(), keys, auth.NewTokenVerifierFromConfig(auth.JWTConfig{}))
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	created := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusCreated) }
+	routes := []Route{
+		newRoute("GET /adspots", auth.PermServeAds, ok),
+		newRoute("GET /adposts", auth.PermReadAds, ok),
+		newRoute("POST /adposts", auth.PermCreateAds, created),
+	}
+	return NewAPIHandler(NewServeMux(routes, authenticator), authenticator, rl, idempotency)
+}
+
+func TestRateLimiter_ReportsBucketInHeaders(t *testing.T) {
+	handler := newLimitedServer(auth.NewKeyService(auth.NewKeyStore()), NewRateLimiter(1, 2))
+
+	serve := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/adspots", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	headers := func(w *httptest.ResponseRecorder) []string {
+		return []string{
+			w.Header().Get("RateLimit-Limit"),
+			w.Header().Get("RateLimit-Remaining"),
+			w.Header().Get("RateLimit-Reset"),
+		}
+	}
+
+	w := serve()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"2", "1", "1"}, headers(w))
+	assert.Empty(t, w.Header().Get("Retry-After"))
+
+	w = serve()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"2", "0", "2"}, headers(w))
+
+	w = serve()
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, []string{"2", "0", "2"}, headers(w))
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+
+	var resp errorResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, "too_many_requests", resp.Error.Code)
+	assert.Equal(t, "rate limit exceeded, retry in 1s", resp.Error.Message)
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, float64, int) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("connection refused")
+}
+
+func (failingStore) Check(context.Context) error {
+	return errors.New("connection refused")
+}
+
+func TestRateLimiter_FailsOpenWhenStoreFails(t *testing.T) {
+	rl, err := NewRateLimiterFromConfig(DefaultRateLimitConfig(), failingStore{}, metrics.New(), zap.NewNop())
+	require.NoError(t, err)
+	handler := newLimitedServer(auth.NewKeyService(auth.NewKeyStore()), rl)
+
+	for i := 0; i < 30; i++ {
+		assert.Equal(t, http.StatusOK, serveFrom(handler, http.MethodGet, "/adspots", "10.0.0.1:1234", ""))
+	}
+}
+
+func TestRateLimiter_SharesBucketsThroughRedis(t *testing.T) {
+	server := miniredis.RunT(t)
+	replica := func() http.Handler {
+		client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+		t.Cleanup(func() { client.Close() })
+		rl, err := NewRateLimiterFromConfig(RateLimitConfig{
+			Default: RateLimitPolicy{RequestsPerSecond: 1, Burst: 2},
+		}, ratelimit.NewRedisStore(client), metrics.New(), zap.NewNop())
+		require.NoError(t, err)
+		return newLimitedServer(auth.NewKeyService(auth.NewKeyStore()), rl)
+	}
+	first, second := replica(), replica()
+
+	assert.Equal(t, http.StatusOK, serveFrom(first, http.MethodGet, "/adspots", "10.0.0.1:1234", ""))
+	assert.Equal(t, http.StatusOK, serveFrom(second, http.MethodGet, "/adspots", "10.0.0.1:1234", ""))
+	assert.Equal(t, http.StatusTooManyRequests, serveFrom(first, http.MethodGet, "/adspots", "10.0.0.1:1234", ""))
+	assert.Equal(t, http.StatusTooManyRequests, serveFrom(second, http.MethodGet, "/adspots", "10.0.0.1:1234", ""))
+}
+
+func TestRateLimiter_KeysOnResolvedClientIP(t *testing.T) {
+	resolver, err := clientip.NewResolver([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+	handler := resolver.Middleware(newLimitedServer(auth.NewKeyService(auth.NewKeyStore()), NewRateLimiter(1, 1)))
+
+	serve := func(forwardedFor string) int {
+		req := httptest.NewRequest(http.MethodGet, "/adspots", nil)
+		req.RemoteAddr = "10.0.0.5:5000"
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve("198.51.100.1"))
+	assert.Equal(t, http.StatusOK, serve("198.51.100.2"), "clients behind the proxy have their own buckets")
+	assert.Equal(t, http.StatusTooManyRequests, serve("198.51.100.1"))
+	assert.Equal(t, http.StatusTooManyRequests, serve("203.0.113.9, 198.51.100.1"), "a spoofed entry does not reset the bucket")
+}
+
+func TestRateLimiter_PoliciesPerRouteAndClient(t *testing.T) {
+	keys := auth.NewKeyService(auth.NewKeyStore())
+	batch, batchKey, err := keys.Issue("batch job", auth.RoleAdmin, "")
+	require.NoError(t, err)
+	viewer, _, err := keys.Issue("dashboard", auth.RoleViewer, "")
+	require.NoError(t, err)
+
+	rl := newTestRateLimiter(t, RateLimitConfig{
+		Default: RateLimitPolicy{RequestsPerSecond: 1, Burst: 1},
+		Routes: map[auth.Permission]RateLimitPolicy{
+			auth.PermServeAds: {RequestsPerSecond: 1, Burst: 3},
+		},
+		APIKeys: map[string]RateLimitPolicy{
+			batchKey.ID: {RequestsPerSecond: 1, Burst: 2},
+		},
+	})
+	handler := newLimitedServer(keys, rl)
+
+	burst := func(target, apiKey string) int {
+		n := 0
+		for serveFrom(handler, http.MethodGet, target, "10.0.0.1:1234", apiKey) == http.StatusOK {
+			n++
+		}
+		return n
+	}
+
+	assert.Equal(t, 3, burst("/adspots", ""), "serving route policy")
+	assert.Equal(t, 1, burst("/adposts", viewer), "default policy, separate bucket per route")
+	assert.Equal(t, 2, burst("/adposts", batch), "api key policy overrides the route")
+	assert.Equal(t, 3, burst("/adspots", viewer), "each client has its own bucket")
+}
+
+func TestRateLimiter_TenantPolicy(t *testing.T) {
+	config := RateLimitConfig{
+		Default: RateLimitPolicy{RequestsPerSecond: 1, Burst: 1},
+		Tenants: map[string]RateLimitPolicy{"bogota": {RequestsPerSecond: 1, Burst: 5}},
+	}
+	bogota := auth.Principal{Method: auth.MethodAPIKey, Subject: "k1", Tenant: "bogota"}
+	lima := auth.Principal{Method: auth.MethodAPIKey, Subject: "k2", Tenant: "lima"}
+
+	assert.Equal(t, 5, config.policyFor(auth.PermServeAds, bogota, true).Burst)
+	assert.Equal(t, 1, config.policyFor(auth.PermServeAds, lima, true).Burst)
+	assert.Equal(t, 1, config.policyFor(auth.PermServeAds, auth.Principal{}, false).Burst)
+}
+
+func TestRateLimiter_AnonymousCallersCannotPickATenantPolicy(t *testing.T) {
+	keys := auth.NewKeyService(auth.NewKeyStore())
+	bogota, _, err := keys.Issue("bogota viewer", auth.RoleViewer, "bogota")
+	require.NoError(t, err)
+
+	rl := newTestRateLimiter(t, RateLimitConfig{
+		Default:   RateLimitPolicy{RequestsPerSecond: 1, Burst: 1},
+		Anonymous: &RateLimitPolicy{RequestsPerSecond: 1, Burst: 2},
+		Tenants:   map[string]RateLimitPolicy{"bogota": {RequestsPerSecond: 1, Burst: 5}},
+	})
+	handler := newLimitedServer(keys, rl)
+
+	burst := func(apiKey string) int {
+		n := 0
+		for {
+			req := httptest.NewRequest(http.MethodGet, "/adspots", nil)
+			req.RemoteAddr = "10.0.0.1:1234"
+			req.Header.Set(tenantHeader, "bogota")
+			if apiKey != "" {
+				req.Header.Set(apiKeyHeader, apiKey)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				return n
+			}
+			n++
+		}
+	}
+
+	assert.Equal(t, 2, burst(""), "the anonymous policy, whatever the header says")
+	assert.Equal(t, 5, burst(bogota), "the tenant policy for credentials bound to it")
+}
+
+func TestRateLimiter_LimitsBadCredentials(t *testing.T) {
+	handler := newLimitedServer(auth.NewKeyService(auth.NewKeyStore()), NewRateLimiter(1, 2))
+
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, http.StatusUnauthorized, serveFrom(handler, http.MethodGet, "/adposts", "10.0.0.1:1234", "ak_bogus.guess"))
+	}
+	assert.Equal(t, http.StatusTooManyRequests, serveFrom(handler, http.MethodGet, "/adposts", "10.0.0.1:1234", "ak_bogus.another"),
+		"guessing keys draws from the caller's IP bucket")
+	assert.Equal(t, http.StatusUnauthorized, serveFrom(handler, http.MethodGet, "/adposts", "10.0.0.2:1234", "ak_bogus.guess"))
+}
+
+func TestRateLimiter_LimitsIdempotentRetries(t *testing.T) {
+	keys := auth.NewKeyService(auth.NewKeyStore())
+	editor, _, err := keys.Issue("editor", auth.RoleEditor, "")
+	require.NoError(t, err)
+
+	rl := newTestRateLimiter(t, RateLimitConfig{Default: RateLimitPolicy{RequestsPerSecond: 20, Burst: 1}})
+	handler := newLimitedServerWith(keys, rl, NewIdempotency(zap.NewNop(), idempotency.NewMemoryStore(time.Hour)))
+
+	post := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/adposts", strings.NewReader(`{"title":"Ad"}`))
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set(apiKeyHeader, editor)
+		req.Header.Set(idempotencyKeyHeader, key)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusCreated, post("first").Code)
+	assert.Equal(t, http.StatusTooManyRequests, post("first").Code, "replays are limited too")
+	assert.Equal(t, http.StatusTooManyRequests, post("second").Code)
+
+	time.Sleep(60 * time.Millisecond)
+	w := post("second")
+	assert.Equal(t, http.StatusCreated, w.Code, "a 429 is not stored under the key")
+	assert.Empty(t, w.Header().Get("Idempotent-Replayed"))
+
+	time.Sleep(60 * time.Millisecond)
+	w = post("first")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "true", w.Header().Get("Idempotent-Replayed"))
+}
+
+func TestRateLimiter_AllowlistBypassesLimits(t *testing.T) {
+	keys := auth.NewKeyService(auth.NewKeyStore())
+	trusted, trustedKey, err := keys.Issue("monitor", auth.RoleViewer, "")
+	require.NoError(t, err)
+
+	rl := newTestRateLimiter(t, RateLimitConfig{
+		Default: RateLimitPolicy{RequestsPerSecond: 1, Burst: 1},
+		Allowlist: RateLimitAllowlist{
+			IPs:     []string{"10.1.0.0/16", "192.168.0.7"},
+			APIKeys: []string{trustedKey.ID},
+		},
+	})
+	handler := newLimitedServer(keys, rl)
+
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, http.StatusOK, serveFrom(handler, http.MethodGet, "/adposts", "10.2.0.1:1234", trusted))
+		assert.Equal(t, http.StatusUnauthorized, serveFrom(handler, http.MethodGet, "/adposts", "10.1.4.2:1234", ""))
+		assert.Equal(t, http.StatusUnauthorized, serveFrom(handler, http.MethodGet, "/adposts", "192.168.0.7:1234", ""))
+	}
+	assert.Equal(t, http.StatusUnauthorized, serveFrom(handler, http.MethodGet, "/adposts", "10.2.0.1:1234", ""))
+	assert.Equal(t, http.StatusTooManyRequests, serveFrom(handler, http.MethodGet, "/adposts", "10.2.0.1:1234", ""))
+}
+
+func TestLoadRateLimitConfig(t *testing.T) {
+	config, err := LoadRateLimitConfig("")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultRateLimitConfig(), config)
+
+	path := filepath.Join(t.TempDir(), "policy.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"routes": {"ads:serve": {"rps": 50, "burst": 100}, "admin": {"rps": 0.5, "burst": 2}},
+		"tenants": {"bogota": {"rps": 20, "burst": 40}},
+		"allowlist": {"ips": ["127.0.0.1"]}
+	}`), 0o600))
+
+	config, err = LoadRateLimitConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultRateLimitConfig().Default, config.Default)
+	assert.Equal(t, RateLimitPolicy{RequestsPerSecond: 0.5, Burst: 2}, config.Routes[auth.PermAdmin])
+	assert.Equal(t, RateLimitPolicy{RequestsPerSecond: 20, Burst: 40}, config.Tenants["bogota"])
+
+	invalid := map[string]string{
+		"zero rps":      `{"routes": {"admin": {"rps": 0, "burst": 1}}}`,
+		"bad cidr":      `{"allowlist": {"ips": ["10.0.0.0/99"]}}`,
+		"unknown field": `{"defaults": {"rps": 1, "burst": 1}}`,
+	}
+	for name, body := range invalid {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, os.WriteFile(path, []byte(body), 0o600))
+			_, err := LoadRateLimitConfig(path)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestRateLimiter_SetConfig(t *testing.T) {
+	rl := NewRateLimiter(1, 1)
+	handler := newLimitedServer(auth.NewKeyService(auth.NewKeyStore()), rl)
+
+	assert.Equal(t, http.StatusOK, serveFrom(handler, http.MethodGet, "/adspots", "10.0.0.1:1234", ""))
+	assert.Equal(t, http.StatusTooManyRequests, serveFrom(handler, http.MethodGet, "/adspots", "10.0.0.1:1234", ""))
+
+	assert.Error(t, rl.SetConfig(RateLimitConfig{Default: RateLimitPolicy{RequestsPerSecond: 1, Burst: 0}}))
+	require.NoError(t, rl.SetConfig(RateLimitConfig{
+		Default:   RateLimitPolicy{RequestsPerSecond: 1, Burst: 1},
+		Allowlist: RateLimitAllowlist{IPs: []string{"10.0.0.1"}},
+	}))
+	assert.Equal(t, http.StatusOK, serveFrom(handler, http.MethodGet, "/adspots", "10.0.0.1:1234", ""), "now allowlisted")
+	assert.Equal(t, http.StatusOK, serveFrom(handler, http.MethodGet, "/adspots", "10.0.0.2:1234", ""))
+	assert.Equal(t, http.StatusTooManyRequests, serveFrom(handler, http.MethodGet, "/adspots", "10.0.0.2:1234", ""))
+}
+
+func TestRateLimiter_ReloadKeepsDrainedBuckets(t *testing.T) {
+	rl := NewRateLimiter(1, 2)
+	handler := newLimitedServer(auth.NewKeyService(auth.NewKeyStore()), rl)
+
+	assert.Equal(t, http.StatusOK, serveFrom(handler, http.MethodGet, "/adspots", "10.0.0.1:1234", ""))
+	assert.Equal(t, http.StatusOK, serveFrom(handler, http.MethodGet, "/adspots", "10.0.0.1:1234", ""))
+	assert.Equal(t, http.StatusTooManyRequests, serveFrom(handler, http.MethodGet, "/adspots", "10.0.0.1:1234", ""))
+
+	require.NoError(t, rl.SetConfig(RateLimitConfig{Default: RateLimitPolicy{RequestsPerSecond: 1, Burst: 10}}))
+	assert.Equal(t, http.StatusTooManyRequests, serveFrom(handler, http.MethodGet, "/adspots", "10.0.0.1:1234", ""), "a reload does not refill the bucket")
+	assert.Equal(t, http.StatusOK, serveFrom(handler, http.MethodGet, "/adspots", "10.0.0.2:1234", ""))
+}
@@ -3,6 +3,9 @@ package http_server
 import (
 	"ads_backend/internal/domain"
 	"fmt"
+	"net/url"
+	"strconv"
+	"time"
 )
 
 type createAdRequest struct {
@@ -21,9 +24,164 @@ func (r *createAdRequest) Validate() error {
 		return fmt.Errorf("image_url is required")
 	}
 
+	if !isValidPlacement(r.Placement) {
+		return fmt.Errorf("invalid placement value")
+	}
+
 	if r.TTLMinutes != nil && *r.TTLMinutes < 0 {
 		return fmt.Errorf("ttl_minutes must be greater than or equal to 0")
 	}
 
 	return nil
 }
+
+func (r *createAdRequest) toAd() domain.Ad {
+	ad := domain.Ad{
+		Title:     r.Title,
+		ImageUrl:  r.ImageURL,
+		Placement: r.Placement,
+	}
+	if r.TTLMinutes != nil {
+		ad.TTLMinutes = *r.TTLMinutes
+	}
+	return ad
+}
+
+// updateAdRequest is a partial edit; omitted fields keep their value.
+type updateAdRequest struct {
+	Title      *string           `json:"title,omitempty"`
+	ImageURL   *string           `json:"imageUrl,omitempty"`
+	Placement  *domain.Placement `json:"placement,omitempty"`
+	TTLMinutes *int              `json:"ttlMinutes,omitempty"`
+}
+
+func (r *updateAdRequest) Validate() error {
+	if r.Title == nil && r.ImageURL == nil && r.Placement == nil && r.TTLMinutes == nil {
+		return fmt.Errorf("at least one field must be updated")
+	}
+
+	if r.Title != nil && *r.Title == "" {
+		return fmt.Errorf("title must not be empty")
+	}
+
+	if r.ImageURL != nil && *r.ImageURL == "" {
+		return fmt.Errorf("image_url must not be empty")
+	}
+
+	if r.Placement != nil && !isValidPlacement(*r.Placement) {
+		return fmt.Errorf("invalid placement value")
+	}
+
+	if r.TTLMinutes != nil && *r.TTLMinutes < 0 {
+		return fmt.Errorf("ttl_minutes must be greater than or equal to 0")
+	}
+
+	return nil
+}
+
+func (r *updateAdRequest) toUpdate() domain.AdUpdate {
+	return domain.AdUpdate{
+		Title:      r.Title,
+		ImageUrl:   r.ImageURL,
+		Placement:  r.Placement,
+		TTLMinutes: r.TTLMinutes,
+	}
+}
+
+const maxBatchSize = 500
+
+type batchCreateRequest struct {
+	Items  []createAdRequest `json:"items"`
+	Atomic bool              `json:"atomic"`
+}
+
+type batchDeactivateRequest struct {
+	IDs    []string `json:"ids"`
+	Atomic bool     `json:"atomic"`
+}
+
+func validateBatchSize(n int) error {
+	if n == 0 {
+		return fmt.Errorf("batch must contain at least one item")
+	}
+	if n > maxBatchSize {
+		return fmt.Errorf("batch must contain at most %d items", maxBatchSize)
+	}
+	return nil
+}
+
+func parseListAdsQuery(values url.Values) (domain.AdQuery, error) {
+	query := domain.AdQuery{
+		Status:        domain.Status(values.Get("status")),
+		Placement:     domain.Placement(values.Get("placement")),
+		TitleContains: values.Get("title"),
+		SortBy:        domain.SortField(values.Get("sort")),
+		Order:         domain.SortOrder(values.Get("order")),
+	}
+
+	if query.Status != "" && query.Status != domain.StatusActive && query.Status != domain.StatusInactive {
+		return domain.AdQuery{}, fmt.Errorf("invalid status value")
+	}
+
+	if query.Placement != "" && !isValidPlacement(query.Placement) {
+		return domain.AdQuery{}, fmt.Errorf("invalid placement value")
+	}
+
+	switch query.SortBy {
+	case "", domain.SortByCreatedAt, domain.SortByTitle, domain.SortByDeactivateAt:
+	default:
+		return domain.AdQuery{}, fmt.Errorf("sort must be one of created_at, title, deactivate_at")
+	}
+
+	switch query.Order {
+	case "", domain.SortAsc, domain.SortDesc:
+	default:
+		return domain.AdQuery{}, fmt.Errorf("order must be 'asc' or 'desc'")
+	}
+
+	var err error
+	if query.CreatedAfter, err = parseTimeParam(values, "created_after"); err != nil {
+		return domain.AdQuery{}, err
+	}
+	if query.CreatedBefore, err = parseTimeParam(values, "created_before"); err != nil {
+		return domain.AdQuery{}, err
+	}
+
+	if limitStr := values.Get("limit"); limitStr != "" {
+		query.Limit, err = strconv.Atoi(limitStr)
+		if err != nil || query.Limit <= 0 || query.Limit > domain.MaxPageLimit {
+			return domain.AdQuery{}, fmt.Errorf("limit must be between 1 and %d", domain.MaxPageLimit)
+		}
+	}
+
+	query = query.WithDefaults()
+
+	if cursorStr := values.Get("cursor"); cursorStr != "" {
+		cursor, err := domain.DecodeCursor(cursorStr)
+		if err != nil {
+			return domain.AdQuery{}, err
+		}
+		if cursor.SortBy != query.SortBy || cursor.Order != query.Order {
+			return domain.AdQuery{}, fmt.Errorf("cursor does not match sort and order")
+		}
+		query.After = &cursor
+	}
+
+	return query, nil
+}
+
+func parseTimeParam(values url.Values, name string) (time.Time, error) {
+	raw := values.Get(name)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be an RFC3339 timestamp", name)
+	}
+	return t, nil
+}
+
+func isValidPlacement(placement domain.Placement) bool {
+	return placement == domain.HomeScreen || placement == domain.RideSummary || placement == domain.MapView
+}
@@ -1,6 +1,7 @@
 package http_server
 
 import (
+	"ads_backend/internal/auth"
 	"net/http"
 
 	"go.uber.org/fx"
@@ -10,6 +11,9 @@ type Route interface {
 	http.Handler
 
 	Pattern() string
+	// Permission is what the caller must be granted for r to reach the
+	// route; see Authenticator.Authorize.
+	Permission(r *http.Request) auth.Permission
 }
 
 func AsRoute(f any) any {
@@ -19,3 +23,30 @@ func AsRoute(f any) any {
 		fx.ResultTags(`group:"routes"`),
 	)
 }
+
+// AsRoutes registers a constructor that returns several routes at once, for
+// handlers that serve more than one pattern.
+func AsRoutes(f any) any {
+	return fx.Annotate(
+		f,
+		fx.ResultTags(`group:"routes,flatten"`),
+	)
+}
+
+type route struct {
+	pattern    string
+	permission auth.Permission
+	http.HandlerFunc
+}
+
+func (r route) Pattern() string {
+	return r.pattern
+}
+
+func (r route) Permission(*http.Request) auth.Permission {
+	return r.permission
+}
+
+func newRoute(pattern string, permission auth.Permission, handler http.HandlerFunc) Route {
+	return route{pattern: pattern, permission: permission, HandlerFunc: handler}
+}
@@ -0,0 +1,64 @@
+package http_server
+
+import (
+	"ads_backend/internal/ads_service"
+	"ads_backend/internal/auth"
+	"ads_backend/internal/domain"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"go.uber.org/zap"
+)
+
+type SearchHandler struct {
+	log     *zap.Logger
+	service ads_service.Service
+}
+
+func NewSearchHandler(log *zap.Logger, service ads_service.Service) *SearchHandler {
+	return &SearchHandler{log: log, service: service}
+}
+
+func (h *SearchHandler) Pattern() string {
+	return "GET /adposts/search"
+}
+
+func (h *SearchHandler) Permission(*http.Request) auth.Permission {
+	return auth.PermReadAds
+}
+
+func (h *SearchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+	if q == "" {
+		writeError(w, http.StatusBadRequest, "q parameter is required")
+		return
+	}
+
+	status := domain.Status(r.URL.Query().Get("status"))
+	if status != "" && status != domain.StatusActive && status != domain.StatusInactive {
+		writeError(w, http.StatusBadRequest, "invalid status value")
+		return
+	}
+
+	limit := domain.DefaultPageLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 || limit > domain.MaxPageLimit {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", domain.MaxPageLimit))
+			return
+		}
+	}
+
+	results, err := h.service.SearchAds(r.Context(), q, status, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(results)
+}
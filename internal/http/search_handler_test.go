@@ -0,0 +1,72 @@
+package http_server
+
+import (
+	"ads_backend/internal/domain"
+	"ads_backend/mocks"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func TestSearchAds_Success(t *testing.T) {
+	results := []domain.AdSearchResult{
+		{Ad: domain.Ad{ID: "1", Title: "Promoción de verano"}, Score: 2.5},
+	}
+
+	mockService := mocks.NewService(t)
+	mockService.On("SearchAds", mock.Anything, "promo", domain.StatusActive, 5).Return(results, nil)
+
+	handler := NewSearchHandler(zap.NewNop(), mockService)
+
+	req := httptest.NewRequest(http.MethodGet, "/adposts/search?q=promo&status=active&limit=5", nil)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var got []domain.AdSearchResult
+	err := json.NewDecoder(w.Body).Decode(&got)
+	assert.NoError(t, err)
+	assert.Len(t, got, 1)
+	assert.Equal(t, "1", got[0].Ad.ID)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestSearchAds_MissingQuery(t *testing.T) {
+	mockService := mocks.NewService(t)
+
+	handler := NewSearchHandler(zap.NewNop(), mockService)
+
+	req := httptest.NewRequest(http.MethodGet, "/adposts/search", nil)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "q parameter is required")
+
+	mockService.AssertNotCalled(t, "SearchAds")
+}
+
+func TestSearchAds_InvalidLimit(t *testing.T) {
+	mockService := mocks.NewService(t)
+
+	handler := NewSearchHandler(zap.NewNop(), mockService)
+
+	req := httptest.NewRequest(http.MethodGet, "/adposts/search?q=promo&limit=0", nil)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mockService.AssertNotCalled(t, "SearchAds")
+}
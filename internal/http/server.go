@@ -1,37 +1,90 @@
 package http_server
 
 import (
+	"ads_backend/internal/clientip"
 	"context"
+	"fmt"
 	"net"
 	"net/http"
-	"os"
+	"strconv"
 	"time"
 
 	"go.uber.org/fx"
 	"go.uber.org/zap"
 )
 
-func NewRateLimiterMiddleware() *RateLimiter {
-	return NewRateLimiter(10, 20)
+// ServerConfig is the server section of the app config.
+type ServerConfig struct {
+	Port              int           `yaml:"port"`
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`
+	// RequestTimeout bounds every request but exports; see withTimeout.
+	RequestTimeout time.Duration `yaml:"request_timeout"`
+	// DrainDelay is how long the server keeps serving after readiness
+	// fails on shutdown, for load balancers to notice and stop sending
+	// traffic.
+	DrainDelay time.Duration `yaml:"drain_delay"`
+	// ShutdownTimeout bounds how long in-flight requests get to finish.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
 }
 
-func NewHTTPServer(lc fx.Lifecycle, mux *http.ServeMux, log *zap.Logger, rateLimiter *RateLimiter, requestLogger *RequestLogger) *http.Server {
-	port := os.Getenv("HTTP_PORT")
-	if port == "" {
-		port = "8080"
+func DefaultServerConfig() ServerConfig {
+	return ServerConfig{
+		Port:              8080,
+		ReadTimeout:       15 * time.Second,
+		ReadHeaderTimeout: 10 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       120 * time.Second,
+		RequestTimeout:    30 * time.Second,
+		ShutdownTimeout:   30 * time.Second,
 	}
+}
 
-	var handler http.Handler = mux
-	handler = rateLimiter.Middleware(handler)
+func (c ServerConfig) Validate() error {
+	if c.Port < 1 || c.Port > 65535 {
+		return fmt.Errorf("port must be between 1 and 65535, got %d", c.Port)
+	}
+	timeouts := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"read_timeout", c.ReadTimeout},
+		{"read_header_timeout", c.ReadHeaderTimeout},
+		{"write_timeout", c.WriteTimeout},
+		{"idle_timeout", c.IdleTimeout},
+		{"request_timeout", c.RequestTimeout},
+		{"shutdown_timeout", c.ShutdownTimeout},
+	}
+	for _, timeout := range timeouts {
+		if timeout.value <= 0 {
+			return fmt.Errorf("%s must be greater than 0", timeout.name)
+		}
+	}
+	if c.DrainDelay < 0 {
+		return fmt.Errorf("drain_delay must not be negative")
+	}
+	return nil
+}
+
+func NewHTTPServer(lc fx.Lifecycle, config ServerConfig, mux *http.ServeMux, log *zap.Logger, rateLimiter *RateLimiter, requestLogger *RequestLogger, idempotency *Idempotency, authenticator *Authenticator, requestMetrics *RequestMetrics, requestTracer *RequestTracer, clientIPs *clientip.Resolver, healthHandler *HealthHandler) *http.Server {
+	drainDelay := config.DrainDelay
+
+	handler := NewAPIHandler(mux, authenticator, rateLimiter, idempotency)
+	handler = requestMetrics.Middleware(handler)
 	handler = requestLogger.Middleware(handler)
+	handler = requestTracer.Middleware(handler)
+	handler = clientIPs.Middleware(handler)
+	handler = healthHandler.Mount(handler)
 
 	srv := &http.Server{
-		Addr:              ":" + port,
-		Handler:           http.TimeoutHandler(handler, 30*time.Second, "Request timeout"),
-		ReadTimeout:       15 * time.Second,
-		ReadHeaderTimeout: 10 * time.Second,
-		WriteTimeout:      30 * time.Second,
-		IdleTimeout:       120 * time.Second,
+		Addr:              ":" + strconv.Itoa(config.Port),
+		Handler:           withTimeout(handler, config.RequestTimeout),
+		ReadTimeout:       config.ReadTimeout,
+		ReadHeaderTimeout: config.ReadHeaderTimeout,
+		WriteTimeout:      config.WriteTimeout,
+		IdleTimeout:       config.IdleTimeout,
 	}
 
 	lc.Append(fx.Hook{
@@ -44,16 +97,29 @@ func NewHTTPServer(lc fx.Lifecycle, mux *http.ServeMux, log *zap.Logger, rateLim
 				zap.String("addr", srv.Addr),
 				zap.Duration("read_timeout", srv.ReadTimeout),
 				zap.Duration("write_timeout", srv.WriteTimeout),
-				zap.String("rate_limit", "10 req/s, burst 20"),
+				zap.Duration("request_timeout", config.RequestTimeout),
+				zap.Any("rate_limit", rateLimiter.Config()),
+				zap.Strings("trusted_proxies", clientIPs.TrustedProxies()),
 				zap.Bool("request_logging", true),
 				zap.Bool("correlation_ids", true),
+				zap.Bool("public_reads", authenticator.publicReads),
+				zap.Duration("drain_delay", drainDelay),
 			)
 			go srv.Serve(ln)
 			return nil
 		},
 		OnStop: func(ctx context.Context) error {
+			healthHandler.health.Drain()
+			if drainDelay > 0 {
+				log.Info("Draining before shutdown", zap.Duration("drain_delay", drainDelay))
+				select {
+				case <-time.After(drainDelay):
+				case <-ctx.Done():
+				}
+			}
+
 			log.Info("Shutting down HTTP server gracefully...")
-			shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
 			defer cancel()
 
 			if err := srv.Shutdown(shutdownCtx); err != nil {
@@ -67,3 +133,32 @@ func NewHTTPServer(lc fx.Lifecycle, mux *http.ServeMux, log *zap.Logger, rateLim
 	})
 	return srv
 }
+
+// NewAPIHandler puts the routes behind authentication, rate limiting and
+// idempotency. Credentials are identified first so the limiter can tell
+// clients apart, but requests are only rejected for bad credentials, or
+// answered from the idempotency store, once the limiter has counted them.
+// idempotency may be nil.
+func NewAPIHandler(mux *http.ServeMux, authenticator *Authenticator, rateLimiter *RateLimiter, idempotency *Idempotency) http.Handler {
+	var handler http.Handler = mux
+	if idempotency != nil {
+		handler = idempotency.Middleware(handler)
+	}
+	handler = authenticator.Middleware(handler)
+	handler = rateLimiter.Middleware(mux, handler)
+	return authenticator.Identify(handler)
+}
+
+// withTimeout applies http.TimeoutHandler to every request except exports.
+// TimeoutHandler buffers the whole response before sending it, which would
+// defeat streaming; exports are still bounded by the server's WriteTimeout.
+func withTimeout(handler http.Handler, timeout time.Duration) http.Handler {
+	timed := http.TimeoutHandler(handler, timeout, "Request timeout")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == exportPath {
+			handler.ServeHTTP(w, r)
+			return
+		}
+		timed.ServeHTTP(w, r)
+	})
+}
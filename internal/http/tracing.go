@@ -0,0 +1,54 @@
+package http_server
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestTracer starts a server span per request, continuing the trace named
+// in the caller's traceparent header if there is one, and returns the span's
+// own traceparent in the response so the caller can find it.
+type RequestTracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+	mux        *http.ServeMux
+}
+
+func NewRequestTracer(tp trace.TracerProvider, propagator propagation.TextMapPropagator, mux *http.ServeMux) *RequestTracer {
+	return &RequestTracer{
+		tracer:     tp.Tracer("ads_backend/internal/http"),
+		propagator: propagator,
+		mux:        mux,
+	}
+}
+
+func (rt *RequestTracer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := rt.propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		_, pattern := rt.mux.Handler(r)
+		route := routeLabel(pattern, r)
+
+		ctx, span := rt.tracer.Start(ctx, route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(r.URL.Path),
+			),
+		)
+		defer span.End()
+		rt.propagator.Inject(ctx, propagation.HeaderCarrier(w.Header()))
+
+		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(wrapped, r.WithContext(ctx))
+
+		span.SetAttributes(semconv.HTTPResponseStatusCode(wrapped.statusCode))
+		if wrapped.statusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(wrapped.statusCode))
+		}
+	})
+}
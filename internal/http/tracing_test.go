@@ -0,0 +1,69 @@
+package http_server
+
+import (
+	"ads_backend/internal/tracing"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+func newTracedServer(status int) (http.Handler, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /adposts/{id}/history", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	})
+	var handler http.Handler = NewRequestLogger(zap.NewNop()).Middleware(mux)
+	handler = NewRequestTracer(tp, tracing.NewPropagator(), mux).Middleware(handler)
+	return handler, recorder
+}
+
+func TestRequestTracer_ContinuesIncomingTrace(t *testing.T) {
+	handler, recorder := newTracedServer(http.StatusOK)
+
+	req := httptest.NewRequest(http.MethodGet, "/adposts/3f2a/history", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "GET /adposts/{id}/history", span.Name())
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+	assert.Contains(t, span.Attributes(), attribute.Int("http.response.status_code", http.StatusOK))
+
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-"+span.SpanContext().SpanID().String()+"-01", w.Header().Get("traceparent"))
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", w.Header().Get("X-Correlation-ID"), "the trace ID stands in for a missing correlation ID")
+	assert.Contains(t, span.Attributes(), attribute.String("correlation_id", "4bf92f3577b34da6a3ce929d0e0e4736"))
+}
+
+func TestRequestTracer_RecordsCallerCorrelationID(t *testing.T) {
+	handler, recorder := newTracedServer(http.StatusInternalServerError)
+
+	req := httptest.NewRequest(http.MethodGet, "/adposts/3f2a/history", nil)
+	req.Header.Set("X-Correlation-ID", "gateway-123")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.False(t, span.Parent().IsValid(), "a request without traceparent starts a new trace")
+	assert.Equal(t, "gateway-123", w.Header().Get("X-Correlation-ID"))
+	assert.Contains(t, span.Attributes(), attribute.String("correlation_id", "gateway-123"))
+	assert.Equal(t, codes.Error, span.Status().Code)
+}
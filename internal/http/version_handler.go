@@ -0,0 +1,118 @@
+package http_server
+
+import (
+	"ads_backend/internal/ads_service"
+	"ads_backend/internal/auth"
+	"ads_backend/internal/domain"
+	"ads_backend/internal/logging"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"go.uber.org/zap"
+)
+
+type versionDiff struct {
+	From    int                  `json:"from"`
+	To      int                  `json:"to"`
+	Changes []domain.FieldChange `json:"changes"`
+}
+
+type VersionHandler struct {
+	log     *zap.Logger
+	service ads_service.Service
+}
+
+// NewVersionRoutes exposes an ad's version history: listing and diffing
+// snapshots, and rolling back to one.
+func NewVersionRoutes(log *zap.Logger, service ads_service.Service) []Route {
+	h := &VersionHandler{log: log, service: service}
+	return []Route{
+		newRoute("GET /adposts/{id}/versions", auth.PermReadAds, h.list),
+		newRoute("GET /adposts/{id}/versions/diff", auth.PermReadAds, h.diff),
+		newRoute("GET /adposts/{id}/versions/{version}", auth.PermReadAds, h.get),
+		newRoute("POST /adposts/{id}/versions/{version}/rollback", auth.PermUpdateAds, h.rollback),
+	}
+}
+
+func (h *VersionHandler) list(w http.ResponseWriter, r *http.Request) {
+	versions, err := h.service.ListAdVersions(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(versions)
+}
+
+func (h *VersionHandler) get(w http.ResponseWriter, r *http.Request) {
+	number, err := parseVersion(r.PathValue("version"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	version, err := h.service.GetAdVersion(r.Context(), r.PathValue("id"), number)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(version)
+}
+
+func (h *VersionHandler) diff(w http.ResponseWriter, r *http.Request) {
+	from, err := parseVersion(r.URL.Query().Get("from"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "from: "+err.Error())
+		return
+	}
+	to, err := parseVersion(r.URL.Query().Get("to"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "to: "+err.Error())
+		return
+	}
+
+	changes, err := h.service.DiffAdVersions(r.Context(), r.PathValue("id"), from, to)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(versionDiff{From: from, To: to, Changes: changes})
+}
+
+func (h *VersionHandler) rollback(w http.ResponseWriter, r *http.Request) {
+	number, err := parseVersion(r.PathValue("version"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ad, err := h.service.RollbackAd(r.Context(), r.PathValue("id"), number)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	logging.For(r.Context(), h.log).Info("Ad rolled back", zap.String("ad_id", ad.ID), zap.Int("version", number))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(ad)
+}
+
+func parseVersion(s string) (int, error) {
+	version, err := strconv.Atoi(s)
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("version must be a positive integer")
+	}
+	return version, nil
+}
@@ -0,0 +1,111 @@
+package http_server
+
+import (
+	"ads_backend/internal/domain"
+	"ads_backend/internal/persistence"
+	"ads_backend/mocks"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func versionRoutes(service *mocks.Service) *http.ServeMux {
+	mux := http.NewServeMux()
+	for _, route := range NewVersionRoutes(zap.NewNop(), service) {
+		mux.Handle(route.Pattern(), route)
+	}
+	return mux
+}
+
+func TestVersions_List(t *testing.T) {
+	versions := []domain.AdVersion{
+		{AdID: "123", Version: 1, Action: domain.AuditActionCreated, Ad: domain.Ad{ID: "123", Title: "Promo"}},
+		{AdID: "123", Version: 2, Action: domain.AuditActionUpdated, Ad: domain.Ad{ID: "123", Title: "Promo 2x1"}},
+	}
+
+	mockService := mocks.NewService(t)
+	mockService.On("ListAdVersions", mock.Anything, "123").Return(versions, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/adposts/123/versions", nil)
+	w := httptest.NewRecorder()
+
+	versionRoutes(mockService).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var got []domain.AdVersion
+	err := json.NewDecoder(w.Body).Decode(&got)
+	assert.NoError(t, err)
+	assert.Equal(t, versions, got)
+}
+
+func TestVersions_Diff(t *testing.T) {
+	changes := []domain.FieldChange{{Field: "title", From: "Promo", To: "Promo 2x1"}}
+
+	mockService := mocks.NewService(t)
+	mockService.On("DiffAdVersions", mock.Anything, "123", 1, 2).Return(changes, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/adposts/123/versions/diff?from=1&to=2", nil)
+	w := httptest.NewRecorder()
+
+	versionRoutes(mockService).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var got versionDiff
+	err := json.NewDecoder(w.Body).Decode(&got)
+	assert.NoError(t, err)
+	assert.Equal(t, versionDiff{From: 1, To: 2, Changes: changes}, got)
+}
+
+func TestVersions_DiffInvalidVersion(t *testing.T) {
+	mockService := mocks.NewService(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/adposts/123/versions/diff?from=0&to=2", nil)
+	w := httptest.NewRecorder()
+
+	versionRoutes(mockService).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "from: version must be a positive integer")
+}
+
+func TestVersions_Rollback(t *testing.T) {
+	restored := domain.Ad{ID: "123", Title: "Promo", Status: domain.StatusActive}
+
+	mockService := mocks.NewService(t)
+	mockService.On("RollbackAd", mock.Anything, "123", 1).Return(restored, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/adposts/123/versions/1/rollback", nil)
+	w := httptest.NewRecorder()
+
+	versionRoutes(mockService).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var got domain.Ad
+	err := json.NewDecoder(w.Body).Decode(&got)
+	assert.NoError(t, err)
+	assert.Equal(t, restored, got)
+}
+
+func TestVersions_RollbackUnknownVersion(t *testing.T) {
+	mockService := mocks.NewService(t)
+	mockService.On("RollbackAd", mock.Anything, "123", 9).Return(domain.Ad{}, persistence.ErrVersionNotFound)
+
+	req := httptest.NewRequest(http.MethodPost, "/adposts/123/versions/9/rollback", nil)
+	w := httptest.NewRecorder()
+
+	versionRoutes(mockService).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	var resp errorResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, errorBody{Code: "not_found", Message: "version not found"}, resp.Error)
+}
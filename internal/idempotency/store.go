@@ -0,0 +1,138 @@
+package idempotency
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const defaultTTL = 24 * time.Hour
+
+var (
+	ErrKeyInFlight         = errors.New("a request with this idempotency key is still in progress")
+	ErrFingerprintMismatch = errors.New("idempotency key was already used with a different request")
+)
+
+// Record is the stored first response for an idempotency key.
+type Record struct {
+	Fingerprint string
+	StatusCode  int
+	Header      http.Header
+	Body        []byte
+}
+
+type Store interface {
+	// Reserve claims key for the request identified by fingerprint. It
+	// returns a nil record when the caller now owns the key, the stored
+	// record when the request is a replay, ErrKeyInFlight when another
+	// request holds the key, or ErrFingerprintMismatch when the key was used
+	// for a different request.
+	Reserve(key, fingerprint string) (*Record, error)
+	// Complete stores the response for a reserved key for the store's TTL.
+	Complete(key string, record Record) error
+	// Release drops a reservation without storing a response, so the client
+	// can retry with the same key.
+	Release(key string) error
+}
+
+type entry struct {
+	record    Record
+	done      bool
+	expiresAt time.Time
+}
+
+type memoryStore struct {
+	entries   map[string]*entry
+	ttl       time.Duration
+	lastSweep time.Time
+	mu        sync.Mutex
+	now       func() time.Time
+}
+
+// Config is the idempotency section of the app config.
+type Config struct {
+	// TTL is how long a response is kept for replay.
+	TTL time.Duration `yaml:"ttl"`
+}
+
+func DefaultConfig() Config {
+	return Config{TTL: defaultTTL}
+}
+
+func (c Config) Validate() error {
+	if c.TTL <= 0 {
+		return fmt.Errorf("ttl must be greater than 0")
+	}
+	return nil
+}
+
+// NewStore returns the in-memory store, keeping responses for config.TTL.
+func NewStore(config Config) Store {
+	return NewMemoryStore(config.TTL)
+}
+
+func NewMemoryStore(ttl time.Duration) Store {
+	return &memoryStore{
+		entries: make(map[string]*entry),
+		ttl:     ttl,
+		now:     time.Now,
+	}
+}
+
+func (s *memoryStore) Reserve(key, fingerprint string) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweepLocked(now)
+
+	e, ok := s.entries[key]
+	if ok && now.After(e.expiresAt) {
+		ok = false
+	}
+	if !ok {
+		s.entries[key] = &entry{record: Record{Fingerprint: fingerprint}, expiresAt: now.Add(s.ttl)}
+		return nil, nil
+	}
+
+	if e.record.Fingerprint != fingerprint {
+		return nil, ErrFingerprintMismatch
+	}
+	if !e.done {
+		return nil, ErrKeyInFlight
+	}
+	record := e.record
+	return &record, nil
+}
+
+func (s *memoryStore) Complete(key string, record Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = &entry{record: record, done: true, expiresAt: s.now().Add(s.ttl)}
+	return nil
+}
+
+func (s *memoryStore) Release(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+// sweepLocked drops expired entries at most twice per TTL, so memory stays
+// bounded without a background goroutine.
+func (s *memoryStore) sweepLocked(now time.Time) {
+	if now.Sub(s.lastSweep) < s.ttl/2 {
+		return
+	}
+	for key, e := range s.entries {
+		if now.After(e.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+	s.lastSweep = now
+}
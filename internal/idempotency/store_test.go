@@ -0,0 +1,63 @@
+package idempotency
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_ReserveCompleteReplay(t *testing.T) {
+	store := NewMemoryStore(time.Hour)
+
+	record, err := store.Reserve("key", "fp")
+	require.NoError(t, err)
+	assert.Nil(t, record)
+
+	_, err = store.Reserve("key", "fp")
+	assert.ErrorIs(t, err, ErrKeyInFlight)
+
+	require.NoError(t, store.Complete("key", Record{Fingerprint: "fp", StatusCode: 201, Body: []byte("ok")}))
+
+	record, err = store.Reserve("key", "fp")
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.Equal(t, 201, record.StatusCode)
+	assert.Equal(t, []byte("ok"), record.Body)
+
+	_, err = store.Reserve("key", "other")
+	assert.ErrorIs(t, err, ErrFingerprintMismatch)
+}
+
+func TestMemoryStore_ReleaseAllowsRetry(t *testing.T) {
+	store := NewMemoryStore(time.Hour)
+
+	_, err := store.Reserve("key", "fp")
+	require.NoError(t, err)
+	require.NoError(t, store.Release("key"))
+
+	record, err := store.Reserve("key", "other")
+	require.NoError(t, err)
+	assert.Nil(t, record)
+}
+
+func TestMemoryStore_ExpiresAfterTTL(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryStore(time.Minute).(*memoryStore)
+	store.now = func() time.Time { return now }
+
+	_, err := store.Reserve("key", "fp")
+	require.NoError(t, err)
+	require.NoError(t, store.Complete("key", Record{Fingerprint: "fp", StatusCode: 201}))
+
+	now = now.Add(2 * time.Minute)
+
+	record, err := store.Reserve("key", "other")
+	require.NoError(t, err)
+	assert.Nil(t, record)
+
+	_, err = store.Reserve("stale", "fp")
+	require.NoError(t, err)
+	assert.Len(t, store.entries, 2)
+}
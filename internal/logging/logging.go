@@ -0,0 +1,28 @@
+package logging
+
+import (
+	"context"
+
+	"ads_backend/internal/correlation"
+	"ads_backend/internal/tenant"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// For returns log with the request-scoped fields ctx carries: the
+// correlation ID, the tenant, and the trace and span IDs. Code below the HTTP
+// layer logs through it so its lines can be joined to the request's.
+func For(ctx context.Context, log *zap.Logger) *zap.Logger {
+	fields := []zap.Field{zap.String("tenant", tenant.ID(ctx))}
+	if id := correlation.ID(ctx); id != "" {
+		fields = append(fields, zap.String("correlation_id", id))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		fields = append(fields,
+			zap.String("trace_id", span.TraceID().String()),
+			zap.String("span_id", span.SpanID().String()),
+		)
+	}
+	return log.With(fields...)
+}
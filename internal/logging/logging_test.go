@@ -0,0 +1,38 @@
+package logging
+
+import (
+	"context"
+	"testing"
+
+	"ads_backend/internal/correlation"
+	"ads_backend/internal/tenant"
+
+	"github.com/stretchr/testify/assert"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestFor_AddsRequestFields(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	ctx := correlation.WithID(context.Background(), "corr-1")
+	ctx = tenant.WithID(ctx, "bogota")
+	ctx, span := sdktrace.NewTracerProvider().Tracer("test").Start(ctx, "request")
+	defer span.End()
+
+	For(ctx, zap.New(core)).Info("Ad changed")
+
+	fields := logs.All()[0].ContextMap()
+	assert.Equal(t, "corr-1", fields["correlation_id"])
+	assert.Equal(t, "bogota", fields["tenant"])
+	assert.Equal(t, span.SpanContext().TraceID().String(), fields["trace_id"])
+	assert.Equal(t, span.SpanContext().SpanID().String(), fields["span_id"])
+}
+
+func TestFor_OmitsMissingFields(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+
+	For(context.Background(), zap.New(core)).Info("Janitor swept")
+
+	assert.Equal(t, map[string]any{"tenant": tenant.DefaultID}, logs.All()[0].ContextMap())
+}
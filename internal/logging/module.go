@@ -0,0 +1,111 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Module provides the app's *zap.Logger, built from the Config in the
+// graph, and the zap.AtomicLevel that changes its level at runtime.
+var Module = fx.Module("logging",
+	fx.Provide(NewLogger),
+)
+
+// Config is the logging section of the app config.
+type Config struct {
+	// Level is the initial minimum level: debug, info, warn or error.
+	Level string `yaml:"level"`
+	// Format is "json" or "console".
+	Format string `yaml:"format"`
+	// Sampling keeps the first 100 identical entries per second and every
+	// 100th after that, so a hot loop cannot flood the output.
+	Sampling         bool     `yaml:"sampling"`
+	OutputPaths      []string `yaml:"output_paths"`
+	ErrorOutputPaths []string `yaml:"error_output_paths"`
+	// RedactFields are field keys whose values are never written.
+	RedactFields []string `yaml:"redact_fields"`
+}
+
+// DefaultRedactFields are redacted unless the config says otherwise.
+var DefaultRedactFields = []string{"api_key", "authorization", "cookie", "email", "password", "secret", "token", "user_id"}
+
+func DefaultConfig() Config {
+	return Config{
+		Level:            "info",
+		Format:           "json",
+		Sampling:         true,
+		OutputPaths:      []string{"stdout"},
+		ErrorOutputPaths: []string{"stderr"},
+		RedactFields:     DefaultRedactFields,
+	}
+}
+
+func (c Config) Validate() error {
+	if _, err := zapcore.ParseLevel(c.Level); err != nil {
+		return fmt.Errorf("log level: %w", err)
+	}
+	if c.Format != "json" && c.Format != "console" {
+		return fmt.Errorf("log format must be json or console, got %q", c.Format)
+	}
+	if len(c.OutputPaths) == 0 {
+		return fmt.Errorf("log output paths must not be empty")
+	}
+	return nil
+}
+
+// NewLogger builds the logger config describes. The sampler wraps the
+// redacting core, so sampling runs first and sampled-out entries are never
+// redacted or encoded at all.
+func NewLogger(lc fx.Lifecycle, config Config) (*zap.Logger, zap.AtomicLevel, error) {
+	level, err := zap.ParseAtomicLevel(config.Level)
+	if err != nil {
+		return nil, zap.AtomicLevel{}, err
+	}
+
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	var encoder zapcore.Encoder
+	if config.Format == "console" {
+		encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+	} else {
+		encoder = zapcore.NewJSONEncoder(encoderConfig)
+	}
+
+	output, closeOutput, err := zap.Open(config.OutputPaths...)
+	if err != nil {
+		return nil, zap.AtomicLevel{}, fmt.Errorf("opening log output: %w", err)
+	}
+	errorOutput, closeErrorOutput, err := zap.Open(config.ErrorOutputPaths...)
+	if err != nil {
+		closeOutput()
+		return nil, zap.AtomicLevel{}, fmt.Errorf("opening log error output: %w", err)
+	}
+
+	core := NewRedactingCore(zapcore.NewCore(encoder, output, level), config.RedactFields)
+	if config.Sampling {
+		core = zapcore.NewSamplerWithOptions(core, time.Second, 100, 100)
+	}
+	log := zap.New(core,
+		zap.ErrorOutput(errorOutput),
+		zap.AddCaller(),
+		zap.AddStacktrace(zapcore.ErrorLevel),
+	)
+
+	lc.Append(fx.Hook{
+		OnStop: func(context.Context) error {
+			// Syncing a terminal fails on some platforms; there is nothing
+			// useful to do about it on the way out.
+			_ = log.Sync()
+			closeOutput()
+			closeErrorOutput()
+			return nil
+		},
+	})
+	return log, level, nil
+}
@@ -0,0 +1,55 @@
+package logging
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRedactingCore(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	log := zap.New(NewRedactingCore(core, []string{"api_key", "user_id"})).
+		With(zap.String("user_id", "u-42"))
+
+	log.Info("API key issued", zap.String("API_KEY", "ak_secret"), zap.String("key_id", "k-1"))
+
+	assert.Equal(t, map[string]any{
+		"user_id": "[REDACTED]",
+		"API_KEY": "[REDACTED]",
+		"key_id":  "k-1",
+	}, logs.All()[0].ContextMap())
+}
+
+func TestNewLogger_WritesJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ads.log")
+	config := DefaultConfig()
+	config.OutputPaths = []string{path}
+
+	lc := fxtest.NewLifecycle(t)
+	log, level, err := NewLogger(lc, config)
+	require.NoError(t, err)
+	lc.RequireStart()
+
+	log.Debug("Dropped")
+	level.SetLevel(zapcore.DebugLevel)
+	log.Debug("Cache miss", zap.String("token", "t-1"))
+	lc.RequireStop()
+
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(raw, &entry), "exactly one entry is written")
+	assert.Equal(t, "Cache miss", entry["msg"])
+	assert.Equal(t, "debug", entry["level"])
+	assert.Equal(t, "[REDACTED]", entry["token"])
+	assert.NotEmpty(t, entry["ts"])
+	assert.NotEmpty(t, entry["caller"])
+}
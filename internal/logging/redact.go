@@ -0,0 +1,64 @@
+package logging
+
+import (
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const redacted = "[REDACTED]"
+
+// redactingCore replaces the value of every field whose key is in keys,
+// whether the field is logged directly or attached with Logger.With.
+type redactingCore struct {
+	zapcore.Core
+	keys map[string]bool
+}
+
+// NewRedactingCore wraps core so fields named in keys, compared
+// case-insensitively, are written as "[REDACTED]".
+func NewRedactingCore(core zapcore.Core, keys []string) zapcore.Core {
+	if len(keys) == 0 {
+		return core
+	}
+	set := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		set[strings.ToLower(key)] = true
+	}
+	return &redactingCore{Core: core, keys: set}
+}
+
+func (c *redactingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactingCore{Core: c.Core.With(c.redact(fields)), keys: c.keys}
+}
+
+func (c *redactingCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *redactingCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(entry, c.redact(fields))
+}
+
+// redact copies fields only when one of them needs redacting, so the common
+// case does not allocate.
+func (c *redactingCore) redact(fields []zapcore.Field) []zapcore.Field {
+	var out []zapcore.Field
+	for i, field := range fields {
+		if !c.keys[strings.ToLower(field.Key)] {
+			continue
+		}
+		if out == nil {
+			out = append([]zapcore.Field(nil), fields...)
+		}
+		out[i] = zap.String(field.Key, redacted)
+	}
+	if out == nil {
+		return fields
+	}
+	return out
+}
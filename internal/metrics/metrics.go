@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"ads_backend/internal/domain"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics holds the app's Prometheus collectors on a registry of its own,
+// so every instance (and every test) starts from zero.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests         *prometheus.CounterVec
+	httpDuration         *prometheus.HistogramVec
+	rateLimitRejections  *prometheus.CounterVec
+	rateLimitClients     prometheus.Gauge
+	rateLimitEvictions   prometheus.Counter
+	adsCreated           *prometheus.CounterVec
+	adsDeactivated       *prometheus.CounterVec
+	eligibleAds          *prometheus.GaugeVec
+	repositoryOperations *prometheus.HistogramVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP requests by route and status code.",
+		}, []string{"route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency by route and status code.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route", "status"}),
+		rateLimitRejections: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "rate_limit_rejections_total",
+			Help: "Requests rejected by the rate limiter, by route.",
+		}, []string{"route"}),
+		rateLimitClients: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "rate_limit_tracked_clients",
+			Help: "Buckets the in-process rate limiter tracks, one per client and route class, as of its last sweep.",
+		}),
+		rateLimitEvictions: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "rate_limit_evictions_total",
+			Help: "Idle rate limit buckets dropped by the janitor.",
+		}),
+		adsCreated: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ads_created_total",
+			Help: "Ads created, by placement.",
+		}, []string{"placement"}),
+		adsDeactivated: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ads_deactivated_total",
+			Help: "Ads deactivated, by placement.",
+		}, []string{"placement"}),
+		eligibleAds: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "ads_eligible",
+			Help: "Ads eligible to be served, by placement, as of the last time they were served in any tenant.",
+		}, []string{"placement"}),
+		repositoryOperations: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "repository_operation_duration_seconds",
+			Help:    "Ad repository operation latency by operation.",
+			Buckets: []float64{.00001, .00005, .0001, .0005, .001, .005, .01, .05, .1, .5, 1},
+		}, []string{"operation"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.rateLimitRejections,
+		m.rateLimitClients,
+		m.rateLimitEvictions,
+		m.adsCreated,
+		m.adsDeactivated,
+		m.eligibleAds,
+		m.repositoryOperations,
+	)
+	return m
+}
+
+// Handler serves the registry in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveRequest records a served request. route must come from a bounded
+// set, such as the mux pattern that matched, never the raw path.
+func (m *Metrics) ObserveRequest(route string, status int, duration time.Duration) {
+	code := strconv.Itoa(status)
+	m.httpRequests.WithLabelValues(route, code).Inc()
+	m.httpDuration.WithLabelValues(route, code).Observe(duration.Seconds())
+}
+
+func (m *Metrics) RateLimited(route string) {
+	m.rateLimitRejections.WithLabelValues(route).Inc()
+}
+
+// RateLimitSwept records a sweep of the in-process rate limit store: the
+// buckets it evicted and the ones left.
+func (m *Metrics) RateLimitSwept(evicted, tracked int) {
+	m.rateLimitEvictions.Add(float64(evicted))
+	m.rateLimitClients.Set(float64(tracked))
+}
+
+func (m *Metrics) AdCreated(placement domain.Placement) {
+	m.adsCreated.WithLabelValues(string(placement)).Inc()
+}
+
+func (m *Metrics) AdDeactivated(placement domain.Placement) {
+	m.adsDeactivated.WithLabelValues(string(placement)).Inc()
+}
+
+func (m *Metrics) SetEligibleAds(placement domain.Placement, count int) {
+	m.eligibleAds.WithLabelValues(string(placement)).Set(float64(count))
+}
+
+func (m *Metrics) ObserveRepository(operation string, start time.Time) {
+	m.repositoryOperations.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+}
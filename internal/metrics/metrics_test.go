@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ads_backend/internal/domain"
+	"ads_backend/internal/persistence"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics_HandlerServesTextFormat(t *testing.T) {
+	m := New()
+	m.ObserveRequest("GET /adposts/{id}", http.StatusOK, 20*time.Millisecond)
+	m.RateLimited("GET /adspots")
+	m.RateLimitSwept(2, 5)
+	m.AdCreated(domain.HomeScreen)
+	m.SetEligibleAds(domain.HomeScreen, 3)
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, `http_requests_total{route="GET /adposts/{id}",status="200"} 1`)
+	assert.Contains(t, body, `http_request_duration_seconds_bucket{route="GET /adposts/{id}",status="200",le="0.025"} 1`)
+	assert.Contains(t, body, `rate_limit_rejections_total{route="GET /adspots"} 1`)
+	assert.Contains(t, body, "rate_limit_tracked_clients 5")
+	assert.Contains(t, body, "rate_limit_evictions_total 2")
+	assert.Contains(t, body, `ads_created_total{placement="home_screen"} 1`)
+	assert.Contains(t, body, `ads_eligible{placement="home_screen"} 3`)
+	assert.Contains(t, body, "go_goroutines")
+}
+
+func TestInstrumentAdRepository_TimesOperations(t *testing.T) {
+	m := New()
+	repo := InstrumentAdRepository(persistence.NewAdRepository(), m)
+	ctx := context.Background()
+
+	created, err := repo.CreateAd(ctx, domain.Ad{ID: "ad-1", Placement: domain.HomeScreen, Status: domain.StatusActive})
+	require.NoError(t, err)
+	_, err = repo.GetAd(ctx, created.ID)
+	require.NoError(t, err)
+
+	err = repo.WithTransaction(ctx, func(tx persistence.AdWriter) error {
+		if _, err := tx.DeactivateAd(ctx, created.ID); err != nil {
+			return err
+		}
+		return errors.New("roll back")
+	})
+	assert.Error(t, err)
+
+	assert.Equal(t, map[string]uint64{
+		"create_ad":     1,
+		"get_ad":        1,
+		"deactivate_ad": 1,
+		"transaction":   1,
+	}, repositoryCounts(t, m))
+}
+
+// repositoryCounts is the number of observations per repository operation.
+func repositoryCounts(t *testing.T, m *Metrics) map[string]uint64 {
+	t.Helper()
+	families, err := m.registry.Gather()
+	require.NoError(t, err)
+
+	counts := make(map[string]uint64)
+	for _, family := range families {
+		if family.GetName() != "repository_operation_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			counts[metric.GetLabel()[0].GetValue()] = metric.GetHistogram().GetSampleCount()
+		}
+	}
+	return counts
+}
@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"ads_backend/internal/domain"
+	"ads_backend/internal/persistence"
+)
+
+// InstrumentAdRepository times every call to repo, including those made
+// inside a transaction.
+func InstrumentAdRepository(repo persistence.AdRepository, m *Metrics) persistence.AdRepository {
+	return &adRepository{adWriter: adWriter{next: repo, metrics: m}, next: repo}
+}
+
+type adWriter struct {
+	next    persistence.AdWriter
+	metrics *Metrics
+}
+
+func (w adWriter) CreateAd(ctx context.Context, ad domain.Ad) (domain.Ad, error) {
+	defer w.metrics.ObserveRepository("create_ad", time.Now())
+	return w.next.CreateAd(ctx, ad)
+}
+
+func (w adWriter) GetAd(ctx context.Context, id string) (domain.Ad, error) {
+	defer w.metrics.ObserveRepository("get_ad", time.Now())
+	return w.next.GetAd(ctx, id)
+}
+
+func (w adWriter) DeactivateAd(ctx context.Context, id string) (domain.Ad, error) {
+	defer w.metrics.ObserveRepository("deactivate_ad", time.Now())
+	return w.next.DeactivateAd(ctx, id)
+}
+
+func (w adWriter) UpdateAd(ctx context.Context, ad domain.Ad) (domain.Ad, error) {
+	defer w.metrics.ObserveRepository("update_ad", time.Now())
+	return w.next.UpdateAd(ctx, ad)
+}
+
+type adRepository struct {
+	adWriter
+	next persistence.AdRepository
+}
+
+func (r *adRepository) ListEligibleActiveAdsByPlacement(ctx context.Context, placement domain.Placement) ([]domain.Ad, error) {
+	defer r.metrics.ObserveRepository("list_eligible_ads", time.Now())
+	return r.next.ListEligibleActiveAdsByPlacement(ctx, placement)
+}
+
+// Ping is not observed: it is called by every readiness probe and says
+// nothing about the repository's real work.
+func (r *adRepository) Ping(ctx context.Context) error {
+	return r.next.Ping(ctx)
+}
+
+func (r *adRepository) ListAds(ctx context.Context, query domain.AdQuery) (domain.AdPage, error) {
+	defer r.metrics.ObserveRepository("list_ads", time.Now())
+	return r.next.ListAds(ctx, query)
+}
+
+// EachAd times the whole walk, including the time fn takes.
+func (r *adRepository) EachAd(ctx context.Context, query domain.AdQuery, fn func(domain.Ad) error) error {
+	defer r.metrics.ObserveRepository("each_ad", time.Now())
+	return r.next.EachAd(ctx, query, fn)
+}
+
+// WithTransaction times the whole transaction as well as each write in it.
+func (r *adRepository) WithTransaction(ctx context.Context, fn func(tx persistence.AdWriter) error) error {
+	defer r.metrics.ObserveRepository("transaction", time.Now())
+	return r.next.WithTransaction(ctx, func(tx persistence.AdWriter) error {
+		return fn(adWriter{next: tx, metrics: r.metrics})
+	})
+}
@@ -1,69 +1,170 @@
 package persistence
 
 import (
-	"fmt"
+	"context"
+	"errors"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"ads_backend/internal/domain"
+	"ads_backend/internal/health"
+	"ads_backend/internal/tenant"
 )
 
+// ErrAdNotFound is returned for an ad that does not exist in the tenant.
+var ErrAdNotFound = errors.New("ad not found")
+
+// AdRepository stores ads per tenant. Every method is scoped to the tenant
+// in ctx: another tenant's ads are reported as not found. Once ctx is done,
+// methods return its error without touching the store.
 type AdRepository interface {
-	CreateAd(ad domain.Ad) (domain.Ad, error)
-	GetAd(id string) (domain.Ad, error)
-	DeactivateAd(id string) (domain.Ad, error)
-	ListEligibleActiveAdsByPlacement(placement domain.Placement) ([]domain.Ad, error)
+	CreateAd(ctx context.Context, ad domain.Ad) (domain.Ad, error)
+	GetAd(ctx context.Context, id string) (domain.Ad, error)
+	DeactivateAd(ctx context.Context, id string) (domain.Ad, error)
+	UpdateAd(ctx context.Context, ad domain.Ad) (domain.Ad, error)
+	ListEligibleActiveAdsByPlacement(ctx context.Context, placement domain.Placement) ([]domain.Ad, error)
+	ListAds(ctx context.Context, query domain.AdQuery) (domain.AdPage, error)
+	// EachAd calls fn with every ad matching query, in the query's order,
+	// ignoring its Limit and After. It stops at the first error from fn and
+	// returns it.
+	EachAd(ctx context.Context, query domain.AdQuery, fn func(domain.Ad) error) error
+	// WithTransaction runs fn against a transactional view of the repository.
+	// Writes made through tx become visible only if fn returns nil; any
+	// error discards all of them.
+	WithTransaction(ctx context.Context, fn func(tx AdWriter) error) error
+	// Ping reports whether the repository can serve requests.
+	Ping(ctx context.Context) error
+}
+
+// AdWriter is the subset of AdRepository available inside a transaction.
+type AdWriter interface {
+	CreateAd(ctx context.Context, ad domain.Ad) (domain.Ad, error)
+	GetAd(ctx context.Context, id string) (domain.Ad, error)
+	DeactivateAd(ctx context.Context, id string) (domain.Ad, error)
+	UpdateAd(ctx context.Context, ad domain.Ad) (domain.Ad, error)
+}
+
+// adKey partitions the ad map by tenant.
+type adKey struct {
+	tenant string
+	id     string
+}
+
+func keyOf(ctx context.Context, id string) adKey {
+	return adKey{tenant: tenant.ID(ctx), id: id}
 }
 
+// scanCheckInterval is how many ads a scan visits between checks of its
+// ctx, so a canceled listing stops early without paying for a check per ad.
+const scanCheckInterval = 1024
+
 type adRepository struct {
-	ads map[string]domain.Ad
+	ads map[adKey]domain.Ad
 	mu  sync.RWMutex
 }
 
+// NewHealthChecker checks that repo is reachable.
+func NewHealthChecker(repo AdRepository) health.Checker {
+	return health.NewChecker("repository", repo.Ping)
+}
+
 func NewAdRepository() AdRepository {
 	return &adRepository{
-		ads: make(map[string]domain.Ad),
+		ads: make(map[adKey]domain.Ad),
 		mu:  sync.RWMutex{},
 	}
 }
 
-func (r *adRepository) CreateAd(ad domain.Ad) (domain.Ad, error) {
+// Ping fails only if the repository's lock cannot be taken, which would hang
+// every other call too.
+func (r *adRepository) Ping(ctx context.Context) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return ctx.Err()
+}
+
+func (r *adRepository) CreateAd(ctx context.Context, ad domain.Ad) (domain.Ad, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Ad{}, err
+	}
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.ads[ad.ID] = ad
+	r.ads[keyOf(ctx, ad.ID)] = ad
 	return ad, nil
 }
 
-func (r *adRepository) GetAd(id string) (domain.Ad, error) {
+func (r *adRepository) GetAd(ctx context.Context, id string) (domain.Ad, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Ad{}, err
+	}
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	ad := r.ads[id]
+	ad := r.ads[keyOf(ctx, id)]
 	if ad == (domain.Ad{}) {
-		return domain.Ad{}, fmt.Errorf("ad not found")
+		return domain.Ad{}, ErrAdNotFound
 	}
 	return ad, nil
 }
 
-func (r *adRepository) DeactivateAd(id string) (domain.Ad, error) {
+func (r *adRepository) DeactivateAd(ctx context.Context, id string) (domain.Ad, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Ad{}, err
+	}
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	ad := r.ads[id]
+	key := keyOf(ctx, id)
+	ad := r.ads[key]
 	if ad == (domain.Ad{}) {
-		return domain.Ad{}, fmt.Errorf("ad not found")
+		return domain.Ad{}, ErrAdNotFound
+	}
+	ad = deactivate(ad)
+	r.ads[key] = ad
+	return ad, nil
+}
+
+func (r *adRepository) UpdateAd(ctx context.Context, ad domain.Ad) (domain.Ad, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Ad{}, err
 	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := keyOf(ctx, ad.ID)
+	if r.ads[key] == (domain.Ad{}) {
+		return domain.Ad{}, ErrAdNotFound
+	}
+	r.ads[key] = ad
+	return ad, nil
+}
+
+func deactivate(ad domain.Ad) domain.Ad {
 	ad.Status = domain.StatusInactive
 	ad.DeactivateAt = time.Now()
-	r.ads[id] = ad
-	return ad, nil
+	return ad
 }
 
-func (r *adRepository) ListEligibleActiveAdsByPlacement(placement domain.Placement) ([]domain.Ad, error) {
+func (r *adRepository) ListEligibleActiveAdsByPlacement(ctx context.Context, placement domain.Placement) ([]domain.Ad, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	result := make([]domain.Ad, 0)
 	now := time.Now()
+	tenantID := tenant.ID(ctx)
+
+	scanned := 0
+	for key, ad := range r.ads {
+		if scanned++; scanned%scanCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		if key.tenant != tenantID {
+			continue
+		}
 
-	for _, ad := range r.ads {
 		if ad.Status != domain.StatusActive {
 			continue
 		}
@@ -84,3 +185,186 @@ func (r *adRepository) ListEligibleActiveAdsByPlacement(placement domain.Placeme
 
 	return result, nil
 }
+
+func (r *adRepository) ListAds(ctx context.Context, query domain.AdQuery) (domain.AdPage, error) {
+	matches, err := r.sortedMatches(ctx, query)
+	if err != nil {
+		return domain.AdPage{}, err
+	}
+
+	start := 0
+	if query.After != nil {
+		start = sort.Search(len(matches), func(i int) bool {
+			return cursorBefore(*query.After, matches[i], query.SortBy, query.Order)
+		})
+	}
+
+	end := start + query.Limit
+	if end > len(matches) {
+		end = len(matches)
+	}
+
+	page := domain.AdPage{Ads: matches[start:end]}
+	if end < len(matches) && end > start {
+		page.NextCursor = domain.NewCursor(matches[end-1], query.SortBy, query.Order).Encode()
+	}
+	return page, nil
+}
+
+// EachAd scans and sorts the matches once, then calls fn outside the lock so
+// a slow consumer never holds up writers.
+func (r *adRepository) EachAd(ctx context.Context, query domain.AdQuery, fn func(domain.Ad) error) error {
+	matches, err := r.sortedMatches(ctx, query)
+	if err != nil {
+		return err
+	}
+	for i, ad := range matches {
+		if (i+1)%scanCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		if err := fn(ad); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortedMatches returns the tenant's ads matching query in the query's order.
+func (r *adRepository) sortedMatches(ctx context.Context, query domain.AdQuery) ([]domain.Ad, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	tenantID := tenant.ID(ctx)
+	r.mu.RLock()
+	matches := make([]domain.Ad, 0)
+	scanned := 0
+	for key, ad := range r.ads {
+		if scanned++; scanned%scanCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				r.mu.RUnlock()
+				return nil, err
+			}
+		}
+		if key.tenant == tenantID && matchesQuery(ad, query) {
+			matches = append(matches, ad)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(matches, func(i, j int) bool {
+		return sortsBefore(matches[i], matches[j], query.SortBy, query.Order)
+	})
+	return matches, nil
+}
+
+func matchesQuery(ad domain.Ad, query domain.AdQuery) bool {
+	if query.Status != "" && ad.Status != query.Status {
+		return false
+	}
+	if query.Placement != "" && ad.Placement != query.Placement {
+		return false
+	}
+	if !query.CreatedAfter.IsZero() && ad.CreatedAt.Before(query.CreatedAfter) {
+		return false
+	}
+	if !query.CreatedBefore.IsZero() && !ad.CreatedAt.Before(query.CreatedBefore) {
+		return false
+	}
+	if query.TitleContains != "" && !strings.Contains(strings.ToLower(ad.Title), strings.ToLower(query.TitleContains)) {
+		return false
+	}
+	return true
+}
+
+// sortsBefore orders by the sort value and breaks ties on ID, so every ad has
+// a unique position that a cursor can point at.
+func sortsBefore(a, b domain.Ad, sortBy domain.SortField, order domain.SortOrder) bool {
+	return keyBefore(domain.SortValue(a, sortBy), a.ID, domain.SortValue(b, sortBy), b.ID, order)
+}
+
+func cursorBefore(c domain.Cursor, ad domain.Ad, sortBy domain.SortField, order domain.SortOrder) bool {
+	return keyBefore(c.Value, c.ID, domain.SortValue(ad, sortBy), ad.ID, order)
+}
+
+func keyBefore(aValue, aID, bValue, bID string, order domain.SortOrder) bool {
+	if aValue == bValue {
+		return aID < bID
+	}
+	if order == domain.SortDesc {
+		return aValue > bValue
+	}
+	return aValue < bValue
+}
+
+// WithTransaction discards the transaction if ctx is done before fn
+// returns, even if fn succeeded.
+func (r *adRepository) WithTransaction(ctx context.Context, fn func(tx AdWriter) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tx := &adTransaction{committed: r.ads, staged: make(map[adKey]domain.Ad)}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	for key, ad := range tx.staged {
+		r.ads[key] = ad
+	}
+	return nil
+}
+
+// adTransaction stages writes on top of the committed ads. It runs while the
+// repository write lock is held, so it needs no locking of its own.
+type adTransaction struct {
+	committed map[adKey]domain.Ad
+	staged    map[adKey]domain.Ad
+}
+
+func (tx *adTransaction) CreateAd(ctx context.Context, ad domain.Ad) (domain.Ad, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Ad{}, err
+	}
+	tx.staged[keyOf(ctx, ad.ID)] = ad
+	return ad, nil
+}
+
+func (tx *adTransaction) GetAd(ctx context.Context, id string) (domain.Ad, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Ad{}, err
+	}
+	key := keyOf(ctx, id)
+	if ad, ok := tx.staged[key]; ok {
+		return ad, nil
+	}
+	ad := tx.committed[key]
+	if ad == (domain.Ad{}) {
+		return domain.Ad{}, ErrAdNotFound
+	}
+	return ad, nil
+}
+
+func (tx *adTransaction) DeactivateAd(ctx context.Context, id string) (domain.Ad, error) {
+	ad, err := tx.GetAd(ctx, id)
+	if err != nil {
+		return domain.Ad{}, err
+	}
+	ad = deactivate(ad)
+	tx.staged[keyOf(ctx, id)] = ad
+	return ad, nil
+}
+
+func (tx *adTransaction) UpdateAd(ctx context.Context, ad domain.Ad) (domain.Ad, error) {
+	if _, err := tx.GetAd(ctx, ad.ID); err != nil {
+		return domain.Ad{}, err
+	}
+	tx.staged[keyOf(ctx, ad.ID)] = ad
+	return ad, nil
+}
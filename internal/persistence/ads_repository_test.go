@@ -0,0 +1,254 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"ads_backend/internal/domain"
+	"ads_backend/internal/tenant"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func seedAds(t *testing.T, repo AdRepository, n int, start time.Time) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		_, err := repo.CreateAd(context.Background(), domain.Ad{
+			ID:        fmt.Sprintf("ad-%02d", i),
+			Title:     fmt.Sprintf("Ad %02d", i),
+			Placement: domain.HomeScreen,
+			Status:    domain.StatusActive,
+			CreatedAt: start.Add(time.Duration(i) * time.Minute),
+		})
+		require.NoError(t, err)
+	}
+}
+
+func TestListAds_PaginatesWithCursor(t *testing.T) {
+	repo := NewAdRepository()
+	seedAds(t, repo, 5, time.Now())
+
+	query := domain.AdQuery{Limit: 2}.WithDefaults()
+	var ids []string
+	for {
+		page, err := repo.ListAds(context.Background(), query)
+		require.NoError(t, err)
+		for _, ad := range page.Ads {
+			ids = append(ids, ad.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor, err := domain.DecodeCursor(page.NextCursor)
+		require.NoError(t, err)
+		query.After = &cursor
+	}
+
+	assert.Equal(t, []string{"ad-04", "ad-03", "ad-02", "ad-01", "ad-00"}, ids)
+}
+
+func TestEachAd_WalksEveryMatchInOrder(t *testing.T) {
+	repo := NewAdRepository()
+	seedAds(t, repo, 5, time.Now())
+	_, err := repo.DeactivateAd(context.Background(), "ad-02")
+	require.NoError(t, err)
+
+	query := domain.AdQuery{Status: domain.StatusActive, Limit: 2}.WithDefaults()
+	var ids []string
+	err = repo.EachAd(context.Background(), query, func(ad domain.Ad) error {
+		ids = append(ids, ad.ID)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ad-04", "ad-03", "ad-01", "ad-00"}, ids, "the limit does not apply")
+
+	stop := errors.New("stop")
+	ids = nil
+	err = repo.EachAd(context.Background(), query, func(ad domain.Ad) error {
+		ids = append(ids, ad.ID)
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, []string{"ad-04"}, ids)
+}
+
+func TestListAds_CursorStableAcrossInserts(t *testing.T) {
+	repo := NewAdRepository()
+	start := time.Now()
+	seedAds(t, repo, 4, start)
+
+	query := domain.AdQuery{SortBy: domain.SortByCreatedAt, Order: domain.SortAsc, Limit: 2}.WithDefaults()
+	page, err := repo.ListAds(context.Background(), query)
+	require.NoError(t, err)
+	require.Len(t, page.Ads, 2)
+
+	_, err = repo.CreateAd(context.Background(), domain.Ad{ID: "early", Title: "Inserted before page", Status: domain.StatusActive, CreatedAt: start.Add(-time.Hour)})
+	require.NoError(t, err)
+
+	cursor, err := domain.DecodeCursor(page.NextCursor)
+	require.NoError(t, err)
+	query.After = &cursor
+
+	page, err = repo.ListAds(context.Background(), query)
+	require.NoError(t, err)
+	require.Len(t, page.Ads, 2)
+	assert.Equal(t, "ad-02", page.Ads[0].ID)
+	assert.Equal(t, "ad-03", page.Ads[1].ID)
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestListAds_Filters(t *testing.T) {
+	repo := NewAdRepository()
+	start := time.Now()
+	seedAds(t, repo, 3, start)
+	_, err := repo.CreateAd(context.Background(), domain.Ad{ID: "map", Title: "Ofertas de verano", Placement: domain.MapView, Status: domain.StatusInactive, CreatedAt: start})
+	require.NoError(t, err)
+
+	page, err := repo.ListAds(context.Background(), domain.AdQuery{Placement: domain.MapView}.WithDefaults())
+	require.NoError(t, err)
+	require.Len(t, page.Ads, 1)
+	assert.Equal(t, "map", page.Ads[0].ID)
+
+	page, err = repo.ListAds(context.Background(), domain.AdQuery{Status: domain.StatusActive, TitleContains: "ad 0"}.WithDefaults())
+	require.NoError(t, err)
+	assert.Len(t, page.Ads, 3)
+
+	page, err = repo.ListAds(context.Background(), domain.AdQuery{
+		CreatedAfter:  start.Add(time.Minute),
+		CreatedBefore: start.Add(2 * time.Minute),
+	}.WithDefaults())
+	require.NoError(t, err)
+	require.Len(t, page.Ads, 1)
+	assert.Equal(t, "ad-01", page.Ads[0].ID)
+}
+
+func TestWithTransaction_CommitsOnSuccess(t *testing.T) {
+	repo := NewAdRepository()
+	seedAds(t, repo, 1, time.Now())
+
+	err := repo.WithTransaction(context.Background(), func(tx AdWriter) error {
+		if _, err := tx.CreateAd(context.Background(), domain.Ad{ID: "new", Title: "New", Status: domain.StatusActive}); err != nil {
+			return err
+		}
+		_, err := tx.DeactivateAd(context.Background(), "ad-00")
+		return err
+	})
+	require.NoError(t, err)
+
+	created, err := repo.GetAd(context.Background(), "new")
+	require.NoError(t, err)
+	assert.Equal(t, "New", created.Title)
+
+	deactivated, err := repo.GetAd(context.Background(), "ad-00")
+	require.NoError(t, err)
+	assert.Equal(t, domain.StatusInactive, deactivated.Status)
+}
+
+func TestWithTransaction_RollsBackOnError(t *testing.T) {
+	repo := NewAdRepository()
+	seedAds(t, repo, 1, time.Now())
+
+	err := repo.WithTransaction(context.Background(), func(tx AdWriter) error {
+		if _, err := tx.CreateAd(context.Background(), domain.Ad{ID: "new", Title: "New", Status: domain.StatusActive}); err != nil {
+			return err
+		}
+		if _, err := tx.DeactivateAd(context.Background(), "ad-00"); err != nil {
+			return err
+		}
+		_, err := tx.DeactivateAd(context.Background(), "missing")
+		return err
+	})
+	require.EqualError(t, err, "ad not found")
+
+	_, err = repo.GetAd(context.Background(), "new")
+	assert.Error(t, err)
+
+	ad, err := repo.GetAd(context.Background(), "ad-00")
+	require.NoError(t, err)
+	assert.Equal(t, domain.StatusActive, ad.Status)
+}
+
+func TestRepository_ScopesEveryCallToTheTenant(t *testing.T) {
+	repo := NewAdRepository()
+	bogota := tenant.WithID(context.Background(), "bogota")
+	lima := tenant.WithID(context.Background(), "lima")
+
+	_, err := repo.CreateAd(bogota, domain.Ad{ID: "ad-1", Title: "Promo", Placement: domain.HomeScreen, Status: domain.StatusActive})
+	require.NoError(t, err)
+
+	_, err = repo.GetAd(lima, "ad-1")
+	assert.EqualError(t, err, "ad not found")
+	_, err = repo.DeactivateAd(lima, "ad-1")
+	assert.EqualError(t, err, "ad not found")
+	_, err = repo.UpdateAd(lima, domain.Ad{ID: "ad-1", Title: "Hijacked"})
+	assert.EqualError(t, err, "ad not found")
+
+	err = repo.WithTransaction(lima, func(tx AdWriter) error {
+		_, err := tx.GetAd(lima, "ad-1")
+		return err
+	})
+	assert.EqualError(t, err, "ad not found")
+
+	page, err := repo.ListAds(lima, domain.AdQuery{}.WithDefaults())
+	require.NoError(t, err)
+	assert.Empty(t, page.Ads)
+
+	eligible, err := repo.ListEligibleActiveAdsByPlacement(lima, domain.HomeScreen)
+	require.NoError(t, err)
+	assert.Empty(t, eligible)
+
+	ad, err := repo.GetAd(bogota, "ad-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Promo", ad.Title)
+	assert.Equal(t, domain.StatusActive, ad.Status)
+}
+
+func TestRepository_HonoursCancellation(t *testing.T) {
+	repo := NewAdRepository()
+	seedAds(t, repo, 3, time.Now())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := repo.CreateAd(ctx, domain.Ad{ID: "late", Status: domain.StatusActive})
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = repo.GetAd(ctx, "ad-00")
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = repo.DeactivateAd(ctx, "ad-00")
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = repo.UpdateAd(ctx, domain.Ad{ID: "ad-00", Status: domain.StatusActive})
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = repo.ListEligibleActiveAdsByPlacement(ctx, domain.HomeScreen)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = repo.ListAds(ctx, domain.AdQuery{}.WithDefaults())
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorIs(t, repo.EachAd(ctx, domain.AdQuery{}.WithDefaults(), func(domain.Ad) error { return nil }), context.Canceled)
+	assert.ErrorIs(t, repo.Ping(ctx), context.Canceled)
+
+	_, err = repo.GetAd(context.Background(), "late")
+	assert.Error(t, err, "a canceled write is not applied")
+	ad, err := repo.GetAd(context.Background(), "ad-00")
+	require.NoError(t, err)
+	assert.Equal(t, domain.StatusActive, ad.Status)
+}
+
+func TestWithTransaction_DiscardedWhenCanceled(t *testing.T) {
+	repo := NewAdRepository()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	err := repo.WithTransaction(ctx, func(tx AdWriter) error {
+		if _, err := tx.CreateAd(ctx, domain.Ad{ID: "ad-1", Status: domain.StatusActive}); err != nil {
+			return err
+		}
+		cancel()
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = repo.GetAd(context.Background(), "ad-1")
+	assert.Error(t, err)
+}
@@ -0,0 +1,39 @@
+package persistence
+
+import (
+	"sync"
+
+	"ads_backend/internal/domain"
+)
+
+// AuditStore is append-only: records can be added and read, never changed.
+type AuditStore interface {
+	Append(record domain.AuditRecord) error
+	ListByAd(adID string) ([]domain.AuditRecord, error)
+}
+
+type auditStore struct {
+	records map[string][]domain.AuditRecord
+	mu      sync.RWMutex
+}
+
+func NewAuditStore() AuditStore {
+	return &auditStore{
+		records: make(map[string][]domain.AuditRecord),
+	}
+}
+
+func (s *auditStore) Append(record domain.AuditRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[record.AdID] = append(s.records[record.AdID], record)
+	return nil
+}
+
+func (s *auditStore) ListByAd(adID string) ([]domain.AuditRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	records := make([]domain.AuditRecord, len(s.records[adID]))
+	copy(records, s.records[adID])
+	return records, nil
+}
@@ -0,0 +1,56 @@
+package persistence
+
+import (
+	"errors"
+	"sync"
+
+	"ads_backend/internal/domain"
+)
+
+// ErrVersionNotFound is returned for a version an ad never had.
+var ErrVersionNotFound = errors.New("version not found")
+
+// VersionStore keeps every snapshot of every ad. Versions are never
+// rewritten; Append numbers each new one after the ad's latest.
+type VersionStore interface {
+	Append(version domain.AdVersion) (domain.AdVersion, error)
+	List(adID string) ([]domain.AdVersion, error)
+	Get(adID string, version int) (domain.AdVersion, error)
+}
+
+type versionStore struct {
+	versions map[string][]domain.AdVersion
+	mu       sync.RWMutex
+}
+
+func NewVersionStore() VersionStore {
+	return &versionStore{
+		versions: make(map[string][]domain.AdVersion),
+	}
+}
+
+func (s *versionStore) Append(version domain.AdVersion) (domain.AdVersion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	version.Version = len(s.versions[version.AdID]) + 1
+	s.versions[version.AdID] = append(s.versions[version.AdID], version)
+	return version, nil
+}
+
+func (s *versionStore) List(adID string) ([]domain.AdVersion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	versions := make([]domain.AdVersion, len(s.versions[adID]))
+	copy(versions, s.versions[adID])
+	return versions, nil
+}
+
+func (s *versionStore) Get(adID string, version int) (domain.AdVersion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	versions := s.versions[adID]
+	if version < 1 || version > len(versions) {
+		return domain.AdVersion{}, ErrVersionNotFound
+	}
+	return versions[version-1], nil
+}
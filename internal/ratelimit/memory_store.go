@@ -0,0 +1,180 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"hash/maphash"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"ads_backend/internal/metrics"
+
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+// defaultIdleTTL is how long a bucket may go unused before it is dropped.
+// By then it has refilled, so a returning client gets the same allowance it
+// would have had anyway.
+const defaultIdleTTL = 3 * time.Minute
+
+// shardCount splits the bucket map so a sweep over millions of clients only
+// ever blocks the requests that hash to the shard being swept.
+const shardCount = 64
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+type shard struct {
+	buckets map[string]*bucket
+	mu      sync.Mutex
+}
+
+// MemoryStore keeps buckets in process. Each replica counts only its own
+// traffic.
+type MemoryStore struct {
+	shards  [shardCount]shard
+	seed    maphash.Seed
+	idleTTL time.Duration
+	evicted atomic.Uint64
+	janitor atomic.Bool
+	now     func() time.Time
+}
+
+// Stats is a snapshot of the store's bookkeeping.
+type Stats struct {
+	Buckets int
+	Evicted uint64
+}
+
+func NewMemoryStore(idleTTL time.Duration) *MemoryStore {
+	s := &MemoryStore{
+		seed:    maphash.MakeSeed(),
+		idleTTL: idleTTL,
+		now:     time.Now,
+	}
+	for i := range s.shards {
+		s.shards[i].buckets = make(map[string]*bucket)
+	}
+	return s
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, limit float64, burst int) (Result, error) {
+	sh := &s.shards[maphash.String(s.seed, key)%shardCount]
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	now := s.now()
+	b, exists := sh.buckets[key]
+	if !exists {
+		b = &bucket{limiter: rate.NewLimiter(rate.Limit(limit), burst)}
+		sh.buckets[key] = b
+	} else if b.limiter.Limit() != rate.Limit(limit) || b.limiter.Burst() != burst {
+		// A changed policy resizes the bucket as of its last use and keeps
+		// its tokens, as the Redis store does: a reload gives nobody a fresh
+		// burst, and what was earned since accrues under the new policy.
+		b.limiter.SetLimitAt(b.lastSeen, rate.Limit(limit))
+		b.limiter.SetBurstAt(b.lastSeen, burst)
+	}
+	b.lastSeen = now
+
+	reservation := b.limiter.ReserveN(now, 1)
+	delay := reservation.DelayFrom(now)
+	if delay > 0 {
+		reservation.CancelAt(now)
+		return Result{Tokens: b.limiter.TokensAt(now), RetryAfter: delay}, nil
+	}
+	return Result{Allowed: true, Tokens: b.limiter.TokensAt(now)}, nil
+}
+
+// Check fails unless the janitor is running, since without it the store
+// grows with every client ever seen.
+func (s *MemoryStore) Check(context.Context) error {
+	if !s.janitor.Load() {
+		return errors.New("rate limit janitor is not running")
+	}
+	return nil
+}
+
+// EvictIdle drops every bucket not used for the idle TTL and returns how
+// many were removed.
+func (s *MemoryStore) EvictIdle() int {
+	cutoff := s.now().Add(-s.idleTTL)
+	evicted := 0
+	for i := range s.shards {
+		sh := &s.shards[i]
+		sh.mu.Lock()
+		for key, b := range sh.buckets {
+			if b.lastSeen.Before(cutoff) {
+				delete(sh.buckets, key)
+				evicted++
+			}
+		}
+		sh.mu.Unlock()
+	}
+	s.evicted.Add(uint64(evicted))
+	return evicted
+}
+
+func (s *MemoryStore) Stats() Stats {
+	buckets := 0
+	for i := range s.shards {
+		sh := &s.shards[i]
+		sh.mu.Lock()
+		buckets += len(sh.buckets)
+		sh.mu.Unlock()
+	}
+	return Stats{Buckets: buckets, Evicted: s.evicted.Load()}
+}
+
+// sweep evicts idle buckets and reports how many clients are left.
+func (s *MemoryStore) sweep(log *zap.Logger, m *metrics.Metrics) {
+	evicted := s.EvictIdle()
+	stats := s.Stats()
+	m.RateLimitSwept(evicted, stats.Buckets)
+	log.Debug("Rate limit buckets swept",
+		zap.Int("evicted", evicted),
+		zap.Int("buckets", stats.Buckets),
+		zap.Uint64("evicted_total", stats.Evicted),
+	)
+}
+
+// StartJanitor evicts idle buckets every half idle TTL until the returned
+// stop function is called, updating the tracked clients gauge and the
+// evictions counter in m after every sweep. stop waits for a sweep in
+// progress to finish.
+func (s *MemoryStore) StartJanitor(log *zap.Logger, m *metrics.Metrics) (stop func()) {
+	interval := s.idleTTL / 2
+	if interval < time.Second {
+		interval = time.Second
+	}
+
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	s.janitor.Store(true)
+	go func() {
+		defer close(finished)
+		defer s.janitor.Store(false)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.sweep(log, m)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-finished
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ads_backend/internal/metrics"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestMemoryStore(idleTTL time.Duration) (*MemoryStore, *fakeClock) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	store := NewMemoryStore(idleTTL)
+	store.now = clock.Now
+	return store, clock
+}
+
+func take(t testing.TB, store Store, key string) {
+	t.Helper()
+	_, err := store.Take(context.Background(), key, 10, 20)
+	require.NoError(t, err)
+}
+
+func TestMemoryStore_Bucket(t *testing.T) {
+	store, clock := newTestMemoryStore(time.Minute)
+	testBucket(t, store, clock.Advance)
+}
+
+func TestMemoryStore_EvictsIdleBuckets(t *testing.T) {
+	store, clock := newTestMemoryStore(time.Minute)
+
+	take(t, store, "10.0.0.1")
+	take(t, store, "10.0.0.2")
+	clock.Advance(45 * time.Second)
+	take(t, store, "10.0.0.2")
+	clock.Advance(30 * time.Second)
+
+	assert.Equal(t, 1, store.EvictIdle())
+	assert.Equal(t, Stats{Buckets: 1, Evicted: 1}, store.Stats())
+
+	clock.Advance(time.Minute)
+	assert.Equal(t, 1, store.EvictIdle())
+	assert.Equal(t, Stats{Buckets: 0, Evicted: 2}, store.Stats())
+}
+
+func TestMemoryStore_SweepUpdatesMetrics(t *testing.T) {
+	store, clock := newTestMemoryStore(time.Minute)
+	m := metrics.New()
+
+	take(t, store, "10.0.0.1")
+	take(t, store, "10.0.0.2")
+	take(t, store, "10.0.0.3")
+	clock.Advance(2 * time.Minute)
+	take(t, store, "10.0.0.3")
+	store.sweep(zap.NewNop(), m)
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, w.Body.String(), "rate_limit_tracked_clients 1")
+	assert.Contains(t, w.Body.String(), "rate_limit_evictions_total 2")
+}
+
+func TestMemoryStore_KeepsActiveBuckets(t *testing.T) {
+	store, _ := newTestMemoryStore(time.Minute)
+	ctx := context.Background()
+
+	_, err := store.Take(ctx, "10.0.0.1", 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 0, store.EvictIdle())
+
+	result, err := store.Take(ctx, "10.0.0.1", 1, 1)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+}
+
+func TestMemoryStore_JanitorStops(t *testing.T) {
+	store := NewMemoryStore(time.Minute)
+	stop := store.StartJanitor(zap.NewNop(), metrics.New())
+	stop()
+	stop()
+}
+
+// BenchmarkMemoryStore_UniqueIPs sends every request from a new address, the
+// worst case for bucket growth. Run with e.g. -benchtime=5000000x to
+// simulate millions of clients; the janitor keeps the map bounded by the
+// clients seen within one idle TTL.
+func BenchmarkMemoryStore_UniqueIPs(b *testing.B) {
+	store, clock := newTestMemoryStore(time.Minute)
+	peak := 0
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		take(b, store, fmt.Sprintf("10.%d.%d.%d", i>>16&0xff, i>>8&0xff, i&0xff))
+		clock.Advance(time.Millisecond)
+		if i%30_000 == 0 {
+			if n := store.Stats().Buckets; n > peak {
+				peak = n
+			}
+			store.EvictIdle()
+		}
+	}
+	b.ReportMetric(float64(peak), "peak_buckets")
+}
+
+// BenchmarkMemoryStore_EvictIdle1M measures a full sweep over a million idle
+// buckets. Each shard is locked for only 1/shardCount of this.
+func BenchmarkMemoryStore_EvictIdle1M(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		store, clock := newTestMemoryStore(time.Minute)
+		for ip := 0; ip < 1_000_000; ip++ {
+			take(b, store, fmt.Sprintf("10.%d.%d.%d", ip>>16&0xff, ip>>8&0xff, ip&0xff))
+		}
+		clock.Advance(2 * time.Minute)
+		b.StartTimer()
+
+		store.EvictIdle()
+	}
+}
+
+func TestMemoryStore_CheckNeedsJanitor(t *testing.T) {
+	store := NewMemoryStore(time.Minute)
+	assert.Error(t, store.Check(context.Background()))
+
+	stop := store.StartJanitor(zap.NewNop(), metrics.New())
+	assert.NoError(t, store.Check(context.Background()))
+
+	stop()
+	assert.Error(t, store.Check(context.Background()))
+}
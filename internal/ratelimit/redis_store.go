@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const redisKeyPrefix = "ratelimit:"
+
+// takeScript is the token bucket of MemoryStore, run atomically in Redis so
+// every replica draws from the same bucket. Time comes from the Redis server,
+// so replicas' clocks need not agree. The bucket expires once it would have
+// refilled, which is when forgetting it changes nothing.
+//
+// Floats are returned as strings: Redis truncates Lua numbers to integers.
+var takeScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + tonumber(time[2]) / 1000
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = now
+end
+
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate / 1000)
+
+local allowed = 0
+local retry_ms = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry_ms = (1 - tokens) * 1000 / rate
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) * 1000 / rate) + 1000)
+return {allowed, tostring(tokens), tostring(retry_ms)}
+`)
+
+// RedisStore keeps buckets in Redis, or anything that speaks its protocol
+// and runs Lua scripts, so the limit holds across replicas.
+type RedisStore struct {
+	client redis.UniversalClient
+}
+
+func NewRedisStore(client redis.UniversalClient) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func (s *RedisStore) Take(ctx context.Context, key string, rate float64, burst int) (Result, error) {
+	reply, err := takeScript.Run(ctx, s.client, []string{redisKeyPrefix + key},
+		strconv.FormatFloat(rate, 'f', -1, 64), burst).Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("rate limit store: %w", err)
+	}
+	if len(reply) != 3 {
+		return Result{}, fmt.Errorf("rate limit store: unexpected reply %v", reply)
+	}
+
+	allowed, _ := reply[0].(int64)
+	tokens, err := strconv.ParseFloat(fmt.Sprint(reply[1]), 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("rate limit store: %w", err)
+	}
+	retryMillis, err := strconv.ParseFloat(fmt.Sprint(reply[2]), 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("rate limit store: %w", err)
+	}
+
+	return Result{
+		Allowed:    allowed == 1,
+		Tokens:     tokens,
+		RetryAfter: time.Duration(retryMillis * float64(time.Millisecond)),
+	}, nil
+}
+
+func (s *RedisStore) Check(ctx context.Context) error {
+	if err := s.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("rate limit store: %w", err)
+	}
+	return nil
+}
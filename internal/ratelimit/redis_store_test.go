@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRedisStore(t *testing.T) (*RedisStore, *miniredis.Miniredis) {
+	server := miniredis.RunT(t)
+	server.SetTime(time.Unix(1_700_000_000, 0))
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewRedisStore(client), server
+}
+
+func TestRedisStore_Bucket(t *testing.T) {
+	store, server := newTestRedisStore(t)
+	now := time.Unix(1_700_000_000, 0)
+	testBucket(t, store, func(d time.Duration) {
+		now = now.Add(d)
+		server.SetTime(now)
+	})
+}
+
+func TestRedisStore_SharedAcrossClients(t *testing.T) {
+	store, server := newTestRedisStore(t)
+	other := NewRedisStore(redis.NewClient(&redis.Options{Addr: server.Addr()}))
+	ctx := context.Background()
+
+	result, err := store.Take(ctx, "ip:10.0.0.1", 1, 1)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	result, err = other.Take(ctx, "ip:10.0.0.1", 1, 1)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed, "a second replica draws from the same bucket")
+}
+
+func TestRedisStore_BucketsExpireOnceFull(t *testing.T) {
+	store, server := newTestRedisStore(t)
+
+	_, err := store.Take(context.Background(), "ip:10.0.0.1", 1, 2)
+	require.NoError(t, err)
+	assert.True(t, server.Exists(redisKeyPrefix+"ip:10.0.0.1"))
+
+	server.FastForward(3 * time.Second)
+	assert.False(t, server.Exists(redisKeyPrefix+"ip:10.0.0.1"))
+}
+
+func TestRedisStore_ReportsErrors(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { client.Close() })
+	store := NewRedisStore(client)
+	server.Close()
+
+	_, err := store.Take(context.Background(), "ip:10.0.0.1", 1, 1)
+	assert.Error(t, err)
+	assert.Error(t, store.Check(context.Background()))
+}
+
+func TestRedisStore_Check(t *testing.T) {
+	store, _ := newTestRedisStore(t)
+	assert.NoError(t, store.Check(context.Background()))
+}
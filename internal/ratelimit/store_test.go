@@ -0,0 +1,61 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testBucket checks the token bucket every Store must implement, advancing
+// the store's clock with advance.
+func testBucket(t *testing.T, store Store, advance func(time.Duration)) {
+	ctx := context.Background()
+	takeFrom := func(key string) Result {
+		t.Helper()
+		result, err := store.Take(ctx, key, 1, 2)
+		require.NoError(t, err)
+		return result
+	}
+
+	first := takeFrom("a")
+	assert.True(t, first.Allowed)
+	assert.InDelta(t, 1, first.Tokens, 0.01)
+
+	assert.True(t, takeFrom("a").Allowed)
+	rejected := takeFrom("a")
+	assert.False(t, rejected.Allowed)
+	assert.InDelta(t, 0, rejected.Tokens, 0.01)
+	assert.InDelta(t, time.Second, rejected.RetryAfter, float64(10*time.Millisecond))
+
+	assert.True(t, takeFrom("b").Allowed, "each key has its own bucket")
+
+	advance(500 * time.Millisecond)
+	assert.False(t, takeFrom("a").Allowed)
+	advance(500 * time.Millisecond)
+	assert.True(t, takeFrom("a").Allowed, "rejected takes do not consume tokens")
+
+	advance(time.Hour)
+	assert.InDelta(t, 1, takeFrom("a").Tokens, 0.01, "the bucket refills up to its burst only")
+
+	// A changed policy applies to the existing bucket.
+	advance(time.Hour)
+	result, err := store.Take(ctx, "a", 1, 5)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.InDelta(t, 4, result.Tokens, 0.01)
+
+	// A drained bucket stays drained when the policy changes.
+	assert.True(t, takeFrom("c").Allowed)
+	assert.True(t, takeFrom("c").Allowed)
+	assert.False(t, takeFrom("c").Allowed)
+	result, err = store.Take(ctx, "c", 2, 10)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed, "a reload does not refill the bucket")
+	advance(500 * time.Millisecond)
+	result, err = store.Take(ctx, "c", 2, 10)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed, "tokens accrue at the new rate")
+}
@@ -0,0 +1,175 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"ads_backend/internal/domain"
+)
+
+const (
+	titleWeight = 3.0
+	otherWeight = 1.0
+
+	// prefixPenalty scales the score of a term that only matched a query
+	// token as a prefix, so "verano" ranks above "veranos" for "verano".
+	prefixPenalty = 0.5
+)
+
+type Hit struct {
+	ID    string
+	Score float64
+}
+
+type Index interface {
+	Upsert(ad domain.Ad)
+	Remove(id string)
+	Search(query string) []Hit
+}
+
+type invertedIndex struct {
+	// postings maps a term to the weighted term frequency of every ad
+	// containing it.
+	postings map[string]map[string]float64
+	// docs keeps each ad's terms so an upsert can drop the old postings.
+	docs map[string]map[string]float64
+	// terms is the sorted vocabulary, used to expand prefixes.
+	terms []string
+	mu    sync.RWMutex
+}
+
+func NewIndex() Index {
+	return &invertedIndex{
+		postings: make(map[string]map[string]float64),
+		docs:     make(map[string]map[string]float64),
+	}
+}
+
+func documentTerms(ad domain.Ad) map[string]float64 {
+	weights := make(map[string]float64)
+	for _, token := range Tokenize(ad.Title) {
+		weights[token] += titleWeight
+	}
+	for _, token := range Tokenize(ad.ImageUrl + " " + string(ad.Placement)) {
+		weights[token] += otherWeight
+	}
+	return weights
+}
+
+func (idx *invertedIndex) Upsert(ad domain.Ad) {
+	weights := documentTerms(ad)
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.removeLocked(ad.ID)
+	for term, weight := range weights {
+		posting, ok := idx.postings[term]
+		if !ok {
+			posting = make(map[string]float64)
+			idx.postings[term] = posting
+			idx.insertTermLocked(term)
+		}
+		posting[ad.ID] = weight
+	}
+	idx.docs[ad.ID] = weights
+}
+
+func (idx *invertedIndex) Remove(id string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.removeLocked(id)
+}
+
+func (idx *invertedIndex) removeLocked(id string) {
+	weights, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+	for term := range weights {
+		posting := idx.postings[term]
+		delete(posting, id)
+		if len(posting) == 0 {
+			delete(idx.postings, term)
+			idx.deleteTermLocked(term)
+		}
+	}
+	delete(idx.docs, id)
+}
+
+func (idx *invertedIndex) insertTermLocked(term string) {
+	i := sort.SearchStrings(idx.terms, term)
+	idx.terms = append(idx.terms, "")
+	copy(idx.terms[i+1:], idx.terms[i:])
+	idx.terms[i] = term
+}
+
+func (idx *invertedIndex) deleteTermLocked(term string) {
+	i := sort.SearchStrings(idx.terms, term)
+	if i < len(idx.terms) && idx.terms[i] == term {
+		idx.terms = append(idx.terms[:i], idx.terms[i+1:]...)
+	}
+}
+
+// Search returns every ad matching all query tokens, best first. Each token
+// matches indexed terms it is a prefix of; an ad's score is the sum over
+// tokens of its best tf-idf among those terms.
+func (idx *invertedIndex) Search(query string) []Hit {
+	tokens := Tokenize(query)
+	if len(tokens) == 0 {
+		return []Hit{}
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	total := float64(len(idx.docs))
+	var scores map[string]float64
+
+	for _, token := range tokens {
+		best := make(map[string]float64)
+		start := sort.SearchStrings(idx.terms, token)
+		for _, term := range idx.terms[start:] {
+			if !strings.HasPrefix(term, token) {
+				break
+			}
+			posting := idx.postings[term]
+			idf := math.Log(1 + total/float64(len(posting)))
+			factor := 1.0
+			if term != token {
+				factor = prefixPenalty
+			}
+			for id, tf := range posting {
+				if score := tf * idf * factor; score > best[id] {
+					best[id] = score
+				}
+			}
+		}
+
+		if scores == nil {
+			scores = best
+			continue
+		}
+		for id, score := range scores {
+			if extra, ok := best[id]; ok {
+				scores[id] = score + extra
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, Hit{ID: id, Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score == hits[j].Score {
+			return hits[i].ID < hits[j].ID
+		}
+		return hits[i].Score > hits[j].Score
+	})
+	return hits
+}
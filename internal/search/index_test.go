@@ -0,0 +1,168 @@
+package search
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"ads_backend/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func hitIDs(hits []Hit) []string {
+	ids := make([]string, 0, len(hits))
+	for _, hit := range hits {
+		ids = append(ids, hit.ID)
+	}
+	return ids
+}
+
+func TestTokenize_FoldsSpanishAccents(t *testing.T) {
+	assert.Equal(t, []string{"cancion", "del", "nino", "pinguino", "2x1"}, Tokenize("¡Canción del NIÑO, pingüino: 2x1!"))
+}
+
+func TestIndex_SearchIgnoresAccents(t *testing.T) {
+	idx := NewIndex()
+	idx.Upsert(domain.Ad{ID: "1", Title: "Promoción de verano"})
+
+	assert.Equal(t, []string{"1"}, hitIDs(idx.Search("promocion")))
+	assert.Equal(t, []string{"1"}, hitIDs(idx.Search("PROMOCIÓN")))
+}
+
+func TestIndex_PrefixMatchingAndRanking(t *testing.T) {
+	idx := NewIndex()
+	idx.Upsert(domain.Ad{ID: "exact", Title: "Pizza gratis"})
+	idx.Upsert(domain.Ad{ID: "prefix", Title: "Pizzeria Napoli"})
+	idx.Upsert(domain.Ad{ID: "other", Title: "Sushi night"})
+
+	hits := idx.Search("pizz")
+	assert.ElementsMatch(t, []string{"exact", "prefix"}, hitIDs(hits))
+
+	hits = idx.Search("pizza")
+	require.NotEmpty(t, hits)
+	assert.Equal(t, "exact", hits[0].ID)
+}
+
+func TestIndex_AllTokensMustMatch(t *testing.T) {
+	idx := NewIndex()
+	idx.Upsert(domain.Ad{ID: "1", Title: "Descuento en viajes al aeropuerto"})
+	idx.Upsert(domain.Ad{ID: "2", Title: "Descuento en comida"})
+
+	assert.Equal(t, []string{"1"}, hitIDs(idx.Search("descuento aero")))
+}
+
+func TestIndex_TitleOutranksOtherFields(t *testing.T) {
+	idx := NewIndex()
+	idx.Upsert(domain.Ad{ID: "url", Title: "Weekend deal", ImageUrl: "http://cdn.example.com/cafe.jpg"})
+	idx.Upsert(domain.Ad{ID: "title", Title: "Cafe discount", ImageUrl: "http://cdn.example.com/a.jpg"})
+
+	hits := idx.Search("cafe")
+	require.Len(t, hits, 2)
+	assert.Equal(t, "title", hits[0].ID)
+}
+
+func TestIndex_UpsertReplacesAndRemoveDeletes(t *testing.T) {
+	idx := NewIndex()
+	idx.Upsert(domain.Ad{ID: "1", Title: "Old title"})
+	idx.Upsert(domain.Ad{ID: "1", Title: "New title"})
+
+	assert.Empty(t, idx.Search("old"))
+	assert.Equal(t, []string{"1"}, hitIDs(idx.Search("new")))
+
+	idx.Remove("1")
+	assert.Empty(t, idx.Search("new"))
+	assert.Empty(t, idx.(*invertedIndex).terms)
+}
+
+var benchWords = []string{
+	"promocion", "verano", "descuento", "viaje", "aeropuerto", "comida", "pizza", "cafe",
+	"musica", "concierto", "cine", "estreno", "banco", "tarjeta", "seguro", "auto",
+	"celular", "plan", "datos", "hotel", "playa", "montaña", "invierno", "oferta",
+}
+
+// benchQuery is run by both search benchmarks.
+const benchQuery = "aeropuerto conc"
+
+func benchAds(n int) []domain.Ad {
+	rng := rand.New(rand.NewSource(1))
+	ads := make([]domain.Ad, n)
+	for i := range ads {
+		ads[i] = domain.Ad{
+			ID: fmt.Sprintf("ad-%06d", i),
+			Title: fmt.Sprintf("%s %s %s %d",
+				benchWords[rng.Intn(len(benchWords))],
+				benchWords[rng.Intn(len(benchWords))],
+				benchWords[rng.Intn(len(benchWords))],
+				i),
+			Placement: domain.HomeScreen,
+			Status:    domain.StatusActive,
+		}
+	}
+	return ads
+}
+
+func BenchmarkSearch_Index100k(b *testing.B) {
+	idx := NewIndex()
+	for _, ad := range benchAds(100_000) {
+		idx.Upsert(ad)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		idx.Search(benchQuery)
+	}
+}
+
+// linearScan applies the index's match rule to every ad: each query token
+// must prefix one of the ad's terms. It returns the matching IDs unranked.
+func linearScan(ads []domain.Ad, query string) []string {
+	tokens := Tokenize(query)
+	ids := make([]string, 0)
+	for _, ad := range ads {
+		terms := documentTerms(ad)
+		matched := true
+		for _, token := range tokens {
+			found := false
+			for term := range terms {
+				if strings.HasPrefix(term, token) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			ids = append(ids, ad.ID)
+		}
+	}
+	return ids
+}
+
+func TestLinearScan_MatchesTheIndex(t *testing.T) {
+	ads := benchAds(2_000)
+	idx := NewIndex()
+	for _, ad := range ads {
+		idx.Upsert(ad)
+	}
+
+	want := linearScan(ads, benchQuery)
+	require.NotEmpty(t, want)
+	assert.ElementsMatch(t, want, hitIDs(idx.Search(benchQuery)))
+}
+
+// BenchmarkSearch_LinearScan100k is the baseline the index replaces: the
+// same query and match rule applied to every ad in turn.
+func BenchmarkSearch_LinearScan100k(b *testing.B) {
+	ads := benchAds(100_000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		linearScan(ads, benchQuery)
+	}
+}
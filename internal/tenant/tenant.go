@@ -0,0 +1,32 @@
+package tenant
+
+import (
+	"context"
+	"regexp"
+)
+
+// DefaultID is the tenant of requests that name none, so single-tenant
+// deployments and callers that predate tenants keep working.
+const DefaultID = "default"
+
+type contextKey string
+
+const idKey contextKey = "tenant-id"
+
+var validID = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, idKey, id)
+}
+
+// ID returns the tenant every read and write in ctx is scoped to.
+func ID(ctx context.Context) string {
+	if id, ok := ctx.Value(idKey).(string); ok && id != "" {
+		return id
+	}
+	return DefaultID
+}
+
+func Valid(id string) bool {
+	return validID.MatchString(id)
+}
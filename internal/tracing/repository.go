@@ -0,0 +1,96 @@
+package tracing
+
+import (
+	"context"
+
+	"ads_backend/internal/domain"
+	"ads_backend/internal/persistence"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentAdRepository wraps every call to repo in a span, including the
+// writes made inside a transaction, which become children of the
+// transaction's span.
+func InstrumentAdRepository(repo persistence.AdRepository, tp trace.TracerProvider) persistence.AdRepository {
+	tracer := tp.Tracer("ads_backend/internal/persistence")
+	return &adRepository{adWriter: adWriter{next: repo, tracer: tracer}, next: repo}
+}
+
+type adWriter struct {
+	next   persistence.AdWriter
+	tracer trace.Tracer
+	// transaction is the span of the transaction the writer belongs to, if
+	// any. Callers pass their own ctx into a transaction, so it is not there.
+	transaction trace.Span
+}
+
+func (w adWriter) start(ctx context.Context, method string, options ...trace.SpanStartOption) (context.Context, trace.Span) {
+	if w.transaction != nil {
+		ctx = trace.ContextWithSpan(ctx, w.transaction)
+	}
+	options = append(options, trace.WithSpanKind(trace.SpanKindClient))
+	return w.tracer.Start(ctx, "persistence."+method, options...)
+}
+
+func (w adWriter) CreateAd(ctx context.Context, ad domain.Ad) (_ domain.Ad, err error) {
+	ctx, span := w.start(ctx, "CreateAd", idAttribute(ad.ID))
+	defer func() { end(span, err) }()
+	return w.next.CreateAd(ctx, ad)
+}
+
+func (w adWriter) GetAd(ctx context.Context, id string) (_ domain.Ad, err error) {
+	ctx, span := w.start(ctx, "GetAd", idAttribute(id))
+	defer func() { end(span, err) }()
+	return w.next.GetAd(ctx, id)
+}
+
+func (w adWriter) DeactivateAd(ctx context.Context, id string) (_ domain.Ad, err error) {
+	ctx, span := w.start(ctx, "DeactivateAd", idAttribute(id))
+	defer func() { end(span, err) }()
+	return w.next.DeactivateAd(ctx, id)
+}
+
+func (w adWriter) UpdateAd(ctx context.Context, ad domain.Ad) (_ domain.Ad, err error) {
+	ctx, span := w.start(ctx, "UpdateAd", idAttribute(ad.ID))
+	defer func() { end(span, err) }()
+	return w.next.UpdateAd(ctx, ad)
+}
+
+type adRepository struct {
+	adWriter
+	next persistence.AdRepository
+}
+
+func (r *adRepository) ListEligibleActiveAdsByPlacement(ctx context.Context, placement domain.Placement) (_ []domain.Ad, err error) {
+	ctx, span := r.start(ctx, "ListEligibleActiveAdsByPlacement", trace.WithAttributes(attribute.String("ad.placement", string(placement))))
+	defer func() { end(span, err) }()
+	return r.next.ListEligibleActiveAdsByPlacement(ctx, placement)
+}
+
+// Ping is not observed: it is called by every readiness probe and says
+// nothing about the repository's real work.
+func (r *adRepository) Ping(ctx context.Context) error {
+	return r.next.Ping(ctx)
+}
+
+func (r *adRepository) ListAds(ctx context.Context, query domain.AdQuery) (_ domain.AdPage, err error) {
+	ctx, span := r.start(ctx, "ListAds")
+	defer func() { end(span, err) }()
+	return r.next.ListAds(ctx, query)
+}
+
+func (r *adRepository) EachAd(ctx context.Context, query domain.AdQuery, fn func(domain.Ad) error) (err error) {
+	ctx, span := r.start(ctx, "EachAd")
+	defer func() { end(span, err) }()
+	return r.next.EachAd(ctx, query, fn)
+}
+
+func (r *adRepository) WithTransaction(ctx context.Context, fn func(tx persistence.AdWriter) error) (err error) {
+	ctx, span := r.start(ctx, "WithTransaction")
+	defer func() { end(span, err) }()
+	return r.next.WithTransaction(ctx, func(tx persistence.AdWriter) error {
+		return fn(adWriter{next: tx, tracer: r.tracer, transaction: span})
+	})
+}
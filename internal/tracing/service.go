@@ -0,0 +1,120 @@
+package tracing
+
+import (
+	"context"
+
+	"ads_backend/internal/ads_service"
+	"ads_backend/internal/domain"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentService wraps every call to svc in a span named after the
+// method, as a child of the span in its ctx.
+func InstrumentService(svc ads_service.Service, tp trace.TracerProvider) ads_service.Service {
+	return &service{next: svc, tracer: tp.Tracer("ads_backend/internal/ads_service")}
+}
+
+type service struct {
+	next   ads_service.Service
+	tracer trace.Tracer
+}
+
+func (s *service) start(ctx context.Context, method string, options ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return s.tracer.Start(ctx, "ads_service."+method, options...)
+}
+
+func (s *service) CreateAd(ctx context.Context, ad domain.Ad) (_ domain.Ad, err error) {
+	ctx, span := s.start(ctx, "CreateAd", trace.WithAttributes(attribute.String("ad.placement", string(ad.Placement))))
+	defer func() { end(span, err) }()
+	return s.next.CreateAd(ctx, ad)
+}
+
+func (s *service) GetAd(ctx context.Context, id string) (_ domain.Ad, err error) {
+	ctx, span := s.start(ctx, "GetAd", idAttribute(id))
+	defer func() { end(span, err) }()
+	return s.next.GetAd(ctx, id)
+}
+
+func (s *service) DeactivateAd(ctx context.Context, id string) (_ domain.Ad, err error) {
+	ctx, span := s.start(ctx, "DeactivateAd", idAttribute(id))
+	defer func() { end(span, err) }()
+	return s.next.DeactivateAd(ctx, id)
+}
+
+func (s *service) ListEligibleActiveAdsByPlacement(ctx context.Context, placement domain.Placement) (_ []domain.Ad, err error) {
+	ctx, span := s.start(ctx, "ListEligibleActiveAdsByPlacement", trace.WithAttributes(attribute.String("ad.placement", string(placement))))
+	defer func() { end(span, err) }()
+	return s.next.ListEligibleActiveAdsByPlacement(ctx, placement)
+}
+
+func (s *service) ListAds(ctx context.Context, query domain.AdQuery) (_ domain.AdPage, err error) {
+	ctx, span := s.start(ctx, "ListAds")
+	defer func() { end(span, err) }()
+	return s.next.ListAds(ctx, query)
+}
+
+func (s *service) ExportAds(ctx context.Context, query domain.AdQuery, fn func(domain.Ad) error) (err error) {
+	ctx, span := s.start(ctx, "ExportAds")
+	defer func() { end(span, err) }()
+	return s.next.ExportAds(ctx, query, fn)
+}
+
+func (s *service) SearchAds(ctx context.Context, query string, status domain.Status, limit int) (_ []domain.AdSearchResult, err error) {
+	ctx, span := s.start(ctx, "SearchAds")
+	defer func() { end(span, err) }()
+	return s.next.SearchAds(ctx, query, status, limit)
+}
+
+func (s *service) CreateAds(ctx context.Context, ads []domain.Ad, atomic bool) (_ []domain.BatchResult, err error) {
+	ctx, span := s.start(ctx, "CreateAds", batchAttributes(len(ads), atomic))
+	defer func() { end(span, err) }()
+	return s.next.CreateAds(ctx, ads, atomic)
+}
+
+func (s *service) DeactivateAds(ctx context.Context, ids []string, atomic bool) (_ []domain.BatchResult, err error) {
+	ctx, span := s.start(ctx, "DeactivateAds", batchAttributes(len(ids), atomic))
+	defer func() { end(span, err) }()
+	return s.next.DeactivateAds(ctx, ids, atomic)
+}
+
+func (s *service) GetAdHistory(ctx context.Context, id string) (_ []domain.AuditRecord, err error) {
+	ctx, span := s.start(ctx, "GetAdHistory", idAttribute(id))
+	defer func() { end(span, err) }()
+	return s.next.GetAdHistory(ctx, id)
+}
+
+func (s *service) UpdateAd(ctx context.Context, id string, update domain.AdUpdate) (_ domain.Ad, err error) {
+	ctx, span := s.start(ctx, "UpdateAd", idAttribute(id))
+	defer func() { end(span, err) }()
+	return s.next.UpdateAd(ctx, id, update)
+}
+
+func (s *service) ListAdVersions(ctx context.Context, id string) (_ []domain.AdVersion, err error) {
+	ctx, span := s.start(ctx, "ListAdVersions", idAttribute(id))
+	defer func() { end(span, err) }()
+	return s.next.ListAdVersions(ctx, id)
+}
+
+func (s *service) GetAdVersion(ctx context.Context, id string, version int) (_ domain.AdVersion, err error) {
+	ctx, span := s.start(ctx, "GetAdVersion", idAttribute(id))
+	defer func() { end(span, err) }()
+	return s.next.GetAdVersion(ctx, id, version)
+}
+
+func (s *service) DiffAdVersions(ctx context.Context, id string, from, to int) (_ []domain.FieldChange, err error) {
+	ctx, span := s.start(ctx, "DiffAdVersions", idAttribute(id))
+	defer func() { end(span, err) }()
+	return s.next.DiffAdVersions(ctx, id, from, to)
+}
+
+func (s *service) RollbackAd(ctx context.Context, id string, version int) (_ domain.Ad, err error) {
+	ctx, span := s.start(ctx, "RollbackAd", idAttribute(id))
+	defer func() { end(span, err) }()
+	return s.next.RollbackAd(ctx, id, version)
+}
+
+func batchAttributes(size int, atomic bool) trace.SpanStartOption {
+	return trace.WithAttributes(attribute.Int("batch.size", size), attribute.Bool("batch.atomic", atomic))
+}
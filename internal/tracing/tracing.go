@@ -0,0 +1,144 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// Config is the tracing section of the app config.
+type Config struct {
+	// Exporter is where spans go:
+	//
+	//   - "otlp" sends them over OTLP/HTTP, configured by the standard
+	//     OTEL_EXPORTER_OTLP_* variables (localhost:4318 by default);
+	//   - "stdout" prints them;
+	//   - "file" appends them as JSON to File;
+	//   - "none", or empty, exports nothing.
+	Exporter string `yaml:"exporter"`
+	File     string `yaml:"file"`
+}
+
+func DefaultConfig() Config {
+	return Config{Exporter: "none"}
+}
+
+func (c Config) Validate() error {
+	switch c.Exporter {
+	case "", "none", "otlp", "stdout":
+		return nil
+	case "file":
+		if c.File == "" {
+			return fmt.Errorf("the file exporter needs a file")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown exporter %q", c.Exporter)
+	}
+}
+
+// NewTracerProvider exports spans as config says. Spans are created either
+// way, so trace IDs still reach the logs and the traceparent response
+// header. Buffered spans are flushed on stop.
+func NewTracerProvider(lc fx.Lifecycle, log *zap.Logger, config Config) (trace.TracerProvider, error) {
+	exporterName := config.Exporter
+	exporter, closeOutput, err := newExporter(config)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.New(context.Background(),
+		resource.WithAttributes(semconv.ServiceName("ads_backend")),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("building trace resource: %w", err)
+	}
+
+	options := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+	if exporter != nil {
+		options = append(options, sdktrace.WithBatcher(exporter))
+	}
+	provider := sdktrace.NewTracerProvider(options...)
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			if exporterName == "" {
+				exporterName = "none"
+			}
+			log.Info("Tracing configured", zap.String("exporter", exporterName))
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			err := provider.Shutdown(ctx)
+			if closeOutput != nil {
+				closeOutput()
+			}
+			return err
+		},
+	})
+	return provider, nil
+}
+
+func newExporter(config Config) (sdktrace.SpanExporter, func(), error) {
+	if err := config.Validate(); err != nil {
+		return nil, nil, err
+	}
+	switch config.Exporter {
+	case "", "none":
+		return nil, nil, nil
+	case "otlp":
+		exporter, err := otlptracehttp.New(context.Background())
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating OTLP exporter: %w", err)
+		}
+		return exporter, nil, nil
+	case "stdout":
+		exporter, err := stdouttrace.New()
+		return exporter, nil, err
+	case "file":
+		f, err := os.OpenFile(config.File, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("opening trace file: %w", err)
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+		return exporter, func() { f.Close() }, nil
+	}
+	return nil, nil, nil
+}
+
+// NewPropagator reads and writes W3C traceparent, tracestate and baggage
+// headers.
+func NewPropagator() propagation.TextMapPropagator {
+	return propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+}
+
+// end finishes span, marking it failed if err is set.
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func idAttribute(id string) trace.SpanStartOption {
+	return trace.WithAttributes(attribute.String("ad.id", id))
+}
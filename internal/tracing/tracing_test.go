@@ -0,0 +1,107 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"ads_backend/internal/ads_service"
+	"ads_backend/internal/domain"
+	"ads_backend/internal/featureflag"
+	"ads_backend/internal/metrics"
+	"ads_backend/internal/persistence"
+	"ads_backend/internal/search"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+)
+
+func newTracedService() (ads_service.Service, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	repo := InstrumentAdRepository(persistence.NewAdRepository(), tp)
+	svc := ads_service.NewService(zap.NewNop(), repo, search.NewIndex(), persistence.NewAuditStore(), persistence.NewVersionStore(), metrics.New(), featureflag.NewStatic())
+	return InstrumentService(svc, tp), recorder
+}
+
+// spansByName indexes the ended spans; names must be unique.
+func spansByName(recorder *tracetest.SpanRecorder) map[string]sdktrace.ReadOnlySpan {
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	return spans
+}
+
+func TestInstrumentService_NestsRepositorySpans(t *testing.T) {
+	svc, recorder := newTracedService()
+
+	created, err := svc.CreateAd(context.Background(), domain.Ad{Title: "Sale", Placement: domain.HomeScreen})
+	require.NoError(t, err)
+
+	spans := spansByName(recorder)
+	require.Len(t, spans, 2)
+	service, repository := spans["ads_service.CreateAd"], spans["persistence.CreateAd"]
+	require.NotNil(t, service)
+	require.NotNil(t, repository)
+
+	assert.Equal(t, service.SpanContext().TraceID(), repository.SpanContext().TraceID())
+	assert.Equal(t, service.SpanContext().SpanID(), repository.Parent().SpanID())
+	assert.Contains(t, repository.Attributes(), attribute.String("ad.id", created.ID))
+}
+
+func TestInstrumentService_RecordsErrors(t *testing.T) {
+	svc, recorder := newTracedService()
+
+	_, err := svc.GetAd(context.Background(), "missing")
+	require.Error(t, err)
+
+	for _, span := range recorder.Ended() {
+		assert.Equal(t, codes.Error, span.Status().Code, span.Name())
+		assert.Len(t, span.Events(), 1, "the error is recorded on %s", span.Name())
+	}
+}
+
+func TestInstrumentAdRepository_TransactionWritesAreChildren(t *testing.T) {
+	svc, recorder := newTracedService()
+
+	_, err := svc.CreateAds(context.Background(), []domain.Ad{
+		{Title: "One", Placement: domain.HomeScreen},
+		{Title: "Two", Placement: domain.MapView},
+	}, true)
+	require.NoError(t, err)
+
+	var transaction sdktrace.ReadOnlySpan
+	var writes []sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		switch span.Name() {
+		case "persistence.WithTransaction":
+			transaction = span
+		case "persistence.CreateAd":
+			writes = append(writes, span)
+		}
+	}
+	require.NotNil(t, transaction)
+	require.Len(t, writes, 2)
+	for _, write := range writes {
+		assert.Equal(t, transaction.SpanContext().SpanID(), write.Parent().SpanID())
+	}
+}
+
+func TestNewExporter(t *testing.T) {
+	exporter, closeOutput, err := newExporter(Config{Exporter: "none"})
+	require.NoError(t, err)
+	assert.Nil(t, exporter)
+	assert.Nil(t, closeOutput)
+
+	_, _, err = newExporter(Config{Exporter: "file"})
+	assert.Error(t, err)
+
+	_, _, err = newExporter(Config{Exporter: "zipkin"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,255 @@
+// Code generated by mockery v2.53.7. DO NOT EDIT.
+
+package mocks
+
+import (
+	domain "ads_backend/internal/domain"
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	persistence "ads_backend/internal/persistence"
+)
+
+// AdRepository is an autogenerated mock type for the AdRepository type
+type AdRepository struct {
+	mock.Mock
+}
+
+// CreateAd provides a mock function with given fields: ctx, ad
+func (_m *AdRepository) CreateAd(ctx context.Context, ad domain.Ad) (domain.Ad, error) {
+	ret := _m.Called(ctx, ad)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateAd")
+	}
+
+	var r0 domain.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Ad) (domain.Ad, error)); ok {
+		return rf(ctx, ad)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Ad) domain.Ad); ok {
+		r0 = rf(ctx, ad)
+	} else {
+		r0 = ret.Get(0).(domain.Ad)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.Ad) error); ok {
+		r1 = rf(ctx, ad)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeactivateAd provides a mock function with given fields: ctx, id
+func (_m *AdRepository) DeactivateAd(ctx context.Context, id string) (domain.Ad, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeactivateAd")
+	}
+
+	var r0 domain.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (domain.Ad, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) domain.Ad); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(domain.Ad)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// EachAd provides a mock function with given fields: ctx, query, fn
+func (_m *AdRepository) EachAd(ctx context.Context, query domain.AdQuery, fn func(domain.Ad) error) error {
+	ret := _m.Called(ctx, query, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EachAd")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.AdQuery, func(domain.Ad) error) error); ok {
+		r0 = rf(ctx, query, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetAd provides a mock function with given fields: ctx, id
+func (_m *AdRepository) GetAd(ctx context.Context, id string) (domain.Ad, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAd")
+	}
+
+	var r0 domain.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (domain.Ad, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) domain.Ad); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(domain.Ad)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListAds provides a mock function with given fields: ctx, query
+func (_m *AdRepository) ListAds(ctx context.Context, query domain.AdQuery) (domain.AdPage, error) {
+	ret := _m.Called(ctx, query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListAds")
+	}
+
+	var r0 domain.AdPage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.AdQuery) (domain.AdPage, error)); ok {
+		return rf(ctx, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.AdQuery) domain.AdPage); ok {
+		r0 = rf(ctx, query)
+	} else {
+		r0 = ret.Get(0).(domain.AdPage)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.AdQuery) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListEligibleActiveAdsByPlacement provides a mock function with given fields: ctx, placement
+func (_m *AdRepository) ListEligibleActiveAdsByPlacement(ctx context.Context, placement domain.Placement) ([]domain.Ad, error) {
+	ret := _m.Called(ctx, placement)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListEligibleActiveAdsByPlacement")
+	}
+
+	var r0 []domain.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Placement) ([]domain.Ad, error)); ok {
+		return rf(ctx, placement)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Placement) []domain.Ad); ok {
+		r0 = rf(ctx, placement)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Ad)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.Placement) error); ok {
+		r1 = rf(ctx, placement)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Ping provides a mock function with given fields: ctx
+func (_m *AdRepository) Ping(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Ping")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateAd provides a mock function with given fields: ctx, ad
+func (_m *AdRepository) UpdateAd(ctx context.Context, ad domain.Ad) (domain.Ad, error) {
+	ret := _m.Called(ctx, ad)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateAd")
+	}
+
+	var r0 domain.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Ad) (domain.Ad, error)); ok {
+		return rf(ctx, ad)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Ad) domain.Ad); ok {
+		r0 = rf(ctx, ad)
+	} else {
+		r0 = ret.Get(0).(domain.Ad)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.Ad) error); ok {
+		r1 = rf(ctx, ad)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// WithTransaction provides a mock function with given fields: ctx, fn
+func (_m *AdRepository) WithTransaction(ctx context.Context, fn func(persistence.AdWriter) error) error {
+	ret := _m.Called(ctx, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for WithTransaction")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, func(persistence.AdWriter) error) error); ok {
+		r0 = rf(ctx, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewAdRepository creates a new instance of AdRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewAdRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *AdRepository {
+	mock := &AdRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,141 @@
+// Code generated by mockery v2.53.7. DO NOT EDIT.
+
+package mocks
+
+import (
+	domain "ads_backend/internal/domain"
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// AdWriter is an autogenerated mock type for the AdWriter type
+type AdWriter struct {
+	mock.Mock
+}
+
+// CreateAd provides a mock function with given fields: ctx, ad
+func (_m *AdWriter) CreateAd(ctx context.Context, ad domain.Ad) (domain.Ad, error) {
+	ret := _m.Called(ctx, ad)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateAd")
+	}
+
+	var r0 domain.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Ad) (domain.Ad, error)); ok {
+		return rf(ctx, ad)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Ad) domain.Ad); ok {
+		r0 = rf(ctx, ad)
+	} else {
+		r0 = ret.Get(0).(domain.Ad)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.Ad) error); ok {
+		r1 = rf(ctx, ad)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeactivateAd provides a mock function with given fields: ctx, id
+func (_m *AdWriter) DeactivateAd(ctx context.Context, id string) (domain.Ad, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeactivateAd")
+	}
+
+	var r0 domain.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (domain.Ad, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) domain.Ad); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(domain.Ad)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAd provides a mock function with given fields: ctx, id
+func (_m *AdWriter) GetAd(ctx context.Context, id string) (domain.Ad, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAd")
+	}
+
+	var r0 domain.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (domain.Ad, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) domain.Ad); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(domain.Ad)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateAd provides a mock function with given fields: ctx, ad
+func (_m *AdWriter) UpdateAd(ctx context.Context, ad domain.Ad) (domain.Ad, error) {
+	ret := _m.Called(ctx, ad)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateAd")
+	}
+
+	var r0 domain.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Ad) (domain.Ad, error)); ok {
+		return rf(ctx, ad)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Ad) domain.Ad); ok {
+		r0 = rf(ctx, ad)
+	} else {
+		r0 = ret.Get(0).(domain.Ad)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.Ad) error); ok {
+		r1 = rf(ctx, ad)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewAdWriter creates a new instance of AdWriter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewAdWriter(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *AdWriter {
+	mock := &AdWriter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
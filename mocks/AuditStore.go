@@ -0,0 +1,76 @@
+// Code generated by mockery v2.53.7. DO NOT EDIT.
+
+package mocks
+
+import (
+	domain "ads_backend/internal/domain"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// AuditStore is an autogenerated mock type for the AuditStore type
+type AuditStore struct {
+	mock.Mock
+}
+
+// Append provides a mock function with given fields: record
+func (_m *AuditStore) Append(record domain.AuditRecord) error {
+	ret := _m.Called(record)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Append")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(domain.AuditRecord) error); ok {
+		r0 = rf(record)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ListByAd provides a mock function with given fields: adID
+func (_m *AuditStore) ListByAd(adID string) ([]domain.AuditRecord, error) {
+	ret := _m.Called(adID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListByAd")
+	}
+
+	var r0 []domain.AuditRecord
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]domain.AuditRecord, error)); ok {
+		return rf(adID)
+	}
+	if rf, ok := ret.Get(0).(func(string) []domain.AuditRecord); ok {
+		r0 = rf(adID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.AuditRecord)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(adID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewAuditStore creates a new instance of AuditStore. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewAuditStore(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *AuditStore {
+	mock := &AuditStore{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,60 @@
+// Code generated by mockery v2.53.7. DO NOT EDIT.
+
+package mocks
+
+import (
+	domain "ads_backend/internal/domain"
+
+	mock "github.com/stretchr/testify/mock"
+
+	search "ads_backend/internal/search"
+)
+
+// Index is an autogenerated mock type for the Index type
+type Index struct {
+	mock.Mock
+}
+
+// Remove provides a mock function with given fields: id
+func (_m *Index) Remove(id string) {
+	_m.Called(id)
+}
+
+// Search provides a mock function with given fields: query
+func (_m *Index) Search(query string) []search.Hit {
+	ret := _m.Called(query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Search")
+	}
+
+	var r0 []search.Hit
+	if rf, ok := ret.Get(0).(func(string) []search.Hit); ok {
+		r0 = rf(query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]search.Hit)
+		}
+	}
+
+	return r0
+}
+
+// Upsert provides a mock function with given fields: ad
+func (_m *Index) Upsert(ad domain.Ad) {
+	_m.Called(ad)
+}
+
+// NewIndex creates a new instance of Index. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewIndex(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Index {
+	mock := &Index{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
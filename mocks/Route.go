@@ -0,0 +1,70 @@
+// Code generated by mockery v2.53.7. DO NOT EDIT.
+
+package mocks
+
+import (
+	auth "ads_backend/internal/auth"
+	http "net/http"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// Route is an autogenerated mock type for the Route type
+type Route struct {
+	mock.Mock
+}
+
+// Pattern provides a mock function with no fields
+func (_m *Route) Pattern() string {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Pattern")
+	}
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+// Permission provides a mock function with given fields: r
+func (_m *Route) Permission(r *http.Request) auth.Permission {
+	ret := _m.Called(r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Permission")
+	}
+
+	var r0 auth.Permission
+	if rf, ok := ret.Get(0).(func(*http.Request) auth.Permission); ok {
+		r0 = rf(r)
+	} else {
+		r0 = ret.Get(0).(auth.Permission)
+	}
+
+	return r0
+}
+
+// ServeHTTP provides a mock function with given fields: _a0, _a1
+func (_m *Route) ServeHTTP(_a0 http.ResponseWriter, _a1 *http.Request) {
+	_m.Called(_a0, _a1)
+}
+
+// NewRoute creates a new instance of Route. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewRoute(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Route {
+	mock := &Route{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
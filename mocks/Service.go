@@ -0,0 +1,453 @@
+// Code generated by mockery v2.53.7. DO NOT EDIT.
+
+package mocks
+
+import (
+	domain "ads_backend/internal/domain"
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// Service is an autogenerated mock type for the Service type
+type Service struct {
+	mock.Mock
+}
+
+// CreateAd provides a mock function with given fields: ctx, ad
+func (_m *Service) CreateAd(ctx context.Context, ad domain.Ad) (domain.Ad, error) {
+	ret := _m.Called(ctx, ad)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateAd")
+	}
+
+	var r0 domain.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Ad) (domain.Ad, error)); ok {
+		return rf(ctx, ad)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Ad) domain.Ad); ok {
+		r0 = rf(ctx, ad)
+	} else {
+		r0 = ret.Get(0).(domain.Ad)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.Ad) error); ok {
+		r1 = rf(ctx, ad)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateAds provides a mock function with given fields: ctx, ads, atomic
+func (_m *Service) CreateAds(ctx context.Context, ads []domain.Ad, atomic bool) ([]domain.BatchResult, error) {
+	ret := _m.Called(ctx, ads, atomic)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateAds")
+	}
+
+	var r0 []domain.BatchResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []domain.Ad, bool) ([]domain.BatchResult, error)); ok {
+		return rf(ctx, ads, atomic)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []domain.Ad, bool) []domain.BatchResult); ok {
+		r0 = rf(ctx, ads, atomic)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.BatchResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []domain.Ad, bool) error); ok {
+		r1 = rf(ctx, ads, atomic)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeactivateAd provides a mock function with given fields: ctx, id
+func (_m *Service) DeactivateAd(ctx context.Context, id string) (domain.Ad, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeactivateAd")
+	}
+
+	var r0 domain.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (domain.Ad, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) domain.Ad); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(domain.Ad)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeactivateAds provides a mock function with given fields: ctx, ids, atomic
+func (_m *Service) DeactivateAds(ctx context.Context, ids []string, atomic bool) ([]domain.BatchResult, error) {
+	ret := _m.Called(ctx, ids, atomic)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeactivateAds")
+	}
+
+	var r0 []domain.BatchResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []string, bool) ([]domain.BatchResult, error)); ok {
+		return rf(ctx, ids, atomic)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []string, bool) []domain.BatchResult); ok {
+		r0 = rf(ctx, ids, atomic)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.BatchResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []string, bool) error); ok {
+		r1 = rf(ctx, ids, atomic)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DiffAdVersions provides a mock function with given fields: ctx, id, from, to
+func (_m *Service) DiffAdVersions(ctx context.Context, id string, from int, to int) ([]domain.FieldChange, error) {
+	ret := _m.Called(ctx, id, from, to)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DiffAdVersions")
+	}
+
+	var r0 []domain.FieldChange
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) ([]domain.FieldChange, error)); ok {
+		return rf(ctx, id, from, to)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) []domain.FieldChange); ok {
+		r0 = rf(ctx, id, from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.FieldChange)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int, int) error); ok {
+		r1 = rf(ctx, id, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ExportAds provides a mock function with given fields: ctx, query, fn
+func (_m *Service) ExportAds(ctx context.Context, query domain.AdQuery, fn func(domain.Ad) error) error {
+	ret := _m.Called(ctx, query, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExportAds")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.AdQuery, func(domain.Ad) error) error); ok {
+		r0 = rf(ctx, query, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetAd provides a mock function with given fields: ctx, id
+func (_m *Service) GetAd(ctx context.Context, id string) (domain.Ad, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAd")
+	}
+
+	var r0 domain.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (domain.Ad, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) domain.Ad); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(domain.Ad)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAdHistory provides a mock function with given fields: ctx, id
+func (_m *Service) GetAdHistory(ctx context.Context, id string) ([]domain.AuditRecord, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAdHistory")
+	}
+
+	var r0 []domain.AuditRecord
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]domain.AuditRecord, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []domain.AuditRecord); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.AuditRecord)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAdVersion provides a mock function with given fields: ctx, id, version
+func (_m *Service) GetAdVersion(ctx context.Context, id string, version int) (domain.AdVersion, error) {
+	ret := _m.Called(ctx, id, version)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAdVersion")
+	}
+
+	var r0 domain.AdVersion
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) (domain.AdVersion, error)); ok {
+		return rf(ctx, id, version)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) domain.AdVersion); ok {
+		r0 = rf(ctx, id, version)
+	} else {
+		r0 = ret.Get(0).(domain.AdVersion)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int) error); ok {
+		r1 = rf(ctx, id, version)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListAdVersions provides a mock function with given fields: ctx, id
+func (_m *Service) ListAdVersions(ctx context.Context, id string) ([]domain.AdVersion, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListAdVersions")
+	}
+
+	var r0 []domain.AdVersion
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]domain.AdVersion, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []domain.AdVersion); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.AdVersion)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListAds provides a mock function with given fields: ctx, query
+func (_m *Service) ListAds(ctx context.Context, query domain.AdQuery) (domain.AdPage, error) {
+	ret := _m.Called(ctx, query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListAds")
+	}
+
+	var r0 domain.AdPage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.AdQuery) (domain.AdPage, error)); ok {
+		return rf(ctx, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.AdQuery) domain.AdPage); ok {
+		r0 = rf(ctx, query)
+	} else {
+		r0 = ret.Get(0).(domain.AdPage)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.AdQuery) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListEligibleActiveAdsByPlacement provides a mock function with given fields: ctx, placement
+func (_m *Service) ListEligibleActiveAdsByPlacement(ctx context.Context, placement domain.Placement) ([]domain.Ad, error) {
+	ret := _m.Called(ctx, placement)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListEligibleActiveAdsByPlacement")
+	}
+
+	var r0 []domain.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Placement) ([]domain.Ad, error)); ok {
+		return rf(ctx, placement)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Placement) []domain.Ad); ok {
+		r0 = rf(ctx, placement)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Ad)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.Placement) error); ok {
+		r1 = rf(ctx, placement)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RollbackAd provides a mock function with given fields: ctx, id, version
+func (_m *Service) RollbackAd(ctx context.Context, id string, version int) (domain.Ad, error) {
+	ret := _m.Called(ctx, id, version)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RollbackAd")
+	}
+
+	var r0 domain.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) (domain.Ad, error)); ok {
+		return rf(ctx, id, version)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) domain.Ad); ok {
+		r0 = rf(ctx, id, version)
+	} else {
+		r0 = ret.Get(0).(domain.Ad)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int) error); ok {
+		r1 = rf(ctx, id, version)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SearchAds provides a mock function with given fields: ctx, query, status, limit
+func (_m *Service) SearchAds(ctx context.Context, query string, status domain.Status, limit int) ([]domain.AdSearchResult, error) {
+	ret := _m.Called(ctx, query, status, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SearchAds")
+	}
+
+	var r0 []domain.AdSearchResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, domain.Status, int) ([]domain.AdSearchResult, error)); ok {
+		return rf(ctx, query, status, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, domain.Status, int) []domain.AdSearchResult); ok {
+		r0 = rf(ctx, query, status, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.AdSearchResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, domain.Status, int) error); ok {
+		r1 = rf(ctx, query, status, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateAd provides a mock function with given fields: ctx, id, update
+func (_m *Service) UpdateAd(ctx context.Context, id string, update domain.AdUpdate) (domain.Ad, error) {
+	ret := _m.Called(ctx, id, update)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateAd")
+	}
+
+	var r0 domain.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, domain.AdUpdate) (domain.Ad, error)); ok {
+		return rf(ctx, id, update)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, domain.AdUpdate) domain.Ad); ok {
+		r0 = rf(ctx, id, update)
+	} else {
+		r0 = ret.Get(0).(domain.Ad)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, domain.AdUpdate) error); ok {
+		r1 = rf(ctx, id, update)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewService creates a new instance of Service. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Service {
+	mock := &Service{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,114 @@
+// Code generated by mockery v2.53.7. DO NOT EDIT.
+
+package mocks
+
+import (
+	domain "ads_backend/internal/domain"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// VersionStore is an autogenerated mock type for the VersionStore type
+type VersionStore struct {
+	mock.Mock
+}
+
+// Append provides a mock function with given fields: version
+func (_m *VersionStore) Append(version domain.AdVersion) (domain.AdVersion, error) {
+	ret := _m.Called(version)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Append")
+	}
+
+	var r0 domain.AdVersion
+	var r1 error
+	if rf, ok := ret.Get(0).(func(domain.AdVersion) (domain.AdVersion, error)); ok {
+		return rf(version)
+	}
+	if rf, ok := ret.Get(0).(func(domain.AdVersion) domain.AdVersion); ok {
+		r0 = rf(version)
+	} else {
+		r0 = ret.Get(0).(domain.AdVersion)
+	}
+
+	if rf, ok := ret.Get(1).(func(domain.AdVersion) error); ok {
+		r1 = rf(version)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Get provides a mock function with given fields: adID, version
+func (_m *VersionStore) Get(adID string, version int) (domain.AdVersion, error) {
+	ret := _m.Called(adID, version)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Get")
+	}
+
+	var r0 domain.AdVersion
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, int) (domain.AdVersion, error)); ok {
+		return rf(adID, version)
+	}
+	if rf, ok := ret.Get(0).(func(string, int) domain.AdVersion); ok {
+		r0 = rf(adID, version)
+	} else {
+		r0 = ret.Get(0).(domain.AdVersion)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, int) error); ok {
+		r1 = rf(adID, version)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// List provides a mock function with given fields: adID
+func (_m *VersionStore) List(adID string) ([]domain.AdVersion, error) {
+	ret := _m.Called(adID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for List")
+	}
+
+	var r0 []domain.AdVersion
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]domain.AdVersion, error)); ok {
+		return rf(adID)
+	}
+	if rf, ok := ret.Get(0).(func(string) []domain.AdVersion); ok {
+		r0 = rf(adID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.AdVersion)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(adID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewVersionStore creates a new instance of VersionStore. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewVersionStore(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *VersionStore {
+	mock := &VersionStore{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
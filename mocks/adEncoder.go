@@ -0,0 +1,64 @@
+// Code generated by mockery v2.53.7. DO NOT EDIT.
+
+package mocks
+
+import (
+	domain "ads_backend/internal/domain"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// adEncoder is an autogenerated mock type for the adEncoder type
+type adEncoder struct {
+	mock.Mock
+}
+
+// Encode provides a mock function with given fields: ad
+func (_m *adEncoder) Encode(ad domain.Ad) error {
+	ret := _m.Called(ad)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Encode")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(domain.Ad) error); ok {
+		r0 = rf(ad)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Flush provides a mock function with no fields
+func (_m *adEncoder) Flush() error {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Flush")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// newAdEncoder creates a new instance of adEncoder. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newAdEncoder(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *adEncoder {
+	mock := &adEncoder{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}